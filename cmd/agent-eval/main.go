@@ -0,0 +1,223 @@
+// Command agent-eval runs a JSONL dataset through an agent, scores the
+// answers and writes a JSON and Markdown report. Given a baseline report it
+// lists regressions and exits with status 1 if there are any.
+//
+// Usage:
+//
+//	agent-eval -dataset configs/eval.example.jsonl -agent react -out report.json -markdown report.md
+//	agent-eval -dataset configs/eval.example.jsonl -agent react -baseline report.json -judge
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/config"
+	"github.com/hassan123789/go-ai-agent/internal/eval"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func main() {
+	var (
+		datasetPath  = flag.String("dataset", "", "JSONL dataset of cases (required)")
+		agentName    = flag.String("agent", "react", "agent to evaluate: react, reflexion, orchestrator, self_consistency, debate, or a name from AGENTS_FILE")
+		scorerNames  = flag.String("scorers", "fuzzy,tools", "comma-separated scorers: exact, fuzzy, tools, judge")
+		judge        = flag.Bool("judge", false, "add the LLM judge scorer")
+		judgeModel   = flag.String("judge-model", "", "model for the LLM judge; default is OPENAI_MODEL")
+		concurrency  = flag.Int("concurrency", 4, "cases run at once")
+		timeout      = flag.Duration("timeout", 2*time.Minute, "timeout per case")
+		threshold    = flag.Float64("threshold", 0.7, "mean score a case needs to pass")
+		outPath      = flag.String("out", "", "write the JSON report to this file")
+		markdownPath = flag.String("markdown", "", "write the Markdown report to this file")
+		baselinePath = flag.String("baseline", "", "JSON report of a previous run to compare against")
+		tolerance    = flag.Float64("tolerance", 0.02, "allowed drop in summary scores before it counts as a regression")
+		verbose      = flag.Bool("verbose", false, "log each case")
+	)
+	flag.Parse()
+
+	if *datasetPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	cases, err := eval.LoadDataset(*datasetPath)
+	if err != nil {
+		log.Fatalf("Failed to load dataset: %v", err)
+	}
+
+	// Read the baseline first so a bad path fails before any model calls
+	var baseline *eval.Report
+	if *baselinePath != "" {
+		baseline, err = eval.ReadReport(*baselinePath)
+		if err != nil {
+			log.Fatalf("Failed to load baseline: %v", err)
+		}
+	}
+
+	llmClient, err := llm.NewOpenAIClient(llm.OpenAIConfig{
+		APIKey:    cfg.OpenAIAPIKey,
+		Model:     cfg.OpenAIModel,
+		MaxTokens: cfg.OpenAIMaxToken,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM client: %v", err)
+	}
+	defer func() {
+		if err := llmClient.Close(); err != nil {
+			log.Printf("Failed to close LLM client: %v", err)
+		}
+	}()
+
+	target, err := buildAgent(*agentName, llmClient, cfg, *verbose)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+
+	names := strings.Split(*scorerNames, ",")
+	if *judge {
+		names = append(names, "judge")
+	}
+	scorers, err := buildScorers(names, llmClient, *judgeModel)
+	if err != nil {
+		log.Fatalf("Invalid scorers: %v", err)
+	}
+
+	runner := &eval.Runner{
+		Agent:         target,
+		Scorers:       scorers,
+		Concurrency:   *concurrency,
+		Timeout:       *timeout,
+		PassThreshold: *threshold,
+		Verbose:       *verbose,
+	}
+	log.Printf("Evaluating %s on %d cases from %s", *agentName, len(cases), *datasetPath)
+	report := runner.Run(context.Background(), cases)
+	report.Agent = *agentName
+	report.Dataset = *datasetPath
+
+	var regressions []eval.Regression
+	if baseline != nil {
+		regressions = eval.Compare(baseline, report, *tolerance)
+	}
+
+	if *outPath != "" {
+		if err := report.WriteJSON(*outPath); err != nil {
+			log.Fatalf("Failed to write report: %v", err)
+		}
+	}
+	markdown := report.Markdown(regressions)
+	if *markdownPath != "" {
+		if err := os.WriteFile(*markdownPath, []byte(markdown), 0o644); err != nil {
+			log.Fatalf("Failed to write Markdown report: %v", err)
+		}
+	}
+
+	s := report.Summary
+	fmt.Printf("%d/%d passed (%.1f%%), mean score %.3f, %d errors, %d tokens\n",
+		s.Passed, s.Cases, s.PassRate*100, s.MeanScore, s.Errors, s.TotalTokens)
+	if len(regressions) > 0 {
+		fmt.Printf("%d regressions against %s:\n", len(regressions), *baselinePath)
+		for _, r := range regressions {
+			fmt.Printf("  %s\n", r)
+		}
+		os.Exit(1)
+	}
+}
+
+// buildAgent creates the named agent the same way the server does.
+func buildAgent(name string, client agent.LLMClient, cfg *config.Config, verbose bool) (agent.Agent, error) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	base := agent.Config{MaxIterations: 10, Verbose: verbose}
+
+	switch name {
+	case "react":
+		return agent.NewReActAgent(client, registry, base), nil
+	case "reflexion":
+		return agent.NewReflexionAgent(client, registry, agent.ReflexionConfig{
+			Config:           base,
+			MaxReflections:   3,
+			QualityThreshold: 8.0,
+		}), nil
+	case "orchestrator":
+		return agent.NewOrchestratorAgent(client, registry, agent.OrchestratorConfig{
+			Config:     base,
+			MaxWorkers: 5,
+		}), nil
+	case "self_consistency":
+		return agent.NewSelfConsistencyAgent(client, registry, agent.SelfConsistencyConfig{
+			Config:  base,
+			Samples: 5,
+		}), nil
+	case "debate":
+		debate := agent.NewGroupChatAgent(client, registry, agent.GroupChatConfig{Config: base})
+		for _, m := range agent.DebateMembers() {
+			debate.AddMember(m)
+		}
+		return debate, nil
+	}
+
+	if cfg.AgentsFile == "" {
+		return nil, fmt.Errorf("unknown agent %q", name)
+	}
+	definitions, err := agent.LoadDefinitions(cfg.AgentsFile)
+	if err != nil {
+		return nil, err
+	}
+	agents, err := definitions.Build(agent.BuildEnv{
+		Clients:         map[string]agent.LLMClient{string(llm.ProviderOpenAI): client},
+		DefaultProvider: string(llm.ProviderOpenAI),
+		Tools:           registry,
+		Verbose:         verbose,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a, ok := agents[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown agent %q (not built in or defined in %s)", name, cfg.AgentsFile)
+	}
+	return a, nil
+}
+
+// buildScorers creates scorers by name, ignoring duplicates.
+func buildScorers(names []string, client agent.LLMClient, judgeModel string) ([]eval.Scorer, error) {
+	var scorers []eval.Scorer
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case "exact":
+			scorers = append(scorers, eval.ExactMatch{})
+		case "fuzzy":
+			scorers = append(scorers, eval.FuzzyMatch{})
+		case "tools":
+			scorers = append(scorers, eval.ToolCalls{})
+		case "judge":
+			scorers = append(scorers, eval.NewLLMJudge(client, judgeModel))
+		default:
+			return nil, fmt.Errorf("unknown scorer %q", name)
+		}
+	}
+	if len(scorers) == 0 {
+		return nil, fmt.Errorf("no scorers selected")
+	}
+	return scorers, nil
+}
@@ -0,0 +1,152 @@
+// Command agent-replay re-runs a trace recorded with RECORD_DIR without
+// network access: every model call and tool execution is answered from the
+// trace. It prints the replayed answer and steps and exits with status 1 if
+// the answer differs from the recorded one.
+//
+// Usage:
+//
+//	agent-replay -trace traces/reflexion-20260101T120000.000-1a2b3c4d.json
+//	agent-replay -trace traces/agent-20260101T120000.000-1a2b3c4d.json -strict -verbose
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/replay"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func main() {
+	var (
+		tracePath = flag.String("trace", "", "trace file to replay (required)")
+		agentName = flag.String("agent", "", "agent to replay with: react, reflexion, orchestrator, plan-execute, self_consistency, debate, or a name from AGENTS_FILE; default is the recorded agent")
+		strict    = flag.Bool("strict", false, "fail on any call the trace does not contain instead of following the recording")
+		verbose   = flag.Bool("verbose", false, "log agent steps as they replay")
+	)
+	flag.Parse()
+
+	if *tracePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	trace, err := replay.Load(*tracePath)
+	if err != nil {
+		log.Fatalf("Failed to load trace: %v", err)
+	}
+
+	name := *agentName
+	if name == "" {
+		name = trace.Agent
+	}
+	a, err := buildAgent(name, replay.Offline(), os.Getenv("AGENTS_FILE"), *verbose)
+	if err != nil {
+		log.Fatalf("Failed to build agent: %v", err)
+	}
+
+	resp, stats, err := replay.Replay(context.Background(), trace, a, *strict)
+	if err != nil {
+		log.Fatalf("Replay failed after %d of %d events: %v", stats.Used, stats.Events, err)
+	}
+
+	fmt.Printf("Trace:  %s (%s, recorded %s)\n", trace.ID, trace.Agent, trace.RecordedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Query:  %s\n\n", trace.Query)
+	for i, step := range resp.Steps {
+		fmt.Printf("%2d. [%s] %s\n", i+1, step.Type, step.Content)
+		if step.ToolName != "" {
+			fmt.Printf("    %s(%s)\n", step.ToolName, step.ToolInput)
+		}
+	}
+	fmt.Printf("\nOutput: %s\n", resp.Output)
+	fmt.Printf("Events: %d used of %d, %d misses\n", stats.Used, stats.Events, stats.Misses)
+
+	if trace.Error != "" {
+		fmt.Printf("Recorded run failed: %s\n", trace.Error)
+	}
+	if resp.Output != trace.Output {
+		fmt.Printf("\nDIVERGED: recorded output was\n%s\n", trace.Output)
+		os.Exit(1)
+	}
+}
+
+// buildAgent creates an agent configured as the server builds it. Guardrails
+// and Reflexion's semantic memory are left out, since they call services
+// outside the agent's LLM client.
+func buildAgent(name string, client agent.LLMClient, agentsFile string, verbose bool) (agent.Agent, error) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	base := agent.Config{MaxIterations: 10, Verbose: verbose}
+
+	var definitions *agent.Definitions
+	if agentsFile != "" {
+		var err error
+		definitions, err = agent.LoadDefinitions(agentsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	buildEnv := agent.BuildEnv{
+		Clients:         map[string]agent.LLMClient{string(llm.ProviderOpenAI): client},
+		DefaultProvider: string(llm.ProviderOpenAI),
+		Tools:           registry,
+		Verbose:         verbose,
+	}
+
+	switch name {
+	case "react", "agent":
+		return agent.NewReActAgent(client, registry, base), nil
+	case "reflexion":
+		return agent.NewReflexionAgent(client, registry, agent.ReflexionConfig{
+			Config:           base,
+			MaxReflections:   3,
+			QualityThreshold: 8.0,
+		}), nil
+	case "orchestrator":
+		orchestrator := agent.NewOrchestratorAgent(client, registry, agent.OrchestratorConfig{
+			Config:     base,
+			MaxWorkers: 5,
+		})
+		if definitions != nil {
+			for _, w := range definitions.BuildWorkers(buildEnv) {
+				orchestrator.RegisterWorker(w)
+			}
+		}
+		return orchestrator, nil
+	case "plan-execute":
+		return agent.NewPlanExecuteAgent(client, registry, agent.PlanExecuteConfig{
+			Config:     base,
+			MaxSteps:   10,
+			MaxReplans: 3,
+		}), nil
+	case "self_consistency":
+		return agent.NewSelfConsistencyAgent(client, registry, agent.SelfConsistencyConfig{
+			Config:  base,
+			Samples: 5,
+		}), nil
+	case "debate":
+		debate := agent.NewGroupChatAgent(client, registry, agent.GroupChatConfig{Config: base})
+		for _, m := range agent.DebateMembers() {
+			debate.AddMember(m)
+		}
+		return debate, nil
+	}
+
+	if definitions == nil {
+		return nil, fmt.Errorf("unknown agent %q", name)
+	}
+	agents, err := definitions.Build(buildEnv)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := agents[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown agent %q (not built in or defined in %s)", name, agentsFile)
+	}
+	return a, nil
+}
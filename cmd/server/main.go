@@ -6,17 +6,25 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
 	"github.com/hassan123789/go-ai-agent/internal/agent"
 	"github.com/hassan123789/go-ai-agent/internal/config"
+	"github.com/hassan123789/go-ai-agent/internal/embedding"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
 	"github.com/hassan123789/go-ai-agent/internal/handler"
 	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
+	"github.com/hassan123789/go-ai-agent/internal/rag"
+	"github.com/hassan123789/go-ai-agent/internal/telemetry"
 	"github.com/hassan123789/go-ai-agent/internal/tools"
+	"github.com/hassan123789/go-ai-agent/internal/vectorstore"
 )
 
 func main() {
@@ -26,6 +34,18 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Initialize tracing before any instrumented client is used
+	shutdownTracing, err := telemetry.Setup(context.Background(), telemetry.Config{
+		Exporter:    cfg.TraceExporter,
+		Endpoint:    cfg.TraceEndpoint,
+		Insecure:    cfg.TraceInsecure,
+		ServiceName: cfg.ServiceName,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	// Initialize LLM client
 	llmClient, err := llm.NewOpenAIClient(llm.OpenAIConfig{
 		APIKey:    cfg.OpenAIAPIKey,
@@ -59,55 +79,296 @@ func main() {
 		},
 	}))
 	e.Use(middleware.Recover())
+	e.Use(otelecho.Middleware(cfg.ServiceName))
 	e.Use(middleware.CORS())
 	e.Use(middleware.RequestID())
 
+	// Conversation sessions shared by the session-aware endpoints
+	sessions := memory.NewConversationManager(memory.BufferConfig{
+		MaxSize: cfg.SessionMaxMessages,
+	})
+
+	// Hedge chat requests to a second provider when configured; agents need
+	// function calling and stay on OpenAI
+	chatBase := llm.Client(llmClient)
+	var hedged *llm.HedgedClient
+	if cfg.HedgeProvider != "" {
+		providers, err := llm.NewMultiProvider(llm.MultiProviderConfig{
+			Providers: []llm.ProviderConfig{
+				{Provider: llm.ProviderOpenAI, APIKey: cfg.OpenAIAPIKey, Model: cfg.OpenAIModel, MaxTokens: cfg.OpenAIMaxToken},
+				{Provider: llm.Provider(cfg.HedgeProvider), APIKey: cfg.AnthropicAPIKey, Model: cfg.HedgeModel, MaxTokens: cfg.OpenAIMaxToken, BaseURL: cfg.OllamaBaseURL},
+			},
+			Primary:  llm.ProviderOpenAI,
+			Fallback: llm.Provider(cfg.HedgeProvider),
+		})
+		if err != nil {
+			log.Fatalf("Failed to create hedge provider: %v", err)
+		}
+		defer func() {
+			if err := providers.Close(); err != nil {
+				log.Printf("Failed to close hedge providers: %v", err)
+			}
+		}()
+		hedged, err = llm.NewHedgedClient(providers, llm.HedgeConfig{Delay: cfg.HedgeDelay})
+		if err != nil {
+			log.Fatalf("Failed to create hedged client: %v", err)
+		}
+		chatBase = hedged
+		log.Printf("Hedging chat requests to %s after %v", cfg.HedgeProvider, cfg.HedgeDelay)
+	}
+
+	// Initialize guardrails. Cheap checks run on every LLM call and on user
+	// messages saved to sessions; banned-topic classification costs a model
+	// call, so it runs once per chat request and on agent queries and answers
+	// only.
+	var guardrails, redaction *guardrail.Pipeline
+	chatClient := chatBase
+	agentClient := agent.LLMClient(llmClient)
+	if cfg.GuardrailsEnabled {
+		injection, err := guardrail.NewPromptInjection()
+		if err != nil {
+			log.Fatalf("Failed to create guardrails: %v", err)
+		}
+		rules := []guardrail.Rule{
+			{Guardrail: injection, Action: guardrail.ActionBlock},
+			{Guardrail: guardrail.NewPII(), Action: guardrail.ActionRedact},
+			{Guardrail: guardrail.NewMaxLength(cfg.GuardrailMaxInputChars, 0), Action: guardrail.ActionBlock},
+		}
+		redaction = guardrail.NewPipeline(rules...)
+		agentClient = guardrail.NewClient(llmClient, redaction)
+
+		if len(cfg.GuardrailBannedTopics) > 0 {
+			rules = append(rules, guardrail.Rule{
+				Guardrail: guardrail.NewBannedTopics(guardrail.NewLLMClassifier(llmClient, ""), cfg.GuardrailBannedTopics...),
+				Action:    guardrail.ActionBlock,
+			})
+		}
+		guardrails = guardrail.NewPipeline(rules...)
+		chatClient = guardrail.NewClient(chatBase, guardrails)
+	}
+
 	// Initialize handlers
-	chatHandler := handler.NewChatHandler(llmClient)
+	chatHandler := handler.NewChatHandler(chatClient, sessions)
 
 	// Initialize tool registry
 	toolRegistry := tools.NewRegistry()
 	toolRegistry.MustRegister(tools.NewCalculator())
 
 	// Initialize ReAct agent
-	reactAgent := agent.NewReActAgent(llmClient, toolRegistry, agent.Config{
+	reactAgent := agent.NewReActAgent(agentClient, toolRegistry, agent.Config{
 		MaxIterations: 10,
 		Verbose:       cfg.IsDevelopment(),
+		Guardrails:    guardrails,
+	})
+
+	// Initialize Reflexion memory (semantic retrieval over past reflections)
+	embedder, err := embedding.NewOpenAIEmbedder(embedding.DefaultOpenAIConfig(cfg.OpenAIAPIKey))
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	reflectionMemory, err := agent.NewReflectionMemory(context.Background(), agent.ReflectionMemoryConfig{
+		Semantic: vectorstore.NewMemoryStore(embedder),
+		Path:     cfg.ReflectionsFile,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load reflections: %v", err)
+	}
+
+	// Initialize the knowledge base for /api/rag/query; loaded documents are
+	// also searchable by agents through the knowledge_search tool
+	knowledgeBase := vectorstore.NewMemoryStore(embedder)
+	if cfg.KnowledgeDir != "" {
+		docs, err := rag.LoadDirectory(cfg.KnowledgeDir, cfg.KnowledgeChunkSize)
+		if err != nil {
+			log.Fatalf("Failed to load knowledge base: %v", err)
+		}
+		if err := knowledgeBase.AddTexts(context.Background(), docs); err != nil {
+			log.Fatalf("Failed to index knowledge base: %v", err)
+		}
+		toolRegistry.MustRegister(rag.NewKnowledgeSearch(rag.NewStoreRetriever(knowledgeBase), 4))
+		log.Printf("Indexed %d knowledge base chunks from %s", len(docs), cfg.KnowledgeDir)
+	}
+	qaChain := rag.NewQAChain(agent.MeteredClient(chatClient), rag.NewStoreRetriever(knowledgeBase), rag.QAConfig{
+		MinScore: 0.2,
+		Verbose:  cfg.IsDevelopment(),
 	})
 
 	// Initialize Reflexion agent (self-improving with evaluation loop)
-	reflexionAgent := agent.NewReflexionAgent(llmClient, toolRegistry, agent.ReflexionConfig{
+	reflexionAgent := agent.NewReflexionAgent(agentClient, toolRegistry, agent.ReflexionConfig{
 		Config: agent.Config{
 			MaxIterations: 10,
 			Verbose:       cfg.IsDevelopment(),
+			Guardrails:    guardrails,
 		},
 		MaxReflections:   3,
 		QualityThreshold: 8.0,
+		Memory:           reflectionMemory,
+		Evaluators: map[string]agent.Evaluator{
+			"llm": agent.NewLLMJudge(llmClient, agent.LLMJudgeConfig{}),
+			"ensemble": agent.NewEnsembleEvaluator([]agent.Evaluator{
+				agent.NewLLMJudge(llmClient, agent.LLMJudgeConfig{Temperature: 0.2}),
+				agent.NewLLMJudge(llmClient, agent.LLMJudgeConfig{Temperature: 0.6}),
+				agent.NewLLMJudge(llmClient, agent.LLMJudgeConfig{Temperature: 1.0}),
+			}, agent.AggregateMedian),
+			"reference":  agent.NewReferenceEvaluator(embedder),
+			"calculator": agent.NewToolVerifier(toolRegistry, []string{"calculator"}),
+		},
 	})
 
 	// Initialize Orchestrator agent (multi-agent task decomposition)
-	orchestratorAgent := agent.NewOrchestratorAgent(llmClient, toolRegistry, agent.OrchestratorConfig{
+	orchestratorAgent := agent.NewOrchestratorAgent(agentClient, toolRegistry, agent.OrchestratorConfig{
 		Config: agent.Config{
 			MaxIterations: 10,
 			Verbose:       cfg.IsDevelopment(),
+			Guardrails:    guardrails,
 		},
 		MaxWorkers: 5,
 	})
 
+	// Initialize Plan-and-Execute agent (plans, executes, and replans after each step)
+	planExecuteAgent := agent.NewPlanExecuteAgent(agentClient, toolRegistry, agent.PlanExecuteConfig{
+		Config: agent.Config{
+			MaxIterations: 10,
+			Verbose:       cfg.IsDevelopment(),
+			Guardrails:    guardrails,
+		},
+		MaxSteps:   10,
+		MaxReplans: 3,
+	})
+
+	// Load declarative agent definitions; built-in and defined workers join the default orchestrator
+	definitions := &agent.Definitions{}
+	if cfg.AgentsFile != "" {
+		definitions, err = agent.LoadDefinitions(cfg.AgentsFile)
+		if err != nil {
+			log.Fatalf("Failed to load agent definitions: %v", err)
+		}
+	}
+	buildEnv := agent.BuildEnv{
+		Clients:         map[string]agent.LLMClient{string(llm.ProviderOpenAI): agentClient},
+		DefaultProvider: string(llm.ProviderOpenAI),
+		Tools:           toolRegistry,
+		Verbose:         cfg.IsDevelopment(),
+		Guardrails:      guardrails,
+	}
+	definedAgents, err := definitions.Build(buildEnv)
+	if err != nil {
+		log.Fatalf("Invalid agent definitions: %v", err)
+	}
+	for _, w := range definitions.BuildWorkers(buildEnv) {
+		orchestratorAgent.RegisterWorker(w)
+	}
+	if len(definedAgents) > 0 {
+		log.Printf("Loaded %d agent definitions from %s", len(definedAgents), cfg.AgentsFile)
+	}
+
+	// Initialize self-consistency agent (votes over parallel ReAct samples)
+	selfConsistencyAgent := agent.NewSelfConsistencyAgent(agentClient, toolRegistry, agent.SelfConsistencyConfig{
+		Config: agent.Config{
+			MaxIterations: 10,
+			Verbose:       cfg.IsDevelopment(),
+			Guardrails:    guardrails,
+		},
+		Samples: 5,
+	})
+
+	// Initialize debate (proposer, critic and judge take turns until the judge decides)
+	debateAgent := agent.NewGroupChatAgent(agentClient, toolRegistry, agent.GroupChatConfig{
+		Config: agent.Config{
+			Verbose:    cfg.IsDevelopment(),
+			Guardrails: guardrails,
+		},
+		MaxTurns: 9,
+	})
+	for _, m := range agent.DebateMembers() {
+		debateAgent.AddMember(m)
+	}
+
+	// Initialize router agent (picks a strategy per query for /api/auto)
+	routerAgent := agent.NewRouterAgent(agentClient, agent.RouterConfig{
+		Config:   agent.Config{Verbose: cfg.IsDevelopment()},
+		Fallback: "react",
+	})
+	routerAgent.Register(agent.Route{
+		Name:        "react",
+		Description: "Direct questions and calculations answered with a few tool calls.",
+		Agent:       reactAgent,
+		Patterns:    []*regexp.Regexp{regexp.MustCompile(`^[\d\s+\-*/().%^]+$`)},
+	})
+	routerAgent.Register(agent.Route{
+		Name:        "reflexion",
+		Description: "Answers where quality matters, such as explanations, code or writing, refined through self-critique.",
+		Agent:       reflexionAgent,
+	})
+	routerAgent.Register(agent.Route{
+		Name:        "self_consistency",
+		Description: "Questions with a single correct answer, such as word problems or classification, answered by voting over several attempts.",
+		Agent:       selfConsistencyAgent,
+	})
+	routerAgent.Register(agent.Route{
+		Name:        "debate",
+		Description: "Contested or open-ended questions that benefit from arguing for and against before a judge decides.",
+		Agent:       debateAgent,
+	})
+	routerAgent.Register(agent.Route{
+		Name:        "orchestrator",
+		Description: "Complex tasks with several independent parts, decomposed and run by specialized workers.",
+		Agent:       orchestratorAgent,
+	})
+	for _, def := range definitions.Agents {
+		routerAgent.Register(agent.Route{
+			Name:        def.Name,
+			Description: def.Description,
+			Agent:       definedAgents[def.Name],
+		})
+	}
+
 	// Initialize handlers
-	agentHandler := handler.NewAgentHandler(reactAgent)
-	reflexionHandler := handler.NewReflexionHandler(reflexionAgent)
-	orchestratorHandler := handler.NewOrchestratorHandler(orchestratorAgent)
+	agentHandler := handler.NewAgentHandler(reactAgent, sessions)
+	reflexionHandler := handler.NewReflexionHandler(reflexionAgent, sessions)
+	orchestratorHandler := handler.NewOrchestratorHandler(orchestratorAgent, sessions)
+	planExecuteHandler := handler.NewPlanExecuteHandler(planExecuteAgent)
+	autoHandler := handler.NewAgentHandler(routerAgent, sessions)
+	agentsHandler := handler.NewAgentsHandler(definedAgents, definitions.Agents, sessions)
+	sessionsHandler := handler.NewSessionsHandler(sessions)
+	ragHandler := handler.NewRAGHandler(qaChain)
 
 	// Routes
 	e.GET("/health", chatHandler.Health)
 
-	api := e.Group("/api")
+	// Agent runs share these limits unless a request sets tighter ones;
+	// calls are priced at the configured model's rates
+	api := e.Group("/api", handler.BudgetDefaults(agent.BudgetLimits{
+		MaxTokens:    cfg.RunMaxTokens,
+		MaxCost:      cfg.RunMaxCost,
+		MaxToolCalls: cfg.RunMaxToolCalls,
+		Model:        cfg.OpenAIModel,
+	}, cfg.RunTimeout), handler.Guardrails(redaction))
+	if cfg.RecordDir != "" {
+		if err := os.MkdirAll(cfg.RecordDir, 0o750); err != nil {
+			log.Fatalf("Failed to create record directory: %v", err)
+		}
+		api.Use(handler.RecordRuns(cfg.RecordDir))
+		log.Printf("Recording agent runs to %s", cfg.RecordDir)
+	}
 	api.POST("/chat", chatHandler.Chat)
 	api.POST("/agent", agentHandler.Run)
+	api.POST("/auto", autoHandler.Run)
 	api.POST("/reflexion", reflexionHandler.Run)
+	api.GET("/reflexion/reflections", reflexionHandler.ListReflections)
+	api.DELETE("/reflexion/reflections", reflexionHandler.ClearReflections)
+	api.DELETE("/reflexion/reflections/:id", reflexionHandler.DeleteReflection)
 	api.POST("/orchestrator", orchestratorHandler.Run)
 	api.GET("/orchestrator/workers", orchestratorHandler.ListWorkers)
+	api.POST("/plan-execute", planExecuteHandler.Run)
+	api.POST("/rag/query", ragHandler.Query)
+	api.GET("/agents", agentsHandler.List)
+	api.POST("/agents/:name/run", agentsHandler.Run)
+	api.GET("/sessions/:id", sessionsHandler.Get)
+	api.DELETE("/sessions/:id", sessionsHandler.Delete)
+	if hedged != nil {
+		api.GET("/hedge/stats", handler.NewHedgeHandler(hedged).Stats)
+	}
 
 	// Start server with graceful shutdown
 	go func() {
@@ -132,6 +393,9 @@ func main() {
 	if err := e.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v", err)
+	}
 
 	log.Println("Server exited")
 }
@@ -4,6 +4,9 @@ package agent
 
 import (
 	"context"
+
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
 )
 
 // Agent defines the interface for AI agents.
@@ -16,6 +19,16 @@ type Agent interface {
 	RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error)
 }
 
+// LLMClient is the subset of LLM client functionality used by agents.
+// *llm.OpenAIClient satisfies it; tests can substitute a mock.
+type LLMClient interface {
+	// Chat sends a chat completion request and returns the response.
+	Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error)
+
+	// ChatWithTools sends a chat completion request with tool definitions.
+	ChatWithTools(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error)
+}
+
 // Message represents a message in the conversation.
 type Message struct {
 	Role    string `json:"role"`
@@ -53,6 +66,9 @@ type Step struct {
 
 	// ToolOutput is the output from the tool (for observation steps).
 	ToolOutput string `json:"tool_output,omitempty"`
+
+	// Agent names the nested agent that produced the step, if any.
+	Agent string `json:"agent,omitempty"`
 }
 
 // Usage contains token usage information for the agent run.
@@ -79,8 +95,37 @@ type Config struct {
 	// Prevents infinite loops. Default is 10.
 	MaxIterations int
 
+	// Model overrides the LLM client's default model when set.
+	Model string
+
+	// Temperature is the sampling temperature. Zero uses the client default.
+	Temperature float32
+
 	// Verbose enables detailed logging of agent steps.
 	Verbose bool
+
+	// Guardrails, when set, checks the query and tool results before they
+	// reach the model and the final answer before it is returned.
+	// Violations are listed in Response.Metadata["guardrails"].
+	Guardrails *guardrail.Pipeline
+
+	// Memory, when set, is recalled for context relevant to the query
+	// before each run and stores the query and answer afterwards. Recalled
+	// items are shown as "memory" steps.
+	Memory MemorySource
+
+	// Compression keeps long ReAct runs within the context window by
+	// compressing older and oversized tool observations. The zero value
+	// keeps every observation verbatim.
+	Compression CompressionConfig
+
+	// LoopDetection intervenes when a ReAct run repeats the same tool calls
+	// or oscillates between answers. The zero value disables detection.
+	LoopDetection LoopDetectionConfig
+
+	// MemoryTokens bounds the recalled context, estimated at four
+	// characters per token. Default is 1000.
+	MemoryTokens int
 }
 
 // DefaultConfig returns the default agent configuration.
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// AgentTool adapts an Agent to the tools.Tool interface so that one agent
+// can delegate to another, e.g. a ReAct agent calling a research orchestrator.
+//
+// The nested run shares the caller's context, so cancelling the parent run
+// cancels every agent beneath it. Nesting is bounded by MaxDepth. The nested
+// usage and steps are returned in the result metadata and merged into the
+// parent's Response by the ReAct loop.
+type AgentTool struct {
+	agent  Agent
+	config AgentToolConfig
+}
+
+// AgentToolConfig contains configuration for an AgentTool.
+type AgentToolConfig struct {
+	// Name is the tool name exposed to the LLM.
+	Name string
+
+	// Description tells the LLM when to delegate to this agent.
+	// Default is derived from Name.
+	Description string
+
+	// MaxDepth is the maximum number of nested agent calls, counting this one.
+	// Default is 3.
+	MaxDepth int
+}
+
+// Result metadata keys used to propagate nested agent runs.
+const (
+	metadataAgentUsage = "agent_usage"
+	metadataAgentSteps = "agent_steps"
+)
+
+// agentDepthKey is the context key holding the current agent nesting depth.
+type agentDepthKey struct{}
+
+// agentDepth returns the number of agent tools currently executing in ctx.
+func agentDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(agentDepthKey{}).(int)
+	return depth
+}
+
+// NewAgentTool wraps an agent as a tool.
+func NewAgentTool(a Agent, config AgentToolConfig) *AgentTool {
+	if config.Description == "" {
+		config.Description = fmt.Sprintf("Delegates a task to the %s agent and returns its final answer.", config.Name)
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = 3
+	}
+
+	return &AgentTool{
+		agent:  a,
+		config: config,
+	}
+}
+
+// agentToolArgs are the arguments accepted by an AgentTool.
+type agentToolArgs struct {
+	Query   string `json:"query"`
+	Context string `json:"context,omitempty"`
+}
+
+// Name returns the tool name.
+func (t *AgentTool) Name() string {
+	return t.config.Name
+}
+
+// Description returns the tool description.
+func (t *AgentTool) Description() string {
+	return t.config.Description
+}
+
+// Parameters returns the JSON Schema for the tool parameters.
+func (t *AgentTool) Parameters() tools.ParameterSchema {
+	return tools.ParameterSchema{
+		Type: "object",
+		Properties: map[string]tools.PropertySchema{
+			"query": {
+				Type:        "string",
+				Description: fmt.Sprintf("The complete, self-contained task for the %s agent", t.config.Name),
+			},
+			"context": {
+				Type:        "string",
+				Description: "Optional background information the agent needs, such as earlier findings",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+// Execute runs the wrapped agent on the query.
+// Agent failures are returned as failed results so the caller can recover;
+// cancellation of the shared context is returned as an error.
+func (t *AgentTool) Execute(ctx context.Context, arguments string) (tools.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return tools.Result{}, err
+	}
+
+	args, err := tools.ParseArguments[agentToolArgs](arguments)
+	if err != nil {
+		return tools.Failure(fmt.Sprintf("invalid arguments: %v", err)), nil
+	}
+	if args.Query == "" {
+		return tools.Failure("query is required"), nil
+	}
+
+	depth := agentDepth(ctx) + 1
+	if depth > t.config.MaxDepth {
+		return tools.Failure(fmt.Sprintf("agent nesting depth limit of %d exceeded", t.config.MaxDepth)), nil
+	}
+	ctx = context.WithValue(ctx, agentDepthKey{}, depth)
+
+	var history []Message
+	if args.Context != "" {
+		history = []Message{{Role: "user", Content: "Context:\n" + args.Context}}
+	}
+
+	resp, err := t.agent.RunWithHistory(ctx, history, args.Query)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return tools.Result{}, ctxErr
+		}
+		return tools.Failure(fmt.Sprintf("%s agent failed: %v", t.config.Name, err)), nil
+	}
+
+	// Attribute nested steps to this agent unless a deeper agent already did
+	steps := make([]Step, len(resp.Steps))
+	for i, s := range resp.Steps {
+		if s.Agent == "" {
+			s.Agent = t.config.Name
+		}
+		steps[i] = s
+	}
+
+	return tools.SuccessWithMetadata(resp.Output, map[string]any{
+		metadataAgentUsage: resp.Usage,
+		metadataAgentSteps: steps,
+	}), nil
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// newDelegatingMock calls the named tool for every user query and answers
+// with the tool output once it arrives.
+func newDelegatingMock(toolName string, tokens int) *MockLLMClient {
+	return &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role == llm.RoleUser {
+				return &llm.ChatWithToolsResponse{
+					ToolCalls: []llm.ToolCall{{ID: "1", Name: toolName, Arguments: `{"query": "sub task"}`}},
+					Usage:     llm.Usage{TotalTokens: tokens},
+				}, nil
+			}
+			return &llm.ChatWithToolsResponse{Content: "parent: " + last.Content, Usage: llm.Usage{TotalTokens: tokens}}, nil
+		},
+	}
+}
+
+func TestAgentTool_Parameters(t *testing.T) {
+	tool := NewAgentTool(&funcAgent{}, AgentToolConfig{Name: "research"})
+
+	if !strings.Contains(tool.Description(), "research") {
+		t.Errorf("expected default description to mention the agent, got %q", tool.Description())
+	}
+	params := tool.Parameters()
+	if _, ok := params.Properties["query"]; !ok || len(params.Required) != 1 || params.Required[0] != "query" {
+		t.Errorf("expected required query parameter, got %+v", params)
+	}
+}
+
+func TestAgentTool_PropagatesUsageAndSteps(t *testing.T) {
+	child := NewReActAgent(&MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return &llm.ChatWithToolsResponse{Content: "child answer", Usage: llm.Usage{TotalTokens: 100}}, nil
+		},
+	}, tools.NewRegistry(), Config{})
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(NewAgentTool(&stepsAgent{Agent: child}, AgentToolConfig{Name: "research"}))
+	parent := NewReActAgent(newDelegatingMock("research", 10), registry, Config{})
+
+	resp, err := parent.Run(context.Background(), "question")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Output != "parent: child answer" {
+		t.Errorf("expected child answer to be used, got %q", resp.Output)
+	}
+	if resp.Usage.TotalTokens != 120 {
+		t.Errorf("expected 20 parent + 100 child tokens, got %d", resp.Usage.TotalTokens)
+	}
+
+	// action, nested child step, observation
+	if len(resp.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(resp.Steps))
+	}
+	if resp.Steps[1].Agent != "research" || resp.Steps[1].Content != "child step" {
+		t.Errorf("expected nested step attributed to research, got %+v", resp.Steps[1])
+	}
+}
+
+func TestAgentTool_DepthLimit(t *testing.T) {
+	registry := tools.NewRegistry()
+	self := NewReActAgent(newDelegatingMock("self", 1), registry, Config{})
+	registry.MustRegister(NewAgentTool(self, AgentToolConfig{Name: "self", MaxDepth: 2}))
+
+	resp, err := self.Run(context.Background(), "recurse")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp.Output, "depth limit of 2 exceeded") {
+		t.Errorf("expected depth limit error to surface, got %q", resp.Output)
+	}
+	// Three levels each make two LLM calls
+	if resp.Usage.TotalTokens != 6 {
+		t.Errorf("expected usage from all three levels, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestAgentTool_SharedCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	child := &funcAgent{
+		run: func(ctx context.Context, _ string) (*Response, error) {
+			cancel()
+			return nil, ctx.Err()
+		},
+	}
+	tool := NewAgentTool(child, AgentToolConfig{Name: "child"})
+
+	if _, err := tool.Execute(ctx, `{"query": "q"}`); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation error, got %v", err)
+	}
+	if _, err := tool.Execute(ctx, `{"query": "q"}`); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancelled context to stop execution, got %v", err)
+	}
+}
+
+func TestAgentTool_ChildFailure(t *testing.T) {
+	child := &funcAgent{
+		run: func(_ context.Context, _ string) (*Response, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	tool := NewAgentTool(child, AgentToolConfig{Name: "child"})
+
+	result, err := tool.Execute(context.Background(), `{"query": "q"}`)
+	if err != nil {
+		t.Fatalf("expected failure result, not error: %v", err)
+	}
+	if result.IsSuccess() || !strings.Contains(result.Error, "boom") {
+		t.Errorf("expected failure mentioning boom, got %+v", result)
+	}
+}
+
+// stepsAgent prepends a fixed step to the wrapped agent's response.
+type stepsAgent struct {
+	Agent
+}
+
+func (a *stepsAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	resp, err := a.Agent.RunWithHistory(ctx, history, query)
+	if err != nil {
+		return nil, err
+	}
+	resp.Steps = append([]Step{{Type: StepTypeThought, Content: "child step"}}, resp.Steps...)
+	return resp, nil
+}
+
+// funcAgent is an Agent backed by a function.
+type funcAgent struct {
+	run func(ctx context.Context, query string) (*Response, error)
+}
+
+func (a *funcAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return a.RunWithHistory(ctx, nil, query)
+}
+
+func (a *funcAgent) RunWithHistory(ctx context.Context, _ []Message, query string) (*Response, error) {
+	if a.run == nil {
+		return &Response{Output: "ok"}, nil
+	}
+	return a.run(ctx, query)
+}
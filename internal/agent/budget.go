@@ -0,0 +1,342 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// ErrBudgetExceeded is wrapped by every budget limit error.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetLimits bounds the resources of a single run. Zero values are unlimited.
+type BudgetLimits struct {
+	// MaxTokens bounds total prompt and completion tokens across all LLM calls.
+	MaxTokens int
+
+	// MaxCost bounds the estimated spend in USD, priced with llm.PriceFor.
+	MaxCost float64
+
+	// Deadline is the wall-clock time by which the run should answer.
+	// Unlike a context deadline it does not cancel in-flight calls; agents
+	// stop starting new work once it passes and return what they have.
+	Deadline time.Time
+
+	// MaxToolCalls bounds tool invocations across the run, including workers.
+	MaxToolCalls int
+
+	// Model prices calls that do not name a model, typically the client's
+	// default. Calls to unpriced models count tokens but no cost.
+	Model string
+}
+
+// Budget tracks a run's consumption against its limits. It is carried in the
+// context so that nested agents, reflexion attempts and orchestrator workers
+// share it, and is safe for concurrent use.
+//
+// Agents check the budget before starting new work. Once it is exhausted they
+// stop and return a best-effort answer from what they have so far, with the
+// reason in Response.Metadata["budget"], or fail under WithStrictLimits.
+type Budget struct {
+	limits BudgetLimits
+	start  time.Time
+
+	mu        sync.Mutex
+	tokens    int
+	cost      float64
+	toolCalls int
+	exhausted string
+}
+
+// BudgetUsage reports what a run consumed.
+type BudgetUsage struct {
+	Tokens    int     `json:"tokens"`
+	Cost      float64 `json:"cost"`
+	ToolCalls int     `json:"tool_calls"`
+	ElapsedMs int64   `json:"elapsed_ms"`
+
+	// Exhausted is the first limit that was hit, or empty.
+	Exhausted string `json:"exhausted,omitempty"`
+}
+
+// NewBudget creates a budget starting now.
+func NewBudget(limits BudgetLimits) *Budget {
+	return &Budget{
+		limits: limits,
+		start:  time.Now(),
+	}
+}
+
+// budgetKey is the context key holding the run's *Budget.
+type budgetKey struct{}
+
+// WithBudget returns a context carrying b.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// BudgetFrom returns the budget carried by ctx, or nil. A nil *Budget is
+// unlimited.
+func BudgetFrom(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetKey{}).(*Budget)
+	return b
+}
+
+// Limits returns the budget's limits.
+func (b *Budget) Limits() BudgetLimits {
+	if b == nil {
+		return BudgetLimits{}
+	}
+	return b.limits
+}
+
+// Check returns an error wrapping ErrBudgetExceeded once the token, cost or
+// deadline limit has been reached.
+func (b *Budget) Check() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var reason string
+	switch {
+	case b.limits.MaxTokens > 0 && b.tokens >= b.limits.MaxTokens:
+		reason = fmt.Sprintf("token limit of %d reached", b.limits.MaxTokens)
+	case b.limits.MaxCost > 0 && b.cost >= b.limits.MaxCost:
+		reason = fmt.Sprintf("cost limit of $%.4f reached", b.limits.MaxCost)
+	case !b.limits.Deadline.IsZero() && !time.Now().Before(b.limits.Deadline):
+		reason = "deadline reached"
+	default:
+		return nil
+	}
+	return b.exhaust(reason)
+}
+
+// spent reports whether the token or cost limit has been reached, so that
+// no further LLM call should be made.
+func (b *Budget) spent() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return (b.limits.MaxTokens > 0 && b.tokens >= b.limits.MaxTokens) ||
+		(b.limits.MaxCost > 0 && b.cost >= b.limits.MaxCost)
+}
+
+// ReserveToolCall counts a tool invocation, returning an error wrapping
+// ErrBudgetExceeded if the tool call limit is already used up.
+func (b *Budget) ReserveToolCall() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limits.MaxToolCalls > 0 && b.toolCalls >= b.limits.MaxToolCalls {
+		return b.exhaust(fmt.Sprintf("tool call limit of %d reached", b.limits.MaxToolCalls))
+	}
+	b.toolCalls++
+	return nil
+}
+
+// exhaust records the first exhausted limit and returns its error.
+// b.mu must be held.
+func (b *Budget) exhaust(reason string) error {
+	if b.exhausted == "" {
+		b.exhausted = reason
+	}
+	return fmt.Errorf("%w: %s", ErrBudgetExceeded, reason)
+}
+
+// record adds the usage of an LLM call made with model.
+func (b *Budget) record(model string, u llm.Usage) {
+	if b == nil {
+		return
+	}
+	if model == "" {
+		model = b.limits.Model
+	}
+	price, _ := llm.PriceFor(model)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += u.TotalTokens
+	b.cost += price.Cost(u)
+}
+
+// Usage reports the consumption so far.
+func (b *Budget) Usage() BudgetUsage {
+	if b == nil {
+		return BudgetUsage{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BudgetUsage{
+		Tokens:    b.tokens,
+		Cost:      b.cost,
+		ToolCalls: b.toolCalls,
+		ElapsedMs: time.Since(b.start).Milliseconds(),
+		Exhausted: b.exhausted,
+	}
+}
+
+// withBudgetUsage reports the budget carried by ctx in resp's metadata.
+func withBudgetUsage(ctx context.Context, resp *Response) *Response {
+	b := BudgetFrom(ctx)
+	if b == nil {
+		return resp
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata["budget"] = b.Usage()
+	return resp
+}
+
+// meteredLLM records the usage of every call in the run's budget. It is also
+// where a run's Interceptor sees model calls.
+type meteredLLM struct {
+	LLMClient
+}
+
+// metered wraps c so its calls are charged to the context's budget.
+// Already metered clients are returned unchanged to avoid double counting.
+func metered(c LLMClient) LLMClient {
+	if c == nil {
+		return nil
+	}
+	if _, ok := c.(meteredLLM); ok {
+		return c
+	}
+	return meteredLLM{c}
+}
+
+// Chat calls the wrapped client, through the context's interceptor if any,
+// and records its usage.
+func (m meteredLLM) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	return meteredChat(ctx, req, m.LLMClient.Chat)
+}
+
+// meteredChat makes a chat call through the context's interceptor if any,
+// and records its usage.
+func meteredChat(ctx context.Context, req *llm.ChatRequest, chat func(context.Context, *llm.ChatRequest) (*llm.ChatResponse, error)) (*llm.ChatResponse, error) {
+	call := chat
+	if i := interceptorFrom(ctx); i != nil {
+		call = func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			return i.InterceptChat(ctx, req, chat)
+		}
+	}
+	resp, err := call(ctx, req)
+	if err == nil {
+		BudgetFrom(ctx).record(req.Model, resp.Usage)
+		usageTallyFrom(ctx).add(resp.Usage)
+	}
+	return resp, err
+}
+
+// ChatWithTools calls the wrapped client, through the context's interceptor
+// if any, and records its usage.
+func (m meteredLLM) ChatWithTools(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+	call := m.LLMClient.ChatWithTools
+	if i := interceptorFrom(ctx); i != nil {
+		call = func(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return i.InterceptChatWithTools(ctx, req, m.LLMClient.ChatWithTools)
+		}
+	}
+	resp, err := call(ctx, req)
+	if err == nil {
+		BudgetFrom(ctx).record(req.Model, resp.Usage)
+		usageTallyFrom(ctx).add(resp.Usage)
+	}
+	return resp, err
+}
+
+// meteredClient charges an llm.Client's chat calls to the run's budget.
+type meteredClient struct {
+	llm.Client
+}
+
+// MeteredClient wraps c for use outside an agent, such as in a RAG chain:
+// Chat calls are charged to the context's budget and seen by its
+// Interceptor. Without an agent loop to fall back on a best-effort answer,
+// calls fail with the budget's error once it is exhausted. Streams are
+// passed through unmetered.
+func MeteredClient(c llm.Client) llm.Client {
+	return meteredClient{c}
+}
+
+// Chat checks the budget and calls the wrapped client, recording its usage.
+func (m meteredClient) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	if err := BudgetFrom(ctx).Check(); err != nil {
+		return nil, err
+	}
+	return meteredChat(ctx, req, m.Client.Chat)
+}
+
+// usageTally sums the usage of the metered calls made with a context, for
+// callers of APIs that do not report usage, such as Evaluator.
+type usageTally struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// usageTallyKey is the context key holding a *usageTally.
+type usageTallyKey struct{}
+
+// withUsageTally returns a context whose metered calls are summed in the
+// returned tally.
+func withUsageTally(ctx context.Context) (context.Context, *usageTally) {
+	t := &usageTally{}
+	return context.WithValue(ctx, usageTallyKey{}, t), t
+}
+
+// usageTallyFrom returns the tally carried by ctx, or nil.
+func usageTallyFrom(ctx context.Context) *usageTally {
+	t, _ := ctx.Value(usageTallyKey{}).(*usageTally)
+	return t
+}
+
+// add records usage. A nil tally ignores it.
+func (t *usageTally) add(usage llm.Usage) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.usage = addUsage(t.usage, toUsage(usage))
+	t.mu.Unlock()
+}
+
+// total returns the usage recorded so far.
+func (t *usageTally) total() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// bestEffortAnswer builds an answer without further LLM calls when a run is
+// stopped early: the model's last reply if it gave one, otherwise the latest
+// tool observations.
+func bestEffortAnswer(lastContent string, steps []Step) string {
+	if strings.TrimSpace(lastContent) != "" {
+		return lastContent
+	}
+
+	var observations []string
+	for i := len(steps) - 1; i >= 0 && len(observations) < 3; i-- {
+		if steps[i].Type == StepTypeObservation && steps[i].ToolOutput != "" {
+			observations = append([]string{fmt.Sprintf("%s: %s", steps[i].ToolName, steps[i].ToolOutput)}, observations...)
+		}
+	}
+	if len(observations) == 0 {
+		return "I could not complete this request within the allotted limits."
+	}
+	return "I could not finish within the allotted limits. Partial results:\n" + strings.Join(observations, "\n")
+}
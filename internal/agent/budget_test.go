@@ -0,0 +1,283 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func TestBudget_Limits(t *testing.T) {
+	t.Run("nil budget is unlimited", func(t *testing.T) {
+		var b *Budget
+		if err := b.Check(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := b.ReserveToolCall(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		b.record("gpt-4o", llm.Usage{TotalTokens: 10})
+	})
+
+	t.Run("tokens and cost", func(t *testing.T) {
+		b := NewBudget(BudgetLimits{MaxTokens: 1000, MaxCost: 0.01, Model: "gpt-4o"})
+		b.record("", llm.Usage{PromptTokens: 500, CompletionTokens: 100, TotalTokens: 600})
+		if err := b.Check(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// gpt-4o-mini is cheap, so only the token limit trips
+		b.record("gpt-4o-mini", llm.Usage{PromptTokens: 400, TotalTokens: 400})
+		err := b.Check()
+		if !errors.Is(err, ErrBudgetExceeded) || !strings.Contains(err.Error(), "token limit") {
+			t.Errorf("expected token limit error, got %v", err)
+		}
+
+		usage := b.Usage()
+		if usage.Tokens != 1000 || usage.Cost <= 0 || usage.Exhausted != "token limit of 1000 reached" {
+			t.Errorf("unexpected usage: %+v", usage)
+		}
+	})
+
+	t.Run("cost", func(t *testing.T) {
+		b := NewBudget(BudgetLimits{MaxCost: 0.001})
+		b.record("gpt-4o", llm.Usage{PromptTokens: 1000, TotalTokens: 1000})
+		if err := b.Check(); !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("expected cost limit error, got %v", err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		b := NewBudget(BudgetLimits{Deadline: time.Now().Add(-time.Second)})
+		if err := b.Check(); !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("expected deadline error, got %v", err)
+		}
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		b := NewBudget(BudgetLimits{MaxToolCalls: 2})
+		for i := 0; i < 2; i++ {
+			if err := b.ReserveToolCall(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := b.ReserveToolCall(); !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("expected tool call limit error, got %v", err)
+		}
+		if b.Usage().ToolCalls != 2 {
+			t.Errorf("expected 2 tool calls, got %d", b.Usage().ToolCalls)
+		}
+	})
+}
+
+func TestMetered_Idempotent(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+			return &llm.ChatResponse{Content: "ok", Usage: llm.Usage{TotalTokens: 10}}, nil
+		},
+	}
+
+	b := NewBudget(BudgetLimits{})
+	client := metered(metered(mock))
+	if _, err := client.Chat(WithBudget(context.Background(), b), &llm.ChatRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Usage().Tokens != 10 {
+		t.Errorf("expected 10 tokens recorded once, got %d", b.Usage().Tokens)
+	}
+}
+
+func TestReActAgent_BudgetToolCalls(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(NewAgentTool(&funcAgent{run: func(_ context.Context, input string) (*Response, error) {
+		return &Response{Output: "found " + input}, nil
+	}}, AgentToolConfig{Name: "search"}))
+
+	calls := 0
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			calls++
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "search", Arguments: `{"query": "docs"}`}},
+				Usage:     llm.Usage{TotalTokens: 10},
+			}, nil
+		},
+	}
+
+	b := NewBudget(BudgetLimits{MaxToolCalls: 2})
+	a := NewReActAgent(mock, registry, Config{MaxIterations: 10})
+	resp, err := a.Run(WithBudget(context.Background(), b), "find the docs")
+	if err != nil {
+		t.Fatalf("expected best-effort answer, got error: %v", err)
+	}
+
+	// Two tool calls, the refused third, and the final answer call
+	if calls != 4 {
+		t.Errorf("expected 4 LLM calls, got %d", calls)
+	}
+	if !strings.Contains(resp.Output, "search: found docs") {
+		t.Errorf("expected partial results in output, got %q", resp.Output)
+	}
+
+	usage, ok := resp.Metadata["budget"].(BudgetUsage)
+	if !ok {
+		t.Fatalf("expected budget usage in metadata, got %+v", resp.Metadata)
+	}
+	if usage.ToolCalls != 2 || usage.Tokens != 40 || usage.Exhausted == "" {
+		t.Errorf("unexpected budget usage: %+v", usage)
+	}
+}
+
+func TestReActAgent_BudgetTokens(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(NewAgentTool(&funcAgent{run: func(_ context.Context, _ string) (*Response, error) {
+		return &Response{Output: "42"}, nil
+	}}, AgentToolConfig{Name: "lookup"}))
+
+	calls := 0
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			calls++
+			return &llm.ChatWithToolsResponse{
+				Content:   "Let me look that up.",
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "lookup", Arguments: `{"query": "answer"}`}},
+				Usage:     llm.Usage{TotalTokens: 100},
+			}, nil
+		},
+	}
+
+	a := NewReActAgent(mock, registry, Config{MaxIterations: 10})
+	ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 150}))
+	resp, err := a.Run(ctx, "what is the answer?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the run to stop after 2 calls, got %d", calls)
+	}
+	if resp.Output != "Let me look that up." {
+		t.Errorf("expected last model reply as the answer, got %q", resp.Output)
+	}
+}
+
+func TestReflexionAgent_BudgetAcrossAttempts(t *testing.T) {
+	reactCalls, reflectCalls := 0, 0
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			reactCalls++
+			return &llm.ChatWithToolsResponse{Content: "draft answer", Usage: llm.Usage{TotalTokens: 100}}, nil
+		},
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			if strings.Contains(req.Messages[0].Content, "feedback") {
+				reflectCalls++
+				return &llm.ChatResponse{Content: "be more specific"}, nil
+			}
+			return &llm.ChatResponse{
+				Content: `{"score": 3, "weaknesses": ["vague"]}`,
+				Usage:   llm.Usage{TotalTokens: 100},
+			}, nil
+		},
+	}
+
+	a := NewReflexionAgent(mock, tools.NewRegistry(), ReflexionConfig{MaxReflections: 3})
+	ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 200}))
+	resp, err := a.Run(ctx, "explain budgets")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if reactCalls != 1 || reflectCalls != 0 {
+		t.Errorf("expected a single attempt without reflection, got %d attempts and %d reflections", reactCalls, reflectCalls)
+	}
+	if resp.Output != "draft answer" {
+		t.Errorf("expected best attempt, got %q", resp.Output)
+	}
+	if usage, ok := resp.Metadata["budget"].(BudgetUsage); !ok || usage.Tokens != 200 {
+		t.Errorf("expected 200 tokens in budget metadata, got %+v", resp.Metadata["budget"])
+	}
+}
+
+func TestOrchestratorAgent_BudgetAcrossWorkers(t *testing.T) {
+	var executed []string
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			input := req.Messages[len(req.Messages)-1].Content
+			switch {
+			case strings.Contains(req.Messages[0].Content, "orchestrator"):
+				return &llm.ChatResponse{
+					Content: `{"analysis": "two steps", "subtasks": [
+						{"id": "task_1", "description": "first", "worker_type": "general", "input": "step one"},
+						{"id": "task_2", "description": "second", "worker_type": "general", "input": "step two"}
+					], "dependencies": {"task_2": ["task_1"]}}`,
+					Usage: llm.Usage{TotalTokens: 50},
+				}, nil
+			case strings.Contains(req.Messages[0].Content, "synthesizer"):
+				t.Error("synthesis must not run once the budget is spent")
+				return &llm.ChatResponse{Content: "synthesized"}, nil
+			default:
+				executed = append(executed, input)
+				return &llm.ChatResponse{Content: "result of " + input, Usage: llm.Usage{TotalTokens: 100}}, nil
+			}
+		},
+	}
+
+	o := NewOrchestratorAgent(mock, tools.NewRegistry(), OrchestratorConfig{})
+	ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 120}))
+	resp, err := o.Run(ctx, "do two things")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(executed) != 1 || executed[0] != "step one" {
+		t.Errorf("expected only task_1 to run, got %v", executed)
+	}
+	if !strings.Contains(resp.Output, "result of step one") {
+		t.Errorf("expected partial results in output, got %q", resp.Output)
+	}
+
+	results := resp.Metadata["orchestrator"].(*OrchestratorTrace).Results
+	for _, r := range results {
+		if r.ID == "task_2" && (r.Success || !strings.Contains(r.Error, "budget exceeded")) {
+			t.Errorf("expected task_2 to be skipped, got %+v", r)
+		}
+	}
+}
+
+// chatOnlyClient is an llm.Client that only implements Chat.
+type chatOnlyClient struct {
+	llm.Client
+	calls int
+}
+
+func (c *chatOnlyClient) Chat(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+	c.calls++
+	return &llm.ChatResponse{Content: "ok", Usage: llm.Usage{TotalTokens: 60}}, nil
+}
+
+func TestMeteredClient_Budget(t *testing.T) {
+	inner := &chatOnlyClient{}
+	client := MeteredClient(inner)
+
+	b := NewBudget(BudgetLimits{MaxTokens: 100})
+	ctx := WithBudget(context.Background(), b)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Chat(ctx, &llm.ChatRequest{}); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if b.Usage().Tokens != 120 {
+		t.Errorf("expected 120 tokens recorded, got %d", b.Usage().Tokens)
+	}
+
+	if _, err := client.Chat(ctx, &llm.ChatRequest{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Error("expected ErrBudgetExceeded once the budget is exhausted")
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected the exhausted call to be refused, got %d calls", inner.calls)
+	}
+}
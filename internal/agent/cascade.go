@@ -0,0 +1,276 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// CascadeAgent answers with the cheapest model it can trust. Each query is
+// first run on the first tier, typically a small or local model; the answer
+// is scored by an Evaluator and escalated to the next, stronger tier while
+// the score is below QualityThreshold. The last tier's answer is accepted
+// without evaluation, as is any answer once the run's budget is exhausted.
+//
+// Each tier runs a ReAct loop with the cascade's tools. The tiers tried,
+// their scores, usage and cost are recorded in Response.Metadata["cascade"]
+// as a *CascadeReport.
+type CascadeAgent struct {
+	tiers  []cascadeTier
+	config CascadeConfig
+}
+
+// CascadeConfig contains configuration for the cascade agent.
+type CascadeConfig struct {
+	Config
+
+	// Tiers lists the models to try, cheapest first. Default is a single
+	// tier using the cascade's client and Config.Model.
+	Tiers []CascadeTier
+
+	// Evaluator scores each tier's answer. Default is an LLMJudge on the
+	// tier's own model, so each model evaluates itself. WithEvalOptions
+	// overrides it per run.
+	Evaluator Evaluator
+
+	// QualityThreshold is the minimum score (0-10) at which an answer is
+	// accepted instead of escalated. Default is 7.
+	QualityThreshold float64
+}
+
+// CascadeTier is a model in the cascade.
+type CascadeTier struct {
+	// Name identifies the tier in the report. Default is the model name.
+	Name string
+
+	// Client serves the tier. Default is the cascade's client.
+	Client LLMClient
+
+	// Model is the model used by the tier and priced in the report.
+	Model string
+}
+
+// CascadeReport records how a query moved through the cascade.
+type CascadeReport struct {
+	// Tier is the name of the tier whose answer was returned.
+	Tier string `json:"tier"`
+
+	// Attempts lists the tiers tried, in order.
+	Attempts []CascadeAttempt `json:"attempts"`
+}
+
+// CascadeAttempt is one tier's run in a cascade. Usage and Cost include the
+// evaluation of the tier's answer, priced at the tier's model.
+type CascadeAttempt struct {
+	Tier  string  `json:"tier"`
+	Model string  `json:"model,omitempty"`
+	Usage Usage   `json:"usage"`
+	Cost  float64 `json:"cost"`
+
+	// Score is the evaluator's score, or zero if the answer was not evaluated.
+	Score float64 `json:"score,omitempty"`
+
+	// Accepted is true for the attempt whose answer was returned.
+	Accepted bool `json:"accepted"`
+
+	// Reason explains why the answer was escalated or accepted.
+	Reason string `json:"reason,omitempty"`
+}
+
+// cascadeTier is a tier ready to run.
+type cascadeTier struct {
+	CascadeTier
+	agent *ReActAgent
+	judge Evaluator
+}
+
+// NewCascadeAgent creates a new cascade agent.
+func NewCascadeAgent(llmClient LLMClient, toolRegistry *tools.Registry, config CascadeConfig) *CascadeAgent {
+	if config.QualityThreshold <= 0 {
+		config.QualityThreshold = 7.0
+	}
+	if len(config.Tiers) == 0 {
+		config.Tiers = []CascadeTier{{Model: config.Model}}
+	}
+
+	tiers := make([]cascadeTier, len(config.Tiers))
+	for i, tier := range config.Tiers {
+		if tier.Client == nil {
+			tier.Client = llmClient
+		}
+		if tier.Name == "" {
+			tier.Name = tier.Model
+		}
+		if tier.Name == "" {
+			tier.Name = fmt.Sprintf("tier%d", i+1)
+		}
+
+		// Memory is recalled once for the whole cascade
+		tierConfig := config.Config
+		tierConfig.Model = tier.Model
+		tierConfig.Memory = nil
+
+		tiers[i] = cascadeTier{
+			CascadeTier: tier,
+			agent:       NewReActAgent(tier.Client, toolRegistry, tierConfig),
+			judge:       NewLLMJudge(tier.Client, LLMJudgeConfig{Model: tier.Model}),
+		}
+	}
+
+	return &CascadeAgent{
+		tiers:  tiers,
+		config: config,
+	}
+}
+
+// Run processes a query.
+func (c *CascadeAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return c.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory runs a query through the tiers until an answer is accepted.
+// The run is traced as an "invoke_agent cascade" span.
+func (c *CascadeAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "cascade")
+	resp, err := withMemory(ctx, c.config.Config, history, query, c.run)
+	if resp != nil {
+		if report, ok := resp.Metadata["cascade"].(*CascadeReport); ok {
+			span.SetAttributes(attrCascadeTier.String(report.Tier), attrCascadeAttempts.Int(len(report.Attempts)))
+		}
+	}
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (c *CascadeAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	opts := evalOptionsFrom(ctx)
+	report := &CascadeReport{}
+	var steps []Step
+	var totalUsage Usage
+
+	for i, tier := range c.tiers {
+		last := i == len(c.tiers)-1
+		if c.config.Verbose {
+			log.Printf("[Cascade] Trying %s (%d/%d)", tier.Name, i+1, len(c.tiers))
+		}
+
+		resp, err := tier.agent.RunWithHistory(ctx, history, query)
+		if err != nil {
+			if last || !escalatable(ctx, err) {
+				return nil, fmt.Errorf("%s tier failed: %w", tier.Name, err)
+			}
+			report.Attempts = append(report.Attempts, CascadeAttempt{Tier: tier.Name, Model: tier.Model, Reason: err.Error()})
+			steps = append(steps, cascadeStep(tier.Name, fmt.Sprintf("Failed: %v; escalating", err)))
+			continue
+		}
+
+		for _, step := range resp.Steps {
+			if step.Agent == "" {
+				step.Agent = tier.Name
+			}
+			steps = append(steps, step)
+		}
+
+		attempt := CascadeAttempt{Tier: tier.Name, Model: tier.Model}
+		var evalUsage Usage
+		attempt.Accepted, attempt.Score, attempt.Reason, evalUsage = c.accept(ctx, tier, opts, query, resp, last)
+		attempt.Usage = addUsage(resp.Usage, evalUsage)
+		price, _ := llm.PriceFor(tier.Model)
+		attempt.Cost = price.Cost(llm.Usage{PromptTokens: attempt.Usage.PromptTokens, CompletionTokens: attempt.Usage.CompletionTokens})
+		totalUsage = addUsage(totalUsage, attempt.Usage)
+		report.Attempts = append(report.Attempts, attempt)
+
+		if !attempt.Accepted {
+			steps = append(steps, cascadeStep(tier.Name, fmt.Sprintf("Escalating: %s", attempt.Reason)))
+			continue
+		}
+		if c.config.Verbose {
+			log.Printf("[Cascade] Accepted %s: %s", tier.Name, attempt.Reason)
+		}
+
+		report.Tier = tier.Name
+		steps = append(steps, cascadeStep(tier.Name, fmt.Sprintf("Accepted: %s", attempt.Reason)))
+		resp.Steps = steps
+		resp.Usage = totalUsage
+		if resp.Metadata == nil {
+			resp.Metadata = make(map[string]any)
+		}
+		resp.Metadata["cascade"] = report
+		return withBudgetUsage(ctx, withGuardrailViolations(ctx, resp)), nil
+	}
+
+	// Unreachable: the last tier is accepted or returns an error
+	return nil, errors.New("cascade has no tiers")
+}
+
+// accept decides whether a tier's answer is returned. It reports the score,
+// the reason for the decision and the usage of the evaluation.
+func (c *CascadeAgent) accept(ctx context.Context, tier cascadeTier, opts EvalOptions, query string, resp *Response, last bool) (bool, float64, string, Usage) {
+	if last {
+		return true, 0, "last tier", Usage{}
+	}
+	// The next tier could not run on a spent budget, so the answer stands
+	if exhausted := budgetExhausted(ctx); exhausted != "" {
+		return true, 0, fmt.Sprintf("budget exhausted (%s)", exhausted), Usage{}
+	}
+	if incomplete, _ := resp.Metadata[metadataIncomplete].(bool); incomplete {
+		return false, 0, fmt.Sprintf("answer incomplete (%v)", resp.Metadata[metadataIncompleteReason]), Usage{}
+	}
+
+	evaluator := tier.judge
+	if c.config.Evaluator != nil {
+		evaluator = c.config.Evaluator
+	}
+	if opts.Evaluator != nil {
+		evaluator = opts.Evaluator
+	}
+
+	// Evaluators do not report usage, so their metered calls are tallied
+	evalCtx, tally := withUsageTally(ctx)
+	eval, err := evaluator.Evaluate(evalCtx, EvalInput{
+		Query:     query,
+		Response:  resp.Output,
+		Reference: opts.Reference,
+		Steps:     resp.Steps,
+	})
+	usage := tally.total()
+	if err != nil {
+		return false, 0, fmt.Sprintf("evaluation failed: %v", err), usage
+	}
+	if c.config.Verbose {
+		log.Printf("[Cascade] %s scored %.1f (threshold: %.1f)", tier.Name, eval.Score, c.config.QualityThreshold)
+	}
+
+	if eval.Score < c.config.QualityThreshold {
+		if exhausted := budgetExhausted(ctx); exhausted != "" {
+			return true, eval.Score, fmt.Sprintf("score %.1f below %.1f but budget exhausted (%s)", eval.Score, c.config.QualityThreshold, exhausted), usage
+		}
+		return false, eval.Score, fmt.Sprintf("score %.1f below %.1f", eval.Score, c.config.QualityThreshold), usage
+	}
+	return true, eval.Score, fmt.Sprintf("score %.1f", eval.Score), usage
+}
+
+// budgetExhausted returns the first limit of the run's budget that has been
+// reached, or "" while the budget allows more work.
+func budgetExhausted(ctx context.Context) string {
+	budget := BudgetFrom(ctx)
+	_ = budget.Check() // Marks a limit reached by the last call as exhausted
+	return budget.Usage().Exhausted
+}
+
+// escalatable reports whether a tier's failure is left to the next tier.
+// Cancellation, budget exhaustion and guardrail blocks end the cascade.
+func escalatable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, ErrBudgetExceeded) && !errors.Is(err, guardrail.ErrBlocked)
+}
+
+// cascadeStep records a cascade decision as a step.
+func cascadeStep(tier, content string) Step {
+	return Step{Type: "cascade", Content: content, Agent: tier}
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func TestCascadeAgent_EscalatesOnLowScore(t *testing.T) {
+	var judged []string
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return &llm.ChatWithToolsResponse{
+				Content: "answer from " + req.Model,
+				Usage:   llm.Usage{PromptTokens: 1000000, CompletionTokens: 0, TotalTokens: 1000000},
+			}, nil
+		},
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			judged = append(judged, req.Model)
+			score := "4"
+			if req.Model == "gpt-4o" {
+				score = "8"
+			}
+			return &llm.ChatResponse{
+				Content: `{"score": ` + score + `, "reasoning": "r"}`,
+				Usage:   llm.Usage{PromptTokens: 1000, TotalTokens: 1000},
+			}, nil
+		},
+	}
+
+	a := NewCascadeAgent(mock, tools.NewRegistry(), CascadeConfig{Tiers: []CascadeTier{
+		{Name: "small", Model: "gpt-4o-mini"},
+		{Model: "gpt-4o"},
+		{Name: "large", Model: "o1"},
+	}})
+	resp, err := a.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Output != "answer from gpt-4o" || resp.Usage.TotalTokens != 2002000 {
+		t.Errorf("expected the second tier's answer with both tiers' and judges' usage, got %q %+v", resp.Output, resp.Usage)
+	}
+	if strings.Join(judged, ",") != "gpt-4o-mini,gpt-4o" {
+		t.Errorf("expected each tier to judge itself, got %v", judged)
+	}
+
+	report, ok := resp.Metadata["cascade"].(*CascadeReport)
+	if !ok || report.Tier != "gpt-4o" || len(report.Attempts) != 2 {
+		t.Fatalf("unexpected report %+v", resp.Metadata["cascade"])
+	}
+	first, second := report.Attempts[0], report.Attempts[1]
+	if first.Accepted || first.Score != 4 || first.Cost != 0.15015 || first.Usage.TotalTokens != 1001000 || !strings.Contains(first.Reason, "below 7.0") {
+		t.Errorf("unexpected first attempt %+v", first)
+	}
+	if !second.Accepted || second.Score != 8 || second.Cost != 2.5025 {
+		t.Errorf("unexpected second attempt %+v", second)
+	}
+	if countSteps(resp.Steps, "cascade") != 2 {
+		t.Errorf("expected an escalation and an acceptance step, got %+v", resp.Steps)
+	}
+}
+
+func TestCascadeAgent_LastTierAndFailures(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			if req.Model == "local" {
+				return nil, errors.New("connection refused")
+			}
+			return &llm.ChatWithToolsResponse{Content: "answer from " + req.Model}, nil
+		},
+	}
+
+	var evaluated int
+	a := NewCascadeAgent(mock, tools.NewRegistry(), CascadeConfig{
+		Tiers: []CascadeTier{{Model: "local"}, {Model: "mid"}, {Model: "top"}},
+		Evaluator: evaluatorFunc(func(context.Context, EvalInput) (Evaluation, error) {
+			evaluated++
+			return Evaluation{Score: 9}, nil
+		}),
+		QualityThreshold: 9.5,
+	})
+	resp, err := a.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Output != "answer from top" || evaluated != 1 {
+		t.Errorf("expected the last tier's answer after one evaluation, got %q and %d", resp.Output, evaluated)
+	}
+	report := resp.Metadata["cascade"].(*CascadeReport)
+	if report.Attempts[0].Reason != "LLM call failed: connection refused" || report.Attempts[2].Reason != "last tier" {
+		t.Errorf("unexpected attempts %+v", report.Attempts)
+	}
+
+	single := NewCascadeAgent(mock, tools.NewRegistry(), CascadeConfig{Tiers: []CascadeTier{{Model: "local"}}})
+	if _, err := single.Run(context.Background(), "q"); err == nil || !strings.HasPrefix(err.Error(), "local tier failed") {
+		t.Errorf("expected the last tier's failure, got %v", err)
+	}
+}
+
+func TestCascadeAgent_StopsOnSpentBudget(t *testing.T) {
+	var models []string
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			models = append(models, req.Model)
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "2 + 3"}`}},
+				Usage:     llm.Usage{TotalTokens: 20},
+			}, nil
+		},
+	}
+	registry := tools.NewRegistry()
+	_ = registry.Register(tools.NewCalculator())
+
+	a := NewCascadeAgent(mock, registry, CascadeConfig{Tiers: []CascadeTier{{Model: "small"}, {Model: "large"}}})
+	ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 10}))
+	resp, err := a.Run(ctx, "What is 2 + 3?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(models, ",") != "small" {
+		t.Errorf("expected no escalation on a spent budget, got calls to %v", models)
+	}
+	if !strings.Contains(resp.Output, "5") || resp.Usage.TotalTokens != 20 {
+		t.Errorf("expected the first tier's partial answer and usage, got %q %+v", resp.Output, resp.Usage)
+	}
+	report := resp.Metadata["cascade"].(*CascadeReport)
+	if report.Tier != "small" || len(report.Attempts) != 1 || !strings.HasPrefix(report.Attempts[0].Reason, "budget exhausted") {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
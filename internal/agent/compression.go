@@ -0,0 +1,271 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// CompressionConfig controls how the ReAct loop keeps tool observations
+// within the context window. The zero value keeps every observation verbatim.
+type CompressionConfig struct {
+	// KeepRaw is the number of most recent observations kept verbatim.
+	// Older ones are stored as artifacts and replaced in the conversation
+	// by a reference. Zero keeps all observations.
+	KeepRaw int `json:"keep_raw,omitempty" yaml:"keep_raw,omitempty"`
+
+	// Summarize condenses observations as they are compressed into running
+	// notes with an LLM call, shown to the model ahead of the tool calls.
+	Summarize bool `json:"summarize,omitempty" yaml:"summarize,omitempty"`
+
+	// ArtifactThreshold stores tool outputs longer than this many characters
+	// as artifacts. The model sees a preview and reads the rest with the
+	// fetch_artifact tool. Zero disables.
+	ArtifactThreshold int `json:"artifact_threshold,omitempty" yaml:"artifact_threshold,omitempty"`
+
+	// PreviewChars is the length of an artifact's preview. Default is 500.
+	PreviewChars int `json:"preview_chars,omitempty" yaml:"preview_chars,omitempty"`
+
+	// PageChars is the most characters fetch_artifact returns per call.
+	// Default is 4000.
+	PageChars int `json:"page_chars,omitempty" yaml:"page_chars,omitempty"`
+}
+
+// enabled reports whether any compression is configured.
+func (c CompressionConfig) enabled() bool {
+	return c.KeepRaw > 0 || c.ArtifactThreshold > 0
+}
+
+// fetchArtifactTool is the name of the built-in tool that reads artifacts.
+const fetchArtifactTool = "fetch_artifact"
+
+const compressionPrompt = `You maintain concise working notes for an assistant answering this question:
+
+%s
+
+Update the notes with the facts from the new tool results that could help answer it. Keep numbers, names, identifiers and artifact IDs exactly. Drop everything irrelevant. Reply with the updated notes only.
+
+Current notes:
+%s
+
+New tool results:
+%s`
+
+// observation is a tool result in the conversation.
+type observation struct {
+	index    int
+	tool     string
+	output   string
+	artifact string
+	raw      bool
+}
+
+// contextCompressor applies a CompressionConfig to one run's messages.
+// A nil compressor appends observations verbatim.
+type contextCompressor struct {
+	config       CompressionConfig
+	llm          LLMClient
+	model        string
+	query        string
+	verbose      bool
+	anchor       int
+	notesIndex   int
+	artifacts    []string
+	observations []*observation
+}
+
+// newContextCompressor returns a compressor for a run whose query is the
+// last of messages, or nil if config disables compression.
+func newContextCompressor(config Config, llmClient LLMClient, messages []Message, query string) *contextCompressor {
+	c := config.Compression
+	if !c.enabled() {
+		return nil
+	}
+	if c.PreviewChars <= 0 {
+		c.PreviewChars = 500
+	}
+	if c.PageChars <= 0 {
+		c.PageChars = 4000
+	}
+	return &contextCompressor{
+		config:     c,
+		llm:        llmClient,
+		model:      config.Model,
+		query:      query,
+		verbose:    config.Verbose,
+		anchor:     len(messages),
+		notesIndex: -1,
+	}
+}
+
+// toolDefinitions adds fetch_artifact to defs when compression is on.
+func (c *contextCompressor) toolDefinitions(defs []llm.ToolDefinition) []llm.ToolDefinition {
+	if c == nil {
+		return defs
+	}
+	return append(defs, llm.ToolDefinition{
+		Type: "function",
+		Function: llm.FunctionDefinition{
+			Name:        fetchArtifactTool,
+			Description: "Reads a stored tool output by artifact ID, a page at a time. Use it when a result was stored as an artifact and you need more than its preview or notes.",
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]tools.PropertySchema{
+					"id":     {Type: "string", Description: "The artifact ID, e.g., 'artifact_1'"},
+					"offset": {Type: "integer", Description: "Character offset to start reading from; default 0"},
+				},
+				"required": []string{"id"},
+			},
+		},
+	})
+}
+
+// handles reports whether the tool call is served by the compressor.
+func (c *contextCompressor) handles(toolName string) bool {
+	return c != nil && toolName == fetchArtifactTool
+}
+
+// store saves output as a new artifact and returns its ID.
+func (c *contextCompressor) store(output string) string {
+	c.artifacts = append(c.artifacts, output)
+	return fmt.Sprintf("artifact_%d", len(c.artifacts))
+}
+
+// fetchArtifactArgs represents the arguments for the fetch_artifact tool.
+type fetchArtifactArgs struct {
+	ID     string `json:"id"`
+	Offset int    `json:"offset"`
+}
+
+// fetch returns a page of an artifact.
+func (c *contextCompressor) fetch(arguments string) tools.Result {
+	args, err := tools.ParseArguments[fetchArtifactArgs](arguments)
+	if err != nil {
+		return tools.Failure("Invalid arguments: " + err.Error())
+	}
+	var n int
+	if _, err := fmt.Sscanf(args.ID, "artifact_%d", &n); err != nil || n < 1 || n > len(c.artifacts) {
+		return tools.Failure(fmt.Sprintf("Unknown artifact %q", args.ID))
+	}
+	content := c.artifacts[n-1]
+	if args.Offset < 0 || args.Offset >= len(content) {
+		return tools.Failure(fmt.Sprintf("Offset %d is outside artifact %s of %d characters", args.Offset, args.ID, len(content)))
+	}
+
+	end := min(args.Offset+c.config.PageChars, len(content))
+	page := fmt.Sprintf("[%s, characters %d-%d of %d]\n%s", args.ID, args.Offset, end, len(content),
+		strings.ToValidUTF8(content[args.Offset:end], ""))
+	if end < len(content) {
+		page += fmt.Sprintf("\n[Call %s with offset %d to read more.]", fetchArtifactTool, end)
+	}
+	return tools.Success(page)
+}
+
+// appendObservation appends a tool result to messages, replacing a large
+// output by a preview of its artifact.
+func (c *contextCompressor) appendObservation(messages []Message, toolName, output string) []Message {
+	if c == nil {
+		return append(messages, Message{Role: "tool", Content: output})
+	}
+
+	obs := &observation{index: len(messages), tool: toolName, output: output, raw: true}
+	content := output
+	if c.config.ArtifactThreshold > 0 && len(output) > c.config.ArtifactThreshold && toolName != fetchArtifactTool {
+		obs.artifact = c.store(output)
+		preview := min(c.config.PreviewChars, len(output))
+		content = fmt.Sprintf("%s...\n[Output truncated at %d of %d characters and stored as %s. Call %s to read more.]",
+			strings.ToValidUTF8(output[:preview], ""), preview, len(output), obs.artifact, fetchArtifactTool)
+	}
+	c.observations = append(c.observations, obs)
+	return append(messages, Message{Role: "tool", Content: content})
+}
+
+// compress replaces all but the last KeepRaw observations by references to
+// their artifacts and, with Summarize, folds them into the run's notes. It
+// returns the updated messages, the usage of the summary call, and a step
+// describing the compression, if any took place.
+func (c *contextCompressor) compress(ctx context.Context, messages []Message) ([]Message, Usage, *Step) {
+	if c == nil || c.config.KeepRaw <= 0 {
+		return messages, Usage{}, nil
+	}
+
+	var raw []*observation
+	for _, obs := range c.observations {
+		if obs.raw {
+			raw = append(raw, obs)
+		}
+	}
+	if len(raw) <= c.config.KeepRaw {
+		return messages, Usage{}, nil
+	}
+	older := raw[:len(raw)-c.config.KeepRaw]
+
+	var results strings.Builder
+	for _, obs := range older {
+		obs.raw = false
+		if obs.tool == fetchArtifactTool {
+			messages[obs.index].Content = "[Earlier artifact page compressed; fetch it again if needed.]"
+		} else {
+			if obs.artifact == "" {
+				obs.artifact = c.store(obs.output)
+			}
+			messages[obs.index].Content = fmt.Sprintf("[Earlier result of %s compressed and stored as %s.]", obs.tool, obs.artifact)
+		}
+		label := obs.tool
+		if obs.artifact != "" {
+			label += " (" + obs.artifact + ")"
+		}
+		results.WriteString(fmt.Sprintf("%s:\n%s\n\n", label, truncate(obs.output, c.config.PageChars)))
+	}
+	step := &Step{Type: "compression", Content: fmt.Sprintf("Compressed %d earlier observations", len(older))}
+
+	if !c.config.Summarize {
+		return messages, Usage{}, step
+	}
+
+	notes := ""
+	if c.notesIndex >= 0 {
+		notes = strings.TrimPrefix(messages[c.notesIndex].Content, notesHeader)
+	}
+	resp, err := c.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{{
+			Role:    llm.RoleUser,
+			Content: fmt.Sprintf(compressionPrompt, c.query, notes, strings.TrimSpace(results.String())),
+		}},
+		Model: c.model,
+	})
+	if err != nil {
+		// The references remain, so the run can fetch what it needs
+		log.Printf("[ReAct] Summarizing observations failed: %v", err)
+		return messages, Usage{}, step
+	}
+	if c.verbose {
+		log.Printf("[ReAct] Notes: %s", resp.Content)
+	}
+
+	if c.notesIndex < 0 {
+		messages = c.insert(messages, c.anchor, Message{Role: "system"})
+		c.notesIndex = c.anchor
+	}
+	messages[c.notesIndex].Content = notesHeader + resp.Content
+	step.Content += ":\n" + resp.Content
+	return messages, toUsage(resp.Usage), step
+}
+
+// notesHeader introduces the notes summarizing compressed observations.
+const notesHeader = "Notes from earlier tool results:\n"
+
+// insert inserts msg at index i, keeping the observation indexes current.
+func (c *contextCompressor) insert(messages []Message, i int, msg Message) []Message {
+	messages = append(messages[:i], append([]Message{msg}, messages[i:]...)...)
+	for _, obs := range c.observations {
+		if obs.index >= i {
+			obs.index++
+		}
+	}
+	return messages
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func TestReActAgent_Compression(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(NewAgentTool(&funcAgent{run: func(_ context.Context, query string) (*Response, error) {
+		return &Response{Output: strings.Repeat(query, 150)}, nil
+	}}, AgentToolConfig{Name: "search"}))
+
+	calls := []llm.ToolCall{
+		{ID: "1", Name: "search", Arguments: `{"query": "a"}`},
+		{ID: "2", Name: "search", Arguments: `{"query": "b"}`},
+		{ID: "3", Name: fetchArtifactTool, Arguments: `{"id": "artifact_1", "offset": 140}`},
+	}
+	var requests []*llm.ChatWithToolsRequest
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			requests = append(requests, req)
+			if n := len(requests); n <= len(calls) {
+				return &llm.ChatWithToolsResponse{ToolCalls: calls[n-1 : n]}, nil
+			}
+			return &llm.ChatWithToolsResponse{Content: "done"}, nil
+		},
+		ChatFunc: func(context.Context, *llm.ChatRequest) (*llm.ChatResponse, error) {
+			return &llm.ChatResponse{Content: "search(a) returned only a's", Usage: llm.Usage{TotalTokens: 7}}, nil
+		},
+	}
+
+	a := NewReActAgent(mock, registry, Config{Compression: CompressionConfig{
+		KeepRaw:           1,
+		Summarize:         true,
+		ArtifactThreshold: 100,
+		PreviewChars:      20,
+	}})
+	resp, err := a.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A large output is shown as a preview of its artifact
+	second := requests[1].Messages
+	if last := second[len(second)-1].Content; !strings.HasPrefix(last, strings.Repeat("a", 20)+"...") || !strings.Contains(last, "stored as artifact_1") {
+		t.Errorf("expected an artifact preview, got %q", last)
+	}
+	if tools := requests[0].Tools; tools[len(tools)-1].Function.Name != fetchArtifactTool {
+		t.Errorf("expected %s to be offered", fetchArtifactTool)
+	}
+
+	// Once two observations exist, the older one is replaced by notes and a reference
+	third := requests[2].Messages
+	if third[2].Role != "system" || third[2].Content != notesHeader+"search(a) returned only a's" {
+		t.Errorf("expected notes after the query, got %+v", third[2])
+	}
+	if third[4].Content != "[Earlier result of search compressed and stored as artifact_1.]" {
+		t.Errorf("expected the first observation to be compressed, got %q", third[4].Content)
+	}
+
+	// The compressed observation remains readable as an artifact
+	fourth := requests[3].Messages
+	if last := fourth[len(fourth)-1].Content; last != "[artifact_1, characters 140-150 of 150]\naaaaaaaaaa" {
+		t.Errorf("unexpected artifact page %q", last)
+	}
+
+	if resp.Output != "done" || resp.Usage.TotalTokens != 14 {
+		t.Errorf("expected both summary calls to be counted, got %q %+v", resp.Output, resp.Usage)
+	}
+	var compressions int
+	for _, step := range resp.Steps {
+		if step.Type == "compression" {
+			compressions++
+		}
+	}
+	if compressions != 2 {
+		t.Errorf("expected 2 compression steps, got %d", compressions)
+	}
+}
@@ -0,0 +1,381 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// Strategy names the agent pattern an AgentDefinition builds.
+type Strategy string
+
+const (
+	StrategyReAct        Strategy = "react"
+	StrategyReflexion    Strategy = "reflexion"
+	StrategyOrchestrator Strategy = "orchestrator"
+	StrategyCascade      Strategy = "cascade"
+)
+
+// Definitions is a declarative set of agents and orchestrator workers,
+// typically loaded from a YAML or JSON file at startup.
+//
+// Example (YAML):
+//
+//	workers:
+//	  - name: analyst
+//	    description: Analyzes numbers
+//	    tools: [calculator]
+//	agents:
+//	  - name: math-tutor
+//	    strategy: reflexion
+//	    tools: [calculator]
+//	    max_reflections: 2
+//	  - name: team
+//	    strategy: orchestrator
+//	    tools: [calculator]
+//	    workers: [analyst, writer]
+//	  - name: thrifty
+//	    strategy: cascade
+//	    tiers:
+//	      - {provider: ollama, model: llama3.2}
+//	      - {provider: openai, model: gpt-4o}
+type Definitions struct {
+	Agents  []AgentDefinition  `json:"agents" yaml:"agents"`
+	Workers []WorkerDefinition `json:"workers" yaml:"workers"`
+}
+
+// AgentDefinition declares an agent served at /api/agents/{name}/run.
+type AgentDefinition struct {
+	// Name identifies the agent and is used in its URL path.
+	Name string `json:"name" yaml:"name"`
+
+	// Description explains what the agent is for.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Strategy selects the agent pattern. Default is react.
+	Strategy Strategy `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// SystemPrompt overrides the strategy's default system prompt.
+	SystemPrompt string `json:"system_prompt,omitempty" yaml:"system_prompt,omitempty"`
+
+	// Tools lists the tools the agent may call. For orchestrators it bounds
+	// the tools available to workers.
+	Tools []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+
+	// Provider selects the LLM client. Empty uses the default provider.
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+
+	// Model overrides the provider's default model.
+	Model string `json:"model,omitempty" yaml:"model,omitempty"`
+
+	// Temperature is the sampling temperature. Zero uses the client default.
+	Temperature float32 `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+
+	// MaxIterations bounds the reasoning loop.
+	MaxIterations int `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+
+	// MaxReflections bounds reflexion attempts.
+	MaxReflections int `json:"max_reflections,omitempty" yaml:"max_reflections,omitempty"`
+
+	// QualityThreshold is the reflexion or cascade acceptance score (0-10).
+	QualityThreshold float64 `json:"quality_threshold,omitempty" yaml:"quality_threshold,omitempty"`
+
+	// Workers lists the orchestrator's workers by name, from the defined
+	// workers or the built-in ones. Empty registers all of them.
+	Workers []string `json:"workers,omitempty" yaml:"workers,omitempty"`
+
+	// MaxWorkers bounds concurrently running orchestrator subtasks.
+	MaxWorkers int `json:"max_workers,omitempty" yaml:"max_workers,omitempty"`
+
+	// Compression compresses tool observations in long ReAct loops.
+	Compression CompressionConfig `json:"compression,omitempty" yaml:"compression,omitempty"`
+
+	// LoopDetection intervenes in repeated tool calls in ReAct loops.
+	LoopDetection LoopDetectionConfig `json:"loop_detection,omitempty" yaml:"loop_detection,omitempty"`
+
+	// Tiers lists a cascade's models, cheapest first.
+	Tiers []TierDefinition `json:"tiers,omitempty" yaml:"tiers,omitempty"`
+}
+
+// TierDefinition declares a cascade tier.
+type TierDefinition struct {
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Model    string `json:"model,omitempty" yaml:"model,omitempty"`
+}
+
+// WorkerDefinition declares an orchestrator worker.
+type WorkerDefinition struct {
+	Name          string   `json:"name" yaml:"name"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	SystemPrompt  string   `json:"system_prompt,omitempty" yaml:"system_prompt,omitempty"`
+	Tools         []string `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Provider      string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Model         string   `json:"model,omitempty" yaml:"model,omitempty"`
+	Temperature   float32  `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	MaxIterations int      `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+}
+
+// BuildEnv supplies the dependencies agents are built with.
+type BuildEnv struct {
+	// Clients maps provider names to LLM clients.
+	Clients map[string]LLMClient
+
+	// DefaultProvider is used when a definition leaves Provider empty.
+	DefaultProvider string
+
+	// Tools is the registry definitions are validated against.
+	Tools *tools.Registry
+
+	// Verbose enables detailed logging in built agents.
+	Verbose bool
+
+	// Guardrails, when set, is applied to every built agent.
+	Guardrails *guardrail.Pipeline
+}
+
+// client returns the LLM client for a provider name.
+func (env BuildEnv) client(provider string) (LLMClient, bool) {
+	if provider == "" {
+		provider = env.DefaultProvider
+	}
+	c, ok := env.Clients[provider]
+	return c, ok && c != nil
+}
+
+var definitionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// LoadDefinitions reads agent and worker definitions from a .yaml, .yml or
+// .json file. Unknown fields are rejected.
+func LoadDefinitions(path string) (*Definitions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read definitions: %w", err)
+	}
+
+	var defs Definitions
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&defs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&defs); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported definitions format %q", ext)
+	}
+
+	return &defs, nil
+}
+
+// Validate checks names, strategies, tools, providers, worker references
+// and limits against env. All problems are reported together.
+func (d *Definitions) Validate(env BuildEnv) error {
+	var errs []error
+
+	workerNames := make(map[string]bool)
+	for _, w := range DefaultWorkers() {
+		workerNames[w.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, w := range d.Workers {
+		prefix := fmt.Sprintf("worker %q", w.Name)
+		errs = append(errs, validateName(prefix, w.Name, seen)...)
+		errs = append(errs, validateCommon(prefix, env, w.Tools, w.Provider, w.Temperature, w.MaxIterations)...)
+		workerNames[w.Name] = true
+	}
+
+	seen = make(map[string]bool)
+	for _, a := range d.Agents {
+		prefix := fmt.Sprintf("agent %q", a.Name)
+		errs = append(errs, validateName(prefix, a.Name, seen)...)
+		errs = append(errs, validateCommon(prefix, env, a.Tools, a.Provider, a.Temperature, a.MaxIterations)...)
+		if !a.LoopDetection.valid() {
+			errs = append(errs, fmt.Errorf("%s: unknown loop_detection strategy %q", prefix, a.LoopDetection.Strategy))
+		}
+
+		switch a.strategy() {
+		case StrategyReAct:
+		case StrategyReflexion:
+			if a.MaxReflections < 0 {
+				errs = append(errs, fmt.Errorf("%s: max_reflections must not be negative", prefix))
+			}
+			if a.QualityThreshold < 0 || a.QualityThreshold > 10 {
+				errs = append(errs, fmt.Errorf("%s: quality_threshold must be between 0 and 10", prefix))
+			}
+		case StrategyOrchestrator:
+			if a.MaxWorkers < 0 {
+				errs = append(errs, fmt.Errorf("%s: max_workers must not be negative", prefix))
+			}
+			for _, name := range a.Workers {
+				if !workerNames[name] {
+					errs = append(errs, fmt.Errorf("%s: unknown worker %q", prefix, name))
+				}
+			}
+		case StrategyCascade:
+			if len(a.Tiers) == 0 {
+				errs = append(errs, fmt.Errorf("%s: cascade needs at least one tier", prefix))
+			}
+			for _, tier := range a.Tiers {
+				if _, ok := env.client(tier.Provider); !ok {
+					errs = append(errs, fmt.Errorf("%s: unknown provider %q", prefix, tier.Provider))
+				}
+			}
+			if a.QualityThreshold < 0 || a.QualityThreshold > 10 {
+				errs = append(errs, fmt.Errorf("%s: quality_threshold must be between 0 and 10", prefix))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown strategy %q", prefix, a.Strategy))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateName checks that name is non-empty, URL-safe and unique.
+func validateName(prefix, name string, seen map[string]bool) []error {
+	var errs []error
+	if !definitionNamePattern.MatchString(name) {
+		errs = append(errs, fmt.Errorf("%s: name must be non-empty and contain only letters, digits, '-' or '_'", prefix))
+	}
+	if seen[name] {
+		errs = append(errs, fmt.Errorf("%s: duplicate name", prefix))
+	}
+	seen[name] = true
+	return errs
+}
+
+// validateCommon checks the fields shared by agent and worker definitions.
+func validateCommon(prefix string, env BuildEnv, toolNames []string, provider string, temperature float32, maxIterations int) []error {
+	var errs []error
+	for _, name := range toolNames {
+		if env.Tools == nil || !env.Tools.Has(name) {
+			errs = append(errs, fmt.Errorf("%s: unknown tool %q", prefix, name))
+		}
+	}
+	if _, ok := env.client(provider); !ok {
+		errs = append(errs, fmt.Errorf("%s: unknown provider %q", prefix, provider))
+	}
+	if temperature < 0 || temperature > 2 {
+		errs = append(errs, fmt.Errorf("%s: temperature must be between 0 and 2", prefix))
+	}
+	if maxIterations < 0 {
+		errs = append(errs, fmt.Errorf("%s: max_iterations must not be negative", prefix))
+	}
+	return errs
+}
+
+// strategy returns the definition's strategy, defaulting to react.
+func (a AgentDefinition) strategy() Strategy {
+	if a.Strategy == "" {
+		return StrategyReAct
+	}
+	return a.Strategy
+}
+
+// BuildWorkers returns the built-in workers overlaid with the defined ones,
+// sorted by name. Definitions must have passed Validate.
+func (d *Definitions) BuildWorkers(env BuildEnv) []*WorkerAgent {
+	byName := make(map[string]*WorkerAgent)
+	for _, w := range DefaultWorkers() {
+		byName[w.Name] = w
+	}
+	for _, def := range d.Workers {
+		llmClient, _ := env.client(def.Provider)
+		byName[def.Name] = &WorkerAgent{
+			Name:          def.Name,
+			Description:   def.Description,
+			SystemPrompt:  def.SystemPrompt,
+			Tools:         def.Tools,
+			Model:         def.Model,
+			Temperature:   def.Temperature,
+			MaxIterations: def.MaxIterations,
+			llm:           llmClient,
+		}
+	}
+
+	workers := make([]*WorkerAgent, 0, len(byName))
+	for _, w := range byName {
+		workers = append(workers, w)
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
+	return workers
+}
+
+// Build validates the definitions and constructs the declared agents by name.
+func (d *Definitions) Build(env BuildEnv) (map[string]Agent, error) {
+	if err := d.Validate(env); err != nil {
+		return nil, err
+	}
+
+	agents := make(map[string]Agent, len(d.Agents))
+	for _, def := range d.Agents {
+		llmClient, _ := env.client(def.Provider)
+		registry := restrictTools(env.Tools, def.Tools)
+		config := Config{
+			SystemPrompt:  def.SystemPrompt,
+			MaxIterations: def.MaxIterations,
+			Model:         def.Model,
+			Temperature:   def.Temperature,
+			Verbose:       env.Verbose,
+			Guardrails:    env.Guardrails,
+			Compression:   def.Compression,
+			LoopDetection: def.LoopDetection,
+		}
+
+		switch def.strategy() {
+		case StrategyReAct:
+			agents[def.Name] = NewReActAgent(llmClient, registry, config)
+		case StrategyReflexion:
+			agents[def.Name] = NewReflexionAgent(llmClient, registry, ReflexionConfig{
+				Config:           config,
+				MaxReflections:   def.MaxReflections,
+				QualityThreshold: def.QualityThreshold,
+			})
+		case StrategyOrchestrator:
+			orch := NewOrchestratorAgent(llmClient, registry, OrchestratorConfig{
+				Config:     config,
+				MaxWorkers: def.MaxWorkers,
+			})
+			// Each orchestrator gets its own worker instances
+			for _, w := range d.BuildWorkers(env) {
+				if len(def.Workers) == 0 || slices.Contains(def.Workers, w.Name) {
+					orch.RegisterWorker(w)
+				}
+			}
+			agents[def.Name] = orch
+		case StrategyCascade:
+			tiers := make([]CascadeTier, len(def.Tiers))
+			for i, tier := range def.Tiers {
+				tierClient, _ := env.client(tier.Provider)
+				tiers[i] = CascadeTier{Name: tier.Name, Client: tierClient, Model: tier.Model}
+			}
+			agents[def.Name] = NewCascadeAgent(llmClient, registry, CascadeConfig{
+				Config:           config,
+				Tiers:            tiers,
+				QualityThreshold: def.QualityThreshold,
+			})
+		}
+	}
+
+	return agents, nil
+}
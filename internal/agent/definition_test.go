@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func newDefinitionEnv(client LLMClient) BuildEnv {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	return BuildEnv{
+		Clients:         map[string]LLMClient{"openai": client},
+		DefaultProvider: "openai",
+		Tools:           registry,
+	}
+}
+
+func writeDefinitions(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write definitions: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefinitions_Formats(t *testing.T) {
+	yamlPath := writeDefinitions(t, "agents.yaml", `
+workers:
+  - name: analyst
+    tools: [calculator]
+agents:
+  - name: tutor
+    strategy: reflexion
+    max_reflections: 2
+`)
+	jsonPath := writeDefinitions(t, "agents.json", `{
+		"workers": [{"name": "analyst", "tools": ["calculator"]}],
+		"agents": [{"name": "tutor", "strategy": "reflexion", "max_reflections": 2}]
+	}`)
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		defs, err := LoadDefinitions(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		if len(defs.Agents) != 1 || defs.Agents[0].MaxReflections != 2 {
+			t.Errorf("%s: unexpected agents %+v", path, defs.Agents)
+		}
+		if len(defs.Workers) != 1 || defs.Workers[0].Tools[0] != "calculator" {
+			t.Errorf("%s: unexpected workers %+v", path, defs.Workers)
+		}
+	}
+}
+
+func TestLoadDefinitions_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"unknown yaml field", "a.yaml", "agents:\n  - name: a\n    colour: blue\n"},
+		{"unknown json field", "a.json", `{"agents": [{"name": "a", "colour": "blue"}]}`},
+		{"unsupported format", "a.toml", "agents = []"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadDefinitions(writeDefinitions(t, tt.file, tt.content)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestDefinitions_Validate(t *testing.T) {
+	defs := &Definitions{
+		Workers: []WorkerDefinition{
+			{Name: "analyst", Tools: []string{"web_search"}},
+		},
+		Agents: []AgentDefinition{
+			{Name: "ok", Tools: []string{"calculator"}},
+			{Name: "ok"},
+			{Name: "bad name"},
+			{Name: "strat", Strategy: "swarm"},
+			{Name: "prov", Provider: "claude"},
+			{Name: "team", Strategy: StrategyOrchestrator, Workers: []string{"analyst", "writer", "ghost"}},
+			{Name: "tutor", Strategy: StrategyReflexion, QualityThreshold: 11},
+			{Name: "thrifty", Strategy: StrategyCascade},
+		},
+	}
+
+	err := defs.Validate(newDefinitionEnv(&MockLLMClient{}))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	for _, want := range []string{
+		`worker "analyst": unknown tool "web_search"`,
+		`agent "ok": duplicate name`,
+		`agent "bad name": name must be`,
+		`agent "strat": unknown strategy "swarm"`,
+		`agent "prov": unknown provider "claude"`,
+		`agent "team": unknown worker "ghost"`,
+		`agent "tutor": quality_threshold`,
+		`agent "thrifty": cascade needs at least one tier`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), `unknown worker "writer"`) {
+		t.Error("expected built-in worker to be accepted")
+	}
+}
+
+func TestDefinitions_Build(t *testing.T) {
+	var toolNames []string
+	var model string
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			toolNames = nil
+			for _, tool := range req.Tools {
+				toolNames = append(toolNames, tool.Function.Name)
+			}
+			model = req.Model
+			return &llm.ChatWithToolsResponse{Content: "answer"}, nil
+		},
+	}
+
+	defs := &Definitions{
+		Workers: []WorkerDefinition{{Name: "analyst", Tools: []string{"calculator"}, Model: "small"}},
+		Agents: []AgentDefinition{
+			{Name: "quick", Tools: []string{"calculator"}, Model: "fast"},
+			{Name: "plain"},
+			{Name: "tutor", Strategy: StrategyReflexion},
+			{Name: "team", Strategy: StrategyOrchestrator, Workers: []string{"analyst", "writer"}},
+			{Name: "thrifty", Strategy: StrategyCascade, Tiers: []TierDefinition{{Model: "small"}, {Model: "large"}}},
+		},
+	}
+
+	agents, err := defs.Build(newDefinitionEnv(mock))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(agents) != 5 {
+		t.Fatalf("expected 5 agents, got %d", len(agents))
+	}
+
+	if _, err := agents["quick"].Run(context.Background(), "q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(toolNames) != 1 || toolNames[0] != "calculator" || model != "fast" {
+		t.Errorf("expected calculator tool and model fast, got %v and %q", toolNames, model)
+	}
+
+	if _, err := agents["plain"].Run(context.Background(), "q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(toolNames) != 0 {
+		t.Errorf("expected no tools for agent without tools, got %v", toolNames)
+	}
+
+	if _, ok := agents["tutor"].(*ReflexionAgent); !ok {
+		t.Errorf("expected reflexion agent, got %T", agents["tutor"])
+	}
+
+	if _, ok := agents["thrifty"].(*CascadeAgent); !ok {
+		t.Errorf("expected cascade agent, got %T", agents["thrifty"])
+	}
+
+	team, ok := agents["team"].(*OrchestratorAgent)
+	if !ok {
+		t.Fatalf("expected orchestrator agent, got %T", agents["team"])
+	}
+	workers := team.ListWorkers()
+	if len(workers) != 2 || workers[0].Name != "analyst" || workers[0].Model != "small" || workers[1].Name != "writer" {
+		t.Errorf("expected analyst and writer workers, got %v", workers)
+	}
+}
+
+func TestDefinitions_BuildWorkers(t *testing.T) {
+	defs := &Definitions{
+		Workers: []WorkerDefinition{{Name: "writer", Description: "custom writer"}},
+	}
+
+	workers := defs.BuildWorkers(newDefinitionEnv(&MockLLMClient{}))
+	if len(workers) != 4 {
+		t.Fatalf("expected 4 workers, got %d", len(workers))
+	}
+	if last := workers[3]; last.Name != "writer" || last.Description != "custom writer" {
+		t.Errorf("expected defined writer to replace the built-in, got %+v", last)
+	}
+}
+
+func TestLoadDefinitions_Example(t *testing.T) {
+	defs, err := LoadDefinitions("../../configs/agents.example.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := defs.Validate(newDefinitionEnv(&MockLLMClient{})); err != nil {
+		t.Errorf("example definitions are invalid: %v", err)
+	}
+}
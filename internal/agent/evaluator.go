@@ -0,0 +1,493 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hassan123789/go-ai-agent/internal/embedding"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// ErrNotApplicable is returned by an Evaluator that has nothing to judge for
+// an input, e.g. a reference evaluator without a reference answer.
+// CompositeEvaluator skips such evaluators and renormalizes the weights.
+var ErrNotApplicable = errors.New("evaluator not applicable")
+
+// Evaluator scores a response on a 0-10 scale.
+// Implementations must be safe for concurrent use.
+type Evaluator interface {
+	Evaluate(ctx context.Context, input EvalInput) (Evaluation, error)
+}
+
+// EvalInput is what an Evaluator judges.
+type EvalInput struct {
+	// Query is the user query.
+	Query string
+
+	// Response is the answer being evaluated.
+	Response string
+
+	// Reference is an optional expected answer.
+	Reference string
+
+	// Steps is the trace that produced the response.
+	Steps []Step
+}
+
+// EvalOptions selects how a single Reflexion run is evaluated.
+type EvalOptions struct {
+	// Evaluator overrides the agent's configured evaluator.
+	Evaluator Evaluator
+
+	// Reference is an expected answer for reference-based evaluators.
+	Reference string
+}
+
+// evalOptionsKey is the context key holding EvalOptions.
+type evalOptionsKey struct{}
+
+// WithEvalOptions returns a context whose Reflexion runs are evaluated with opts.
+func WithEvalOptions(ctx context.Context, opts EvalOptions) context.Context {
+	return context.WithValue(ctx, evalOptionsKey{}, opts)
+}
+
+// evalOptionsFrom returns the options set by WithEvalOptions.
+func evalOptionsFrom(ctx context.Context) EvalOptions {
+	opts, _ := ctx.Value(evalOptionsKey{}).(EvalOptions)
+	return opts
+}
+
+// LLMJudge scores responses with an LLM-as-judge prompt.
+type LLMJudge struct {
+	llm    LLMClient
+	config LLMJudgeConfig
+}
+
+// LLMJudgeConfig contains configuration for an LLMJudge.
+type LLMJudgeConfig struct {
+	// Prompt is a format string taking the query and the response.
+	// Default is the Reflexion evaluation prompt.
+	Prompt string
+
+	// Model overrides the client's default model.
+	Model string
+
+	// Temperature is the sampling temperature. Default is 0.3.
+	Temperature float32
+}
+
+// NewLLMJudge creates an LLM-as-judge evaluator.
+func NewLLMJudge(llmClient LLMClient, config LLMJudgeConfig) *LLMJudge {
+	if config.Prompt == "" {
+		config.Prompt = defaultEvaluationPrompt
+	}
+	if config.Temperature <= 0 {
+		config.Temperature = 0.3 // Lower temperature for more consistent evaluation
+	}
+
+	return &LLMJudge{
+		llm:    metered(llmClient),
+		config: config,
+	}
+}
+
+// Evaluate asks the LLM to score the response.
+func (j *LLMJudge) Evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	prompt := fmt.Sprintf(j.config.Prompt, input.Query, input.Response)
+
+	resp, err := j.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a critical evaluator. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Model:       j.config.Model,
+		Temperature: j.config.Temperature,
+	})
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("evaluation request failed: %w", err)
+	}
+
+	var eval Evaluation
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &eval); err != nil {
+		return Evaluation{}, fmt.Errorf("failed to parse evaluation: %w", err)
+	}
+
+	return eval, nil
+}
+
+// Aggregation combines the scores of an ensemble.
+type Aggregation string
+
+const (
+	AggregateMean   Aggregation = "mean"
+	AggregateMedian Aggregation = "median"
+	AggregateMin    Aggregation = "min"
+)
+
+// EnsembleEvaluator runs several judges in parallel and aggregates their scores.
+// Judges that fail are ignored as long as at least one succeeds.
+type EnsembleEvaluator struct {
+	judges      []Evaluator
+	aggregation Aggregation
+}
+
+// NewEnsembleEvaluator creates an ensemble. Default aggregation is mean.
+func NewEnsembleEvaluator(judges []Evaluator, aggregation Aggregation) *EnsembleEvaluator {
+	if aggregation == "" {
+		aggregation = AggregateMean
+	}
+
+	return &EnsembleEvaluator{
+		judges:      judges,
+		aggregation: aggregation,
+	}
+}
+
+// Evaluate runs all judges and aggregates their scores.
+func (e *EnsembleEvaluator) Evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	evals := make([]Evaluation, len(e.judges))
+	errs := make([]error, len(e.judges))
+
+	var wg sync.WaitGroup
+	for i, judge := range e.judges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			evals[i], errs[i] = judge.Evaluate(ctx, input)
+		}()
+	}
+	wg.Wait()
+
+	var ok []Evaluation
+	for i, eval := range evals {
+		if errs[i] == nil {
+			ok = append(ok, eval)
+		}
+	}
+	if len(ok) == 0 {
+		return Evaluation{}, fmt.Errorf("all %d judges failed: %w", len(e.judges), errors.Join(errs...))
+	}
+
+	scores := make([]float64, len(ok))
+	for i, eval := range ok {
+		scores[i] = eval.Score
+	}
+
+	var score float64
+	switch e.aggregation {
+	case AggregateMedian:
+		sort.Float64s(scores)
+		mid := len(scores) / 2
+		score = scores[mid]
+		if len(scores)%2 == 0 {
+			score = (scores[mid-1] + scores[mid]) / 2
+		}
+	case AggregateMin:
+		score = scores[0]
+		for _, s := range scores[1:] {
+			score = math.Min(score, s)
+		}
+	default:
+		for _, s := range scores {
+			score += s
+		}
+		score /= float64(len(scores))
+	}
+
+	result := mergeEvaluations(ok, nil)
+	result.Score = score
+	result.Reasoning = fmt.Sprintf("%s of %d judges: %s", e.aggregation, len(ok), result.Reasoning)
+	return result, nil
+}
+
+// ReferenceEvaluator scores a response by embedding similarity to a reference answer.
+type ReferenceEvaluator struct {
+	embedder embedding.Embedder
+}
+
+// NewReferenceEvaluator creates a reference-answer similarity evaluator.
+func NewReferenceEvaluator(embedder embedding.Embedder) *ReferenceEvaluator {
+	return &ReferenceEvaluator{
+		embedder: embedder,
+	}
+}
+
+// Evaluate scores the cosine similarity of the response and the reference.
+// Returns ErrNotApplicable when the input has no reference.
+func (r *ReferenceEvaluator) Evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	if input.Reference == "" {
+		return Evaluation{}, ErrNotApplicable
+	}
+
+	vectors, err := r.embedder.EmbedBatch(ctx, []string{input.Response, input.Reference})
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("failed to embed response: %w", err)
+	}
+
+	similarity := math.Max(0, float64(embedding.CosineSimilarity(vectors[0], vectors[1])))
+	eval := Evaluation{
+		Score:     similarity * 10,
+		Reasoning: fmt.Sprintf("similarity to reference answer is %.2f", similarity),
+	}
+	if similarity < 0.8 {
+		eval.Weaknesses = []string{"response diverges from the reference answer"}
+	}
+	return eval, nil
+}
+
+// RuleConfig lists deterministic checks for a RuleEvaluator.
+type RuleConfig struct {
+	// MustMatch are regular expressions the response must match.
+	MustMatch []string `json:"must_match,omitempty"`
+
+	// MustNotMatch are regular expressions the response must not match.
+	MustNotMatch []string `json:"must_not_match,omitempty"`
+
+	// JSONSchema, when set, requires the response to be JSON matching the schema.
+	// Supports type, properties, required, items and enum.
+	JSONSchema map[string]any `json:"json_schema,omitempty"`
+}
+
+// RuleEvaluator scores a response by the share of rules it passes.
+type RuleEvaluator struct {
+	mustMatch    []*regexp.Regexp
+	mustNotMatch []*regexp.Regexp
+	schema       map[string]any
+}
+
+// NewRuleEvaluator compiles the rules, returning an error for invalid patterns.
+func NewRuleEvaluator(config RuleConfig) (*RuleEvaluator, error) {
+	e := &RuleEvaluator{schema: config.JSONSchema}
+
+	for _, p := range config.MustMatch {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid must_match pattern %q: %w", p, err)
+		}
+		e.mustMatch = append(e.mustMatch, re)
+	}
+	for _, p := range config.MustNotMatch {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid must_not_match pattern %q: %w", p, err)
+		}
+		e.mustNotMatch = append(e.mustNotMatch, re)
+	}
+
+	return e, nil
+}
+
+// Evaluate checks every rule. Returns ErrNotApplicable when no rules are set.
+func (e *RuleEvaluator) Evaluate(_ context.Context, input EvalInput) (Evaluation, error) {
+	var passed, total int
+	var eval Evaluation
+
+	check := func(ok bool, rule string) {
+		total++
+		if ok {
+			passed++
+			eval.Strengths = append(eval.Strengths, rule)
+		} else {
+			eval.Weaknesses = append(eval.Weaknesses, rule)
+		}
+	}
+
+	for _, re := range e.mustMatch {
+		check(re.MatchString(input.Response), fmt.Sprintf("matches /%s/", re))
+	}
+	for _, re := range e.mustNotMatch {
+		check(!re.MatchString(input.Response), fmt.Sprintf("does not match /%s/", re))
+	}
+	if e.schema != nil {
+		var value any
+		if err := json.Unmarshal([]byte(extractJSON(input.Response)), &value); err != nil {
+			check(false, "is valid JSON")
+		} else {
+			violations := guardrail.ValidateSchema(value, e.schema)
+			check(len(violations) == 0, "matches the JSON schema")
+			eval.Weaknesses = append(eval.Weaknesses, violations...)
+		}
+	}
+
+	if total == 0 {
+		return Evaluation{}, ErrNotApplicable
+	}
+
+	eval.Score = 10 * float64(passed) / float64(total)
+	eval.Reasoning = fmt.Sprintf("passed %d of %d rules", passed, total)
+	return eval, nil
+}
+
+// ToolVerifier re-runs the tool calls recorded in a trace and checks that
+// the observations and the final response agree with the fresh results,
+// e.g. re-evaluating calculator expressions.
+type ToolVerifier struct {
+	registry *tools.Registry
+	tools    []string
+}
+
+// NewToolVerifier creates a verifier for the named tools in registry.
+// Default tools are ["calculator"]. Only deterministic tools should be listed.
+func NewToolVerifier(registry *tools.Registry, toolNames []string) *ToolVerifier {
+	if len(toolNames) == 0 {
+		toolNames = []string{"calculator"}
+	}
+
+	return &ToolVerifier{
+		registry: registry,
+		tools:    toolNames,
+	}
+}
+
+// Evaluate re-executes verifiable tool calls. Returns ErrNotApplicable when
+// the trace has none.
+func (v *ToolVerifier) Evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	var eval Evaluation
+	var passed, total int
+	var lastOutput string
+
+	for i, step := range input.Steps {
+		if step.Type != StepTypeAction || step.Agent != "" || !v.verifiable(step.ToolName) {
+			continue
+		}
+		observed, ok := observationFor(input.Steps[i+1:], step.ToolName)
+		if !ok {
+			continue
+		}
+
+		result, err := executeTool(ctx, v.registry, step.ToolName, step.ToolInput)
+		if err != nil {
+			return Evaluation{}, fmt.Errorf("failed to re-run %s: %w", step.ToolName, err)
+		}
+
+		total++
+		if result.String() == observed {
+			passed++
+			lastOutput = result.Output
+		} else {
+			eval.Weaknesses = append(eval.Weaknesses, fmt.Sprintf("%s(%s) returned %q on re-run, but the trace recorded %q",
+				step.ToolName, step.ToolInput, result.String(), observed))
+		}
+	}
+
+	if total == 0 {
+		return Evaluation{}, ErrNotApplicable
+	}
+
+	// The final answer should state the last verified result
+	total++
+	if lastOutput != "" && strings.Contains(input.Response, strings.TrimSpace(lastOutput)) {
+		passed++
+		eval.Strengths = append(eval.Strengths, "response states the verified tool result")
+	} else {
+		eval.Weaknesses = append(eval.Weaknesses, "response does not state the verified tool result")
+	}
+
+	eval.Score = 10 * float64(passed) / float64(total)
+	eval.Reasoning = fmt.Sprintf("%d of %d tool checks passed", passed, total)
+	return eval, nil
+}
+
+// verifiable reports whether calls to the named tool can be re-run.
+func (v *ToolVerifier) verifiable(name string) bool {
+	return slices.Contains(v.tools, name) && v.registry != nil && v.registry.Has(name)
+}
+
+// observationFor returns the output of the next top-level observation of a tool.
+func observationFor(steps []Step, toolName string) (string, bool) {
+	for _, s := range steps {
+		if s.Type == StepTypeObservation && s.Agent == "" && s.ToolName == toolName {
+			return s.ToolOutput, true
+		}
+	}
+	return "", false
+}
+
+// WeightedEvaluator is an Evaluator with a name and weight in a composite.
+type WeightedEvaluator struct {
+	Name      string
+	Evaluator Evaluator
+	Weight    float64
+}
+
+// CompositeEvaluator combines evaluators into a weighted average score.
+// Evaluators returning ErrNotApplicable are skipped; any other error fails
+// the evaluation.
+type CompositeEvaluator struct {
+	evaluators []WeightedEvaluator
+}
+
+// NewCompositeEvaluator creates a weighted composite. Non-positive weights default to 1.
+func NewCompositeEvaluator(evaluators ...WeightedEvaluator) *CompositeEvaluator {
+	for i := range evaluators {
+		if evaluators[i].Weight <= 0 {
+			evaluators[i].Weight = 1
+		}
+	}
+
+	return &CompositeEvaluator{
+		evaluators: evaluators,
+	}
+}
+
+// Evaluate runs every evaluator and returns the weighted average score.
+func (c *CompositeEvaluator) Evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	var evals []Evaluation
+	var names []string
+	var weighted, totalWeight float64
+
+	for _, we := range c.evaluators {
+		eval, err := we.Evaluator.Evaluate(ctx, input)
+		if errors.Is(err, ErrNotApplicable) {
+			continue
+		}
+		if err != nil {
+			return Evaluation{}, fmt.Errorf("%s evaluator: %w", we.Name, err)
+		}
+		evals = append(evals, eval)
+		names = append(names, we.Name)
+		weighted += eval.Score * we.Weight
+		totalWeight += we.Weight
+	}
+
+	if totalWeight == 0 {
+		return Evaluation{}, ErrNotApplicable
+	}
+
+	result := mergeEvaluations(evals, names)
+	result.Score = weighted / totalWeight
+	return result, nil
+}
+
+// mergeEvaluations combines strengths, weaknesses and reasoning. Names, if
+// given, label each evaluation's reasoning.
+func mergeEvaluations(evals []Evaluation, names []string) Evaluation {
+	var merged Evaluation
+	var reasons []string
+
+	for i, eval := range evals {
+		merged.Strengths = append(merged.Strengths, eval.Strengths...)
+		merged.Weaknesses = append(merged.Weaknesses, eval.Weaknesses...)
+		if eval.Reasoning == "" {
+			continue
+		}
+		if names != nil {
+			reasons = append(reasons, fmt.Sprintf("%s (%.1f): %s", names[i], eval.Score, eval.Reasoning))
+		} else {
+			reasons = append(reasons, eval.Reasoning)
+		}
+	}
+
+	merged.Reasoning = strings.Join(reasons, "; ")
+	return merged
+}
@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// fixedEvaluator returns a fixed evaluation or error.
+type fixedEvaluator struct {
+	eval Evaluation
+	err  error
+}
+
+func (f fixedEvaluator) Evaluate(_ context.Context, _ EvalInput) (Evaluation, error) {
+	return f.eval, f.err
+}
+
+func TestLLMJudge_Evaluate(t *testing.T) {
+	var temperature float32
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			temperature = req.Temperature
+			return &llm.ChatResponse{Content: "```json\n{\"score\": 6.5, \"reasoning\": \"ok\"}\n```"}, nil
+		},
+	}
+
+	eval, err := NewLLMJudge(mock, LLMJudgeConfig{}).Evaluate(context.Background(), EvalInput{Query: "q", Response: "r"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eval.Score != 6.5 || temperature != 0.3 {
+		t.Errorf("expected score 6.5 at temperature 0.3, got %v at %v", eval.Score, temperature)
+	}
+}
+
+func TestEnsembleEvaluator_Aggregation(t *testing.T) {
+	judges := []Evaluator{
+		fixedEvaluator{eval: Evaluation{Score: 2}},
+		fixedEvaluator{eval: Evaluation{Score: 8}},
+		fixedEvaluator{eval: Evaluation{Score: 9}},
+		fixedEvaluator{err: errors.New("judge down")},
+	}
+
+	tests := []struct {
+		aggregation Aggregation
+		want        float64
+	}{
+		{AggregateMean, 19.0 / 3},
+		{AggregateMedian, 8},
+		{AggregateMin, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.aggregation), func(t *testing.T) {
+			eval, err := NewEnsembleEvaluator(judges, tt.aggregation).Evaluate(context.Background(), EvalInput{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if eval.Score != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, eval.Score)
+			}
+		})
+	}
+
+	failing := NewEnsembleEvaluator([]Evaluator{fixedEvaluator{err: errors.New("down")}}, "")
+	if _, err := failing.Evaluate(context.Background(), EvalInput{}); err == nil {
+		t.Error("expected error when all judges fail")
+	}
+}
+
+func TestReferenceEvaluator(t *testing.T) {
+	e := NewReferenceEvaluator(&bagOfWordsEmbedder{})
+
+	if _, err := e.Evaluate(context.Background(), EvalInput{Response: "r"}); !errors.Is(err, ErrNotApplicable) {
+		t.Errorf("expected ErrNotApplicable without reference, got %v", err)
+	}
+
+	same, _ := e.Evaluate(context.Background(), EvalInput{Response: "Paris is the capital", Reference: "the capital is Paris"})
+	different, _ := e.Evaluate(context.Background(), EvalInput{Response: "bananas are yellow", Reference: "the capital is Paris"})
+	if same.Score < 9.9 || different.Score >= same.Score {
+		t.Errorf("expected matching answer to score higher, got %v and %v", same.Score, different.Score)
+	}
+}
+
+func TestRuleEvaluator(t *testing.T) {
+	e, err := NewRuleEvaluator(RuleConfig{
+		MustMatch:    []string{`\d+`},
+		MustNotMatch: []string{`(?i)as an ai`},
+		JSONSchema: map[string]any{
+			"type":     "object",
+			"required": []any{"answer", "unit"},
+			"properties": map[string]any{
+				"answer": map[string]any{"type": "number"},
+				"unit":   map[string]any{"type": "string", "enum": []any{"km", "mi"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	good, _ := e.Evaluate(context.Background(), EvalInput{Response: `{"answer": 42, "unit": "km"}`})
+	if good.Score != 10 {
+		t.Errorf("expected all rules to pass, got %v: %v", good.Score, good.Weaknesses)
+	}
+
+	bad, _ := e.Evaluate(context.Background(), EvalInput{Response: `{"answer": "42", "unit": "m"}`})
+	if bad.Score >= 10 {
+		t.Errorf("expected schema failure, got %v", bad.Score)
+	}
+	joined := strings.Join(bad.Weaknesses, "; ")
+	if !strings.Contains(joined, "$.answer must be a number") || !strings.Contains(joined, "$.unit is not one of") {
+		t.Errorf("expected schema violations, got %v", bad.Weaknesses)
+	}
+
+	if _, err := NewRuleEvaluator(RuleConfig{MustMatch: []string{"("}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	empty, _ := NewRuleEvaluator(RuleConfig{})
+	if _, err := empty.Evaluate(context.Background(), EvalInput{}); !errors.Is(err, ErrNotApplicable) {
+		t.Errorf("expected ErrNotApplicable without rules, got %v", err)
+	}
+}
+
+func TestToolVerifier(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	v := NewToolVerifier(registry, nil)
+
+	trace := func(recorded string) []Step {
+		return []Step{
+			{Type: StepTypeAction, ToolName: "calculator", ToolInput: `{"expression": "6 * 7"}`},
+			{Type: StepTypeObservation, ToolName: "calculator", ToolOutput: recorded},
+		}
+	}
+
+	good, err := v.Evaluate(context.Background(), EvalInput{Response: "It is 42.", Steps: trace("42")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if good.Score != 10 {
+		t.Errorf("expected verified trace to score 10, got %v: %v", good.Score, good.Weaknesses)
+	}
+
+	tampered, _ := v.Evaluate(context.Background(), EvalInput{Response: "It is 41.", Steps: trace("41")})
+	if tampered.Score != 0 {
+		t.Errorf("expected tampered trace to score 0, got %v", tampered.Score)
+	}
+
+	if _, err := v.Evaluate(context.Background(), EvalInput{Response: "no tools"}); !errors.Is(err, ErrNotApplicable) {
+		t.Errorf("expected ErrNotApplicable without tool calls, got %v", err)
+	}
+}
+
+func TestCompositeEvaluator(t *testing.T) {
+	c := NewCompositeEvaluator(
+		WeightedEvaluator{Name: "a", Evaluator: fixedEvaluator{eval: Evaluation{Score: 10, Reasoning: "great"}}, Weight: 3},
+		WeightedEvaluator{Name: "b", Evaluator: fixedEvaluator{eval: Evaluation{Score: 2}}},
+		WeightedEvaluator{Name: "skip", Evaluator: fixedEvaluator{err: ErrNotApplicable}, Weight: 100},
+	)
+
+	eval, err := c.Evaluate(context.Background(), EvalInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eval.Score != 8 {
+		t.Errorf("expected weighted score 8, got %v", eval.Score)
+	}
+	if !strings.Contains(eval.Reasoning, "a (10.0): great") {
+		t.Errorf("expected labelled reasoning, got %q", eval.Reasoning)
+	}
+
+	failing := NewCompositeEvaluator(WeightedEvaluator{Name: "x", Evaluator: fixedEvaluator{err: errors.New("boom")}})
+	if _, err := failing.Evaluate(context.Background(), EvalInput{}); err == nil {
+		t.Error("expected evaluator error to fail the composite")
+	}
+}
+
+func TestReflexionAgent_EvalOptions(t *testing.T) {
+	judgeCalls := 0
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+			judgeCalls++
+			return &llm.ChatResponse{Content: `{"score": 1}`}, nil
+		},
+	}
+	a := NewReflexionAgent(mock, tools.NewRegistry(), ReflexionConfig{})
+
+	var got EvalInput
+	override := evaluatorFunc(func(_ context.Context, input EvalInput) (Evaluation, error) {
+		got = input
+		return Evaluation{Score: 10}, nil
+	})
+	ctx := WithEvalOptions(context.Background(), EvalOptions{Evaluator: override, Reference: "expected"})
+
+	resp, err := a.Run(ctx, "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if judgeCalls != 0 {
+		t.Errorf("expected default judge to be bypassed, got %d calls", judgeCalls)
+	}
+	if got.Reference != "expected" || got.Response != resp.Output {
+		t.Errorf("expected reference and response to reach the evaluator, got %+v", got)
+	}
+}
+
+// evaluatorFunc adapts a function to the Evaluator interface.
+type evaluatorFunc func(ctx context.Context, input EvalInput) (Evaluation, error)
+
+func (f evaluatorFunc) Evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	return f(ctx, input)
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMaxIterations is returned by a strict run that reaches MaxIterations
+// without a final answer.
+var ErrMaxIterations = errors.New("max iterations exceeded without reaching a final answer")
+
+// Response.Metadata keys set when a run stops at a limit and answers from
+// what it gathered.
+const (
+	metadataIncomplete       = "incomplete"
+	metadataIncompleteReason = "incomplete_reason"
+)
+
+// finalizePrompt asks for an answer from the gathered observations once a
+// run has hit a limit. It is formatted with the reason.
+const finalizePrompt = `You cannot call any more tools (%v). Using only the information gathered above, give your best final answer to the original question now. Say briefly what is uncertain or was left unfinished.`
+
+// strictLimitsKey is the context key marking a run as strict.
+type strictLimitsKey struct{}
+
+// WithStrictLimits returns a context whose runs fail when they hit
+// MaxIterations or their budget, instead of answering from what they
+// gathered so far. The errors are ErrMaxIterations and errors wrapping
+// ErrBudgetExceeded.
+func WithStrictLimits(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictLimitsKey{}, true)
+}
+
+// strictLimits reports whether ctx asks for strict failure at limits.
+func strictLimits(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictLimitsKey{}).(bool)
+	return strict
+}
+
+// withIncomplete flags resp as stopped at a limit, with the reason.
+func withIncomplete(resp *Response, reason error) *Response {
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata[metadataIncomplete] = true
+	resp.Metadata[metadataIncompleteReason] = reason.Error()
+	return resp
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// loopingClient calls the calculator until it is offered no tools, then
+// answers from the conversation.
+func loopingClient(requests *[]*llm.ChatWithToolsRequest) *MockLLMClient {
+	return &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			*requests = append(*requests, req)
+			if len(req.Tools) == 0 {
+				return &llm.ChatWithToolsResponse{Content: "probably 2", Usage: llm.Usage{TotalTokens: 5}}, nil
+			}
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "1 + 1"}`}},
+				Usage:     llm.Usage{TotalTokens: 10},
+			}, nil
+		},
+	}
+}
+
+func TestReActAgent_FinalizeAtMaxIterations(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	var requests []*llm.ChatWithToolsRequest
+	a := NewReActAgent(loopingClient(&requests), registry, Config{MaxIterations: 2})
+	resp, err := a.Run(context.Background(), "what is 1 + 1?")
+	if err != nil {
+		t.Fatalf("expected a best-effort answer, got error: %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("expected 2 iterations and a final call, got %d calls", len(requests))
+	}
+	final := requests[2].Messages
+	if !strings.Contains(final[len(final)-1].Content, "cannot call any more tools") || final[len(final)-2].Content != "2" {
+		t.Errorf("expected the final call to ask for an answer from the observations, got %+v", final[len(final)-2:])
+	}
+	if resp.Output != "probably 2" || resp.Usage.TotalTokens != 25 {
+		t.Errorf("unexpected answer %q with usage %+v", resp.Output, resp.Usage)
+	}
+	if resp.Metadata["incomplete"] != true || !strings.Contains(resp.Metadata["incomplete_reason"].(string), "max iterations") {
+		t.Errorf("expected the response to be flagged incomplete, got %+v", resp.Metadata)
+	}
+}
+
+func TestReActAgent_FinalizeSkippedWhenTokensSpent(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	var requests []*llm.ChatWithToolsRequest
+	a := NewReActAgent(loopingClient(&requests), registry, Config{})
+	ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 10}))
+	resp, err := a.Run(ctx, "what is 1 + 1?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Errorf("expected no call once the token limit is spent, got %d calls", len(requests))
+	}
+	if !strings.Contains(resp.Output, "calculator: 2") || resp.Metadata["incomplete"] != true {
+		t.Errorf("expected an incomplete answer from the observations, got %q %+v", resp.Output, resp.Metadata)
+	}
+}
+
+func TestReActAgent_StrictLimits(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	var requests []*llm.ChatWithToolsRequest
+	a := NewReActAgent(loopingClient(&requests), registry, Config{MaxIterations: 2})
+	if _, err := a.Run(WithStrictLimits(context.Background()), "q"); !errors.Is(err, ErrMaxIterations) {
+		t.Errorf("expected ErrMaxIterations, got %v", err)
+	}
+
+	ctx := WithStrictLimits(WithBudget(context.Background(), NewBudget(BudgetLimits{MaxToolCalls: 1})))
+	if _, err := a.Run(ctx, "q"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+}
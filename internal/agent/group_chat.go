@@ -0,0 +1,385 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// GroupChatAgent lets several worker personas take turns on a shared
+// transcript, e.g. a proposer, a critic and a judge debating a question.
+// Unlike OrchestratorAgent, which fans a plan out once, members see and
+// respond to each other's messages.
+//
+// Reference: Wu et al., 2023 - "AutoGen: Enabling Next-Gen LLM Applications via Multi-Agent Conversation"
+// https://arxiv.org/abs/2308.08155
+//
+// Flow:
+//  1. The query opens the transcript
+//  2. The selection policy picks the next speaker
+//  3. The speaker runs its ReAct loop on the transcript and adds a message
+//  4. Repeat until a message starts with the termination phrase, MaxTurns
+//     is reached or the run budget is spent
+type GroupChatAgent struct {
+	llm     LLMClient
+	tools   *tools.Registry
+	members []*WorkerAgent
+	config  GroupChatConfig
+}
+
+// Speaker selection policies.
+const (
+	// SelectRoundRobin lets members speak in the order they were added.
+	SelectRoundRobin = "round_robin"
+
+	// SelectLLM asks the LLM who should speak next.
+	SelectLLM = "llm"
+
+	// SelectMention gives the turn to the member @mentioned in the last
+	// message, falling back to round robin.
+	SelectMention = "mention"
+)
+
+// Group chat termination reasons.
+const (
+	TerminationPhrase   = "phrase"
+	TerminationMaxTurns = "max_turns"
+	TerminationBudget   = "budget"
+)
+
+// GroupChatConfig contains configuration for a group chat.
+type GroupChatConfig struct {
+	Config
+
+	// Selection is "round_robin", "llm" or "mention". Default is "round_robin".
+	Selection string
+
+	// MaxTurns is the maximum number of member messages. Default is 9.
+	MaxTurns int
+
+	// TerminationPhrase ends the chat when a message starts with it; the rest
+	// of the message is the final answer. Default is "FINAL ANSWER:".
+	TerminationPhrase string
+
+	// TurnPrompt is the input given to the speaker. It is formatted with the
+	// transcript, the speaker's name and the termination phrase.
+	TurnPrompt string
+
+	// SelectionPrompt is the prompt for "llm" selection. It is formatted with
+	// the member list and the transcript.
+	SelectionPrompt string
+}
+
+// GroupChatTrace summarizes a group chat run.
+type GroupChatTrace struct {
+	// Selection is the speaker selection policy used.
+	Selection string `json:"selection"`
+
+	// Speakers lists the speaker of each turn in order.
+	Speakers []string `json:"speakers"`
+
+	// Termination is "phrase", "max_turns" or "budget".
+	Termination string `json:"termination"`
+}
+
+// NewGroupChatAgent creates a new group chat. Members are added with AddMember.
+func NewGroupChatAgent(llmClient LLMClient, toolRegistry *tools.Registry, config GroupChatConfig) *GroupChatAgent {
+	if config.Selection == "" {
+		config.Selection = SelectRoundRobin
+	}
+	if config.MaxTurns <= 0 {
+		config.MaxTurns = 9
+	}
+	if config.TerminationPhrase == "" {
+		config.TerminationPhrase = "FINAL ANSWER:"
+	}
+	if config.TurnPrompt == "" {
+		config.TurnPrompt = defaultTurnPrompt
+	}
+	if config.SelectionPrompt == "" {
+		config.SelectionPrompt = defaultSelectionPrompt
+	}
+
+	return &GroupChatAgent{
+		llm:    metered(llmClient),
+		tools:  toolRegistry,
+		config: config,
+	}
+}
+
+const defaultTurnPrompt = `Conversation so far:
+
+%s
+
+You are %s. Write your next message in the conversation. Address other participants with @name.
+If the conversation has reached its conclusion and you are permitted to close it, start your message with "%s" followed by the final answer.`
+
+const defaultSelectionPrompt = `You moderate a group conversation. Choose who should speak next.
+
+Participants:
+%s
+
+Conversation so far:
+
+%s
+
+Return JSON: {"speaker": "name", "reason": "why"}
+Return only valid JSON.`
+
+// AddMember adds a participant. Members without their own LLM client use
+// the group chat's. Members speak in the order they were added.
+func (g *GroupChatAgent) AddMember(member *WorkerAgent) {
+	if member.llm == nil {
+		member.llm = g.llm
+	}
+	member.registry = g.tools
+	member.parent = g.config.Config
+	g.members = append(g.members, member)
+}
+
+// Members returns the participants in the order they were added.
+func (g *GroupChatAgent) Members() []*WorkerAgent {
+	return append([]*WorkerAgent(nil), g.members...)
+}
+
+// Run processes a query.
+func (g *GroupChatAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return g.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory holds a group chat about a query, with history opening the transcript.
+//
+// Response.Steps is the transcript: one "message" step per turn with Agent
+// set to the speaker. Metadata["group_chat"] holds the *GroupChatTrace.
+// The run is traced as an "invoke_agent group_chat" span with one
+// "group_chat.turn" span per message.
+func (g *GroupChatAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "group_chat")
+	resp, err := withMemory(ctx, g.config.Config, history, query, g.run)
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (g *GroupChatAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	if len(g.members) == 0 {
+		return nil, fmt.Errorf("group chat has no members")
+	}
+
+	ctx = guardrailContext(ctx, g.config.Guardrails)
+	query, err := g.config.Guardrails.CheckInput(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var transcript []Step
+	for _, msg := range history {
+		transcript = append(transcript, Step{Type: "message", Agent: msg.Role, Content: msg.Content})
+	}
+	transcript = append(transcript, Step{Type: "message", Agent: "user", Content: query})
+
+	trace := &GroupChatTrace{Selection: g.config.Selection, Termination: TerminationMaxTurns}
+	var totalUsage Usage
+	var output string
+	budget := BudgetFrom(ctx)
+	last := -1
+
+	for turn := 1; turn <= g.config.MaxTurns; turn++ {
+		if err := budget.Check(); err != nil {
+			trace.Termination = TerminationBudget
+			break
+		}
+
+		next, usage := g.selectSpeaker(ctx, transcript, last)
+		totalUsage = addUsage(totalUsage, usage)
+		member := g.members[next]
+		last = next
+
+		if g.config.Verbose {
+			log.Printf("[GroupChat] Turn %d: %s", turn, member.Name)
+		}
+
+		turnCtx, turnSpan := tracer().Start(ctx, "group_chat.turn")
+		turnSpan.SetAttributes(attrTurn.Int(turn), attrSpeaker.String(member.Name))
+		input := fmt.Sprintf(g.config.TurnPrompt, formatTranscript(transcript), member.Name, g.config.TerminationPhrase)
+		result, usage := member.Execute(turnCtx, input)
+		totalUsage = addUsage(totalUsage, usage)
+		if !result.Success && !result.Incomplete {
+			err := fmt.Errorf("%s failed on turn %d: %s", member.Name, turn, result.Error)
+			endSpan(turnSpan, err)
+			return nil, err
+		}
+		endSpan(turnSpan, nil)
+
+		message := strings.TrimSpace(result.Output)
+		transcript = append(transcript, Step{Type: "message", Agent: member.Name, Content: message})
+		trace.Speakers = append(trace.Speakers, member.Name)
+		output = message
+
+		if answer, ok := strings.CutPrefix(message, g.config.TerminationPhrase); ok {
+			output = strings.TrimSpace(answer)
+			trace.Termination = TerminationPhrase
+			break
+		}
+
+		// A turn stopped at a limit keeps its best-effort message; the chat
+		// ends there once the budget is spent
+		if result.Incomplete && budget.Check() != nil {
+			trace.Termination = TerminationBudget
+			break
+		}
+	}
+
+	if output == "" {
+		output = bestEffortAnswer("", nil)
+	}
+	if g.config.Verbose {
+		log.Printf("[GroupChat] Ended after %d turns: %s", len(trace.Speakers), trace.Termination)
+	}
+
+	output, err = g.config.Guardrails.CheckOutput(ctx, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+		Output: output,
+		Steps:  transcript,
+		Usage:  totalUsage,
+		Metadata: map[string]any{
+			"group_chat": trace,
+		},
+	})), nil
+}
+
+// mentionPattern matches @name mentions.
+var mentionPattern = regexp.MustCompile(`@([\w-]+)`)
+
+// selectSpeaker returns the index of the next member to speak. last is the
+// index of the previous speaker, or -1 before the first turn.
+func (g *GroupChatAgent) selectSpeaker(ctx context.Context, transcript []Step, last int) (int, Usage) {
+	roundRobin := (last + 1) % len(g.members)
+
+	switch g.config.Selection {
+	case SelectMention:
+		for _, m := range mentionPattern.FindAllStringSubmatch(transcript[len(transcript)-1].Content, -1) {
+			if i := g.memberIndex(m[1]); i >= 0 && i != last {
+				return i, Usage{}
+			}
+		}
+	case SelectLLM:
+		i, usage, err := g.askSpeaker(ctx, transcript)
+		if err == nil {
+			return i, usage
+		}
+		if g.config.Verbose {
+			log.Printf("[GroupChat] Speaker selection failed, using round robin: %v", err)
+		}
+		return roundRobin, usage
+	}
+	return roundRobin, Usage{}
+}
+
+// askSpeaker asks the LLM to choose the next speaker.
+func (g *GroupChatAgent) askSpeaker(ctx context.Context, transcript []Step) (int, Usage, error) {
+	var members strings.Builder
+	for _, m := range g.members {
+		fmt.Fprintf(&members, "- %s: %s\n", m.Name, m.Description)
+	}
+
+	resp, err := g.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a conversation moderator. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: fmt.Sprintf(g.config.SelectionPrompt, strings.TrimSuffix(members.String(), "\n"), formatTranscript(transcript))},
+		},
+		Model:       g.config.Model,
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return 0, Usage{}, err
+	}
+	usage := toUsage(resp.Usage)
+
+	var choice struct {
+		Speaker string `json:"speaker"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &choice); err != nil {
+		return 0, usage, fmt.Errorf("failed to parse speaker: %w", err)
+	}
+	i := g.memberIndex(strings.TrimPrefix(choice.Speaker, "@"))
+	if i < 0 {
+		return 0, usage, fmt.Errorf("unknown speaker %q", choice.Speaker)
+	}
+	return i, usage, nil
+}
+
+// memberIndex returns the index of the named member, or -1.
+func (g *GroupChatAgent) memberIndex(name string) int {
+	for i, m := range g.members {
+		if strings.EqualFold(m.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatTranscript renders transcript messages as "[speaker]: content" blocks.
+func formatTranscript(transcript []Step) string {
+	var sb strings.Builder
+	for i, step := range transcript {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		fmt.Fprintf(&sb, "[%s]: %s", step.Agent, step.Content)
+	}
+	return sb.String()
+}
+
+// NewProposerWorker creates a debate participant that proposes and defends answers.
+func NewProposerWorker() *WorkerAgent {
+	return &WorkerAgent{
+		Name:        "proposer",
+		Description: "Proposes an answer and revises it in response to criticism",
+		SystemPrompt: `You are the proposer in a debate.
+Propose a well-reasoned answer to the question, then defend or revise it when criticized.
+Never give the final answer yourself; the judge does that.`,
+	}
+}
+
+// NewCriticWorker creates a debate participant that challenges proposals.
+func NewCriticWorker() *WorkerAgent {
+	return &WorkerAgent{
+		Name:        "critic",
+		Description: "Finds errors, gaps and counterarguments in the proposal",
+		SystemPrompt: `You are the critic in a debate.
+Challenge the latest proposal: point out errors, missing cases and counterarguments.
+Acknowledge points that hold up. Never give the final answer yourself; the judge does that.`,
+	}
+}
+
+// NewJudgeWorker creates a debate participant that decides when the debate is settled.
+func NewJudgeWorker() *WorkerAgent {
+	return &WorkerAgent{
+		Name:        "judge",
+		Description: "Weighs the arguments and gives the final answer once the debate is settled",
+		SystemPrompt: `You are the judge in a debate.
+Weigh the proposal against the criticism. If the answer is settled, give it as the final answer.
+Otherwise name the open issues the proposer must address next.`,
+	}
+}
+
+// DebateMembers returns a proposer, a critic and a judge, which debate in
+// rounds under round-robin selection.
+func DebateMembers() []*WorkerAgent {
+	return []*WorkerAgent{
+		NewProposerWorker(),
+		NewCriticWorker(),
+		NewJudgeWorker(),
+	}
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// scriptedMembers answers each member's turn with the next of its scripted
+// messages, identifying the member by its system prompt.
+func scriptedMembers(scripts map[string][]string) *MockLLMClient {
+	turns := make(map[string]int)
+	return &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			for name, script := range scripts {
+				if strings.Contains(req.Messages[0].Content, "the "+name+" in a debate") {
+					msg := script[turns[name]%len(script)]
+					turns[name]++
+					return &llm.ChatWithToolsResponse{Content: msg, Usage: llm.Usage{TotalTokens: 10}}, nil
+				}
+			}
+			return &llm.ChatWithToolsResponse{Content: "?"}, nil
+		},
+	}
+}
+
+// newDebate creates a group chat with the debate members.
+func newDebate(mock *MockLLMClient, config GroupChatConfig) *GroupChatAgent {
+	g := NewGroupChatAgent(mock, tools.NewRegistry(), config)
+	for _, m := range DebateMembers() {
+		g.AddMember(m)
+	}
+	return g
+}
+
+func TestGroupChatAgent_Debate(t *testing.T) {
+	mock := scriptedMembers(map[string][]string{
+		"proposer": {"Use a map.", "Use a map with a mutex."},
+		"critic":   {"Maps are not safe for concurrent writes.", "Agreed."},
+		"judge":    {"@proposer address concurrency.", "FINAL ANSWER: a map guarded by a mutex"},
+	})
+
+	resp, err := newDebate(mock, GroupChatConfig{}).Run(context.Background(), "How to cache results across goroutines?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Output != "a map guarded by a mutex" {
+		t.Errorf("expected the judge's final answer, got %q", resp.Output)
+	}
+	trace := resp.Metadata["group_chat"].(*GroupChatTrace)
+	if trace.Termination != TerminationPhrase {
+		t.Errorf("expected phrase termination, got %s", trace.Termination)
+	}
+	want := []string{"proposer", "critic", "judge", "proposer", "critic", "judge"}
+	if strings.Join(trace.Speakers, ",") != strings.Join(want, ",") {
+		t.Errorf("expected speakers %v, got %v", want, trace.Speakers)
+	}
+
+	// The transcript opens with the query and has one step per turn
+	if len(resp.Steps) != 7 || resp.Steps[0].Agent != "user" || resp.Steps[2].Agent != "critic" {
+		t.Errorf("unexpected transcript %+v", resp.Steps)
+	}
+	if resp.Usage.TotalTokens != 60 {
+		t.Errorf("expected usage of all turns, got %d", resp.Usage.TotalTokens)
+	}
+}
+
+func TestGroupChatAgent_Selection(t *testing.T) {
+	t.Run("mention", func(t *testing.T) {
+		mock := scriptedMembers(map[string][]string{
+			"proposer": {"Ask @judge directly."},
+			"critic":   {"Unused."},
+			"judge":    {"FINAL ANSWER: done"},
+		})
+		resp, err := newDebate(mock, GroupChatConfig{Selection: SelectMention}).Run(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if speakers := resp.Metadata["group_chat"].(*GroupChatTrace).Speakers; strings.Join(speakers, ",") != "proposer,judge" {
+			t.Errorf("expected the mention to skip the critic, got %v", speakers)
+		}
+	})
+
+	t.Run("llm", func(t *testing.T) {
+		mock := scriptedMembers(map[string][]string{
+			"proposer": {"Unused."},
+			"critic":   {"Unused."},
+			"judge":    {"FINAL ANSWER: judged"},
+		})
+		mock.ChatFunc = func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			if !strings.Contains(req.Messages[1].Content, "- critic: Finds errors") {
+				t.Errorf("expected member descriptions in the prompt, got %q", req.Messages[1].Content)
+			}
+			return &llm.ChatResponse{Content: `{"speaker": "judge", "reason": "settled"}`, Usage: llm.Usage{TotalTokens: 5}}, nil
+		}
+
+		resp, err := newDebate(mock, GroupChatConfig{Selection: SelectLLM}).Run(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Output != "judged" || resp.Usage.TotalTokens != 15 {
+			t.Errorf("expected the selected judge to answer with selection usage counted, got %q and %d tokens", resp.Output, resp.Usage.TotalTokens)
+		}
+	})
+}
+
+func TestGroupChatAgent_Termination(t *testing.T) {
+	mock := scriptedMembers(map[string][]string{
+		"proposer": {"Yes."},
+		"critic":   {"No."},
+		"judge":    {"Keep going."},
+	})
+
+	resp, err := newDebate(mock, GroupChatConfig{MaxTurns: 4}).Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	trace := resp.Metadata["group_chat"].(*GroupChatTrace)
+	if trace.Termination != TerminationMaxTurns || len(trace.Speakers) != 4 {
+		t.Errorf("expected 4 turns ending on max_turns, got %+v", trace)
+	}
+	if resp.Output != "Yes." {
+		t.Errorf("expected the last message as output, got %q", resp.Output)
+	}
+
+	if _, err := NewGroupChatAgent(mock, nil, GroupChatConfig{}).Run(context.Background(), "q"); err == nil {
+		t.Error("expected error without members")
+	}
+}
+
+func TestGroupChatAgent_BudgetSpentMidTurn(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "2 + 3"}`}},
+				Usage:     llm.Usage{TotalTokens: 20},
+			}, nil
+		},
+	}
+	registry := tools.NewRegistry()
+	_ = registry.Register(tools.NewCalculator())
+
+	g := NewGroupChatAgent(mock, registry, GroupChatConfig{})
+	g.AddMember(NewCalculatorWorker())
+	g.AddMember(NewGeneralWorker())
+
+	ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 10}))
+	resp, err := g.Run(ctx, "What is 2 + 3?")
+	if err != nil {
+		t.Fatalf("expected the chat to end on the budget, got error: %v", err)
+	}
+
+	trace := resp.Metadata["group_chat"].(*GroupChatTrace)
+	if trace.Termination != TerminationBudget || len(trace.Speakers) != 1 {
+		t.Errorf("expected one turn ending on the budget, got %+v", trace)
+	}
+	if !strings.Contains(resp.Output, "5") {
+		t.Errorf("expected the turn's best-effort answer, got %q", resp.Output)
+	}
+	if len(resp.Steps) != 2 || resp.Steps[1].Agent != "calculator" {
+		t.Errorf("expected the turn in the transcript, got %+v", resp.Steps)
+	}
+	if resp.Usage.TotalTokens != 20 {
+		t.Errorf("expected the turn's usage, got %d", resp.Usage.TotalTokens)
+	}
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+)
+
+// metadataGuardrails is the Response.Metadata key listing guardrail violations.
+const metadataGuardrails = "guardrails"
+
+// guardrailContext ensures ctx carries a guardrail report when the agent has
+// guardrails, so violations from the whole run are collected in one place.
+func guardrailContext(ctx context.Context, p *guardrail.Pipeline) context.Context {
+	if p == nil {
+		return ctx
+	}
+	ctx, _ = guardrail.WithReport(ctx)
+	return ctx
+}
+
+// guardObservation applies the input guardrails to a tool result before it
+// is shown to the model. A blocked result is replaced by a notice so the run
+// can continue without it.
+func guardObservation(ctx context.Context, p *guardrail.Pipeline, toolName, output string) (string, error) {
+	checked, err := p.CheckInput(ctx, output)
+	var blocked *guardrail.BlockedError
+	if errors.As(err, &blocked) {
+		return fmt.Sprintf("[result of %s withheld: %v]", toolName, blocked), nil
+	}
+	return checked, err
+}
+
+// withGuardrailViolations lists the violations recorded in ctx in resp's metadata.
+func withGuardrailViolations(ctx context.Context, resp *Response) *Response {
+	violations := guardrail.ReportFrom(ctx).Violations()
+	if len(violations) == 0 {
+		return resp
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata[metadataGuardrails] = violations
+	return resp
+}
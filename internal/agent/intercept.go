@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// Interceptor observes or replaces the model calls and tool executions of a
+// run whose context carries it (see WithInterceptor). Each method receives
+// the call and a next function that performs it: a recorder calls next and
+// keeps the result, a replayer answers from a recording without calling next.
+//
+// Model calls are intercepted on every agent's client, including evaluators
+// and workers. Implementations must be safe for concurrent use.
+type Interceptor interface {
+	InterceptChat(ctx context.Context, req *llm.ChatRequest, next ChatFunc) (*llm.ChatResponse, error)
+	InterceptChatWithTools(ctx context.Context, req *llm.ChatWithToolsRequest, next ChatWithToolsFunc) (*llm.ChatWithToolsResponse, error)
+	InterceptTool(ctx context.Context, name, arguments string, next ToolFunc) (tools.Result, error)
+}
+
+// ChatFunc performs a chat completion.
+type ChatFunc func(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error)
+
+// ChatWithToolsFunc performs a chat completion with tool definitions.
+type ChatWithToolsFunc func(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error)
+
+// ToolFunc executes a tool with JSON arguments.
+type ToolFunc func(ctx context.Context, name, arguments string) (tools.Result, error)
+
+// interceptorKey is the context key holding the run's Interceptor.
+type interceptorKey struct{}
+
+// WithInterceptor returns a context whose agent runs go through i.
+func WithInterceptor(ctx context.Context, i Interceptor) context.Context {
+	return context.WithValue(ctx, interceptorKey{}, i)
+}
+
+// interceptorFrom returns the Interceptor set by WithInterceptor, or nil.
+func interceptorFrom(ctx context.Context) Interceptor {
+	i, _ := ctx.Value(interceptorKey{}).(Interceptor)
+	return i
+}
+
+// executeTool runs a registry tool through the context's interceptor.
+func executeTool(ctx context.Context, registry *tools.Registry, name, arguments string) (tools.Result, error) {
+	if i := interceptorFrom(ctx); i != nil {
+		return i.InterceptTool(ctx, name, arguments, registry.Execute)
+	}
+	return registry.Execute(ctx, name, arguments)
+}
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// ErrLoopDetected is the reason a run stops when it repeats itself and its
+// LoopDetectionConfig strategy is LoopStrategyStop.
+var ErrLoopDetected = errors.New("loop detected")
+
+// LoopStrategy selects how the ReAct loop intervenes in a detected loop.
+type LoopStrategy string
+
+const (
+	// LoopStrategyHint runs the call and tells the model it is repeating
+	// itself. It is the default.
+	LoopStrategyHint LoopStrategy = "hint"
+
+	// LoopStrategyCache skips a repeated call and returns its earlier result
+	// with a warning. Oscillating answers get a hint.
+	LoopStrategyCache LoopStrategy = "cache"
+
+	// LoopStrategyStop ends the run with a best-effort answer, as when it
+	// reaches MaxIterations.
+	LoopStrategyStop LoopStrategy = "stop"
+)
+
+// LoopDetectionConfig controls how the ReAct loop detects a model that
+// repeats tool calls or oscillates between answers. The zero value disables
+// detection.
+type LoopDetectionConfig struct {
+	// MaxRepeats is how many times in a row a tool call, or a cycle of
+	// calls, may be made with identical arguments. The next repetition is a
+	// loop. Zero disables detection.
+	MaxRepeats int `json:"max_repeats,omitempty" yaml:"max_repeats,omitempty"`
+
+	// MaxCycle is the longest sequence of calls detected as a cycle, e.g., 2
+	// detects search, fetch, search, fetch. Default is 3.
+	MaxCycle int `json:"max_cycle,omitempty" yaml:"max_cycle,omitempty"`
+
+	// Strategy is the intervention. Default is LoopStrategyHint.
+	Strategy LoopStrategy `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+}
+
+// valid reports whether the strategy is known.
+func (c LoopDetectionConfig) valid() bool {
+	switch c.Strategy {
+	case "", LoopStrategyHint, LoopStrategyCache, LoopStrategyStop:
+		return true
+	}
+	return false
+}
+
+const (
+	loopHint        = "You are repeating yourself: %s. Repeating it will not produce new information. Use the results you already have, try a different tool or arguments, or give your final answer."
+	loopCacheNotice = "[Warning: %s. This is the earlier result; the tool was not called again.]\n"
+)
+
+// loopDetector tracks one run's tool calls and interim answers. A nil
+// detector detects nothing.
+type loopDetector struct {
+	config  LoopDetectionConfig
+	calls   []string
+	results map[string]string
+	answers []string
+}
+
+// newLoopDetector returns a detector for config, or nil if it disables
+// detection.
+func newLoopDetector(config LoopDetectionConfig) *loopDetector {
+	if config.MaxRepeats <= 0 {
+		return nil
+	}
+	if config.MaxCycle <= 0 {
+		config.MaxCycle = 3
+	}
+	if config.Strategy == "" {
+		config.Strategy = LoopStrategyHint
+	}
+	return &loopDetector{config: config, results: make(map[string]string)}
+}
+
+// checkCall records a tool call and describes the loop it completes, or
+// returns "" if there is none.
+func (d *loopDetector) checkCall(call llm.ToolCall) string {
+	if d == nil {
+		return ""
+	}
+	d.calls = append(d.calls, callKey(call))
+
+	// A block of the last k calls repeated more than MaxRepeats times
+	for k := 1; k <= d.config.MaxCycle; k++ {
+		n := k * (d.config.MaxRepeats + 1)
+		if n > len(d.calls) {
+			break
+		}
+		recent := d.calls[len(d.calls)-n:]
+		repeated := true
+		for i := k; i < n && repeated; i++ {
+			repeated = recent[i] == recent[i-k]
+		}
+		if !repeated {
+			continue
+		}
+		if k == 1 {
+			return fmt.Sprintf("%s was called %d times in a row with the same arguments", call.Name, n)
+		}
+		return fmt.Sprintf("the same %d tool calls were repeated %d times in a row", k, d.config.MaxRepeats+1)
+	}
+	return ""
+}
+
+// checkAnswer records the model's interim answer and describes the
+// oscillation when it returns to an answer it had moved away from.
+func (d *loopDetector) checkAnswer(content string) string {
+	answer := strings.ToLower(strings.TrimSpace(content))
+	if d == nil || answer == "" {
+		return ""
+	}
+	if n := len(d.answers); n > 0 && d.answers[n-1] == answer {
+		return ""
+	}
+	d.answers = append(d.answers, answer)
+
+	n := len(d.answers)
+	for i := n - 2; i >= 0; i-- {
+		if d.answers[i] == answer {
+			return "your answer is switching back and forth between the same alternatives"
+		}
+	}
+	return ""
+}
+
+// record stores the observation of a tool call for LoopStrategyCache.
+func (d *loopDetector) record(call llm.ToolCall, result string) {
+	if d != nil {
+		d.results[callKey(call)] = result
+	}
+}
+
+// cached returns the earlier observation of a repeated call, with a
+// warning, when the strategy is LoopStrategyCache.
+func (d *loopDetector) cached(call llm.ToolCall, loop string) (string, bool) {
+	if d == nil || loop == "" || d.config.Strategy != LoopStrategyCache {
+		return "", false
+	}
+	result, ok := d.results[callKey(call)]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(loopCacheNotice, loop) + result, true
+}
+
+// stops reports whether a detected loop ends the run.
+func (d *loopDetector) stops() bool {
+	return d.config.Strategy == LoopStrategyStop
+}
+
+// step records an intervention in the run's steps.
+func (d *loopDetector) step(loop, toolName string) Step {
+	return Step{
+		Type:     "loop",
+		Content:  fmt.Sprintf("Loop detected (%s): %s", d.config.Strategy, loop),
+		ToolName: toolName,
+	}
+}
+
+// callKey identifies a tool call by name and arguments, ignoring key order
+// and whitespace in JSON arguments.
+func callKey(call llm.ToolCall) string {
+	args := strings.TrimSpace(call.Arguments)
+	var v any
+	if err := json.Unmarshal([]byte(args), &v); err == nil {
+		if data, err := json.Marshal(v); err == nil {
+			args = string(data)
+		}
+	}
+	return call.Name + "(" + args + ")"
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func TestLoopDetector_CheckCall(t *testing.T) {
+	call := func(name, args string) llm.ToolCall { return llm.ToolCall{Name: name, Arguments: args} }
+
+	tests := []struct {
+		name  string
+		calls []llm.ToolCall
+		loop  bool
+	}{
+		{"distinct", []llm.ToolCall{call("a", `{"x": 1}`), call("a", `{"x": 2}`), call("a", `{"x": 3}`)}, false},
+		{"repeated", []llm.ToolCall{call("a", `{"x": 1, "y": 2}`), call("a", `{"y":2,"x":1}`), call("a", `{"x": 1, "y": 2}`)}, true},
+		{"not in a row", []llm.ToolCall{call("a", "{}"), call("a", "{}"), call("b", "{}"), call("a", "{}")}, false},
+		{"cycle", []llm.ToolCall{call("a", "{}"), call("b", "{}"), call("a", "{}"), call("b", "{}"), call("a", "{}"), call("b", "{}")}, true},
+		{"cycle once", []llm.ToolCall{call("a", "{}"), call("b", "{}"), call("a", "{}"), call("b", "{}")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newLoopDetector(LoopDetectionConfig{MaxRepeats: 2})
+			var loop string
+			for _, c := range tt.calls {
+				loop = d.checkCall(c)
+			}
+			if (loop != "") != tt.loop {
+				t.Errorf("expected loop %v, got %q", tt.loop, loop)
+			}
+		})
+	}
+}
+
+func TestLoopDetector_CheckAnswer(t *testing.T) {
+	d := newLoopDetector(LoopDetectionConfig{MaxRepeats: 2})
+	for _, answer := range []string{"It is 4", "It is 4", "It is 5"} {
+		if loop := d.checkAnswer(answer); loop != "" {
+			t.Fatalf("unexpected loop at %q: %s", answer, loop)
+		}
+	}
+	if loop := d.checkAnswer("it is 4"); loop == "" {
+		t.Error("expected returning to an earlier answer to be detected")
+	}
+}
+
+// repeatingClient calls the calculator with the same arguments until it is
+// offered no tools.
+func repeatingClient(requests *[]*llm.ChatWithToolsRequest) *MockLLMClient {
+	return &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			*requests = append(*requests, req)
+			if len(req.Tools) == 0 || len(*requests) > 4 {
+				return &llm.ChatWithToolsResponse{Content: "2"}, nil
+			}
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "1 + 1"}`}},
+			}, nil
+		},
+	}
+}
+
+func TestReActAgent_LoopStrategies(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	t.Run("hint", func(t *testing.T) {
+		var requests []*llm.ChatWithToolsRequest
+		a := NewReActAgent(repeatingClient(&requests), registry, Config{LoopDetection: LoopDetectionConfig{MaxRepeats: 2}})
+		resp, err := a.Run(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		fourth := requests[3].Messages
+		if last := fourth[len(fourth)-1]; last.Role != "system" || !strings.Contains(last.Content, "calculator was called 3 times") {
+			t.Errorf("expected a corrective hint after the third call, got %+v", last)
+		}
+		if countSteps(resp.Steps, "loop") != 2 || countSteps(resp.Steps, StepTypeObservation) != 4 {
+			t.Errorf("expected 2 interventions and 4 calls, got %+v", resp.Steps)
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		var requests []*llm.ChatWithToolsRequest
+		budget := NewBudget(BudgetLimits{})
+		a := NewReActAgent(repeatingClient(&requests), registry, Config{LoopDetection: LoopDetectionConfig{MaxRepeats: 1, Strategy: LoopStrategyCache}})
+		if _, err := a.Run(WithBudget(context.Background(), budget), "q"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		third := requests[2].Messages
+		if last := third[len(third)-1].Content; !strings.HasPrefix(last, "[Warning: calculator was called 2 times") || !strings.HasSuffix(last, "\n2") {
+			t.Errorf("expected the cached result with a warning, got %q", last)
+		}
+		if calls := budget.Usage().ToolCalls; calls != 1 {
+			t.Errorf("expected the tool to run once, got %d", calls)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var requests []*llm.ChatWithToolsRequest
+		a := NewReActAgent(repeatingClient(&requests), registry, Config{LoopDetection: LoopDetectionConfig{MaxRepeats: 1, Strategy: LoopStrategyStop}})
+		resp, err := a.Run(context.Background(), "q")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(requests) != 3 || resp.Output != "2" {
+			t.Errorf("expected a final answer after the second call, got %d calls and %q", len(requests), resp.Output)
+		}
+		if reason, _ := resp.Metadata["incomplete_reason"].(string); !strings.HasPrefix(reason, "loop detected") {
+			t.Errorf("expected the run to be flagged incomplete, got %+v", resp.Metadata)
+		}
+
+		requests = nil
+		if _, err := a.Run(WithStrictLimits(context.Background()), "q"); !errors.Is(err, ErrLoopDetected) {
+			t.Errorf("expected ErrLoopDetected, got %v", err)
+		}
+	})
+}
+
+func countSteps(steps []Step, stepType string) int {
+	var n int
+	for _, step := range steps {
+		if step.Type == stepType {
+			n++
+		}
+	}
+	return n
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hassan123789/go-ai-agent/internal/llm"
 	"github.com/hassan123789/go-ai-agent/internal/tools"
@@ -24,7 +26,7 @@ import (
 //  3. Workers execute in parallel where possible
 //  4. Results are synthesized by the orchestrator
 type OrchestratorAgent struct {
-	llm     *llm.OpenAIClient
+	llm     LLMClient
 	tools   *tools.Registry
 	workers map[string]*WorkerAgent
 	config  OrchestratorConfig
@@ -42,16 +44,27 @@ type OrchestratorConfig struct {
 
 	// SynthesisPrompt is the prompt for result synthesis.
 	SynthesisPrompt string
+
+	// PlanRepairPrompt is the prompt used to fix plans that fail validation.
+	PlanRepairPrompt string
+
+	// MaxPlanRepairs is the number of repair attempts for an invalid plan.
+	MaxPlanRepairs int
 }
 
 // WorkerAgent is a specialized agent for specific tasks.
+// Each worker runs its own bounded ReAct loop over its allowed tools.
 type WorkerAgent struct {
-	Name         string
-	Description  string
-	SystemPrompt string
-	Tools        []string // Tool names this worker can use
-	llm          *llm.OpenAIClient
-	registry     *tools.Registry
+	Name          string
+	Description   string
+	SystemPrompt  string
+	Tools         []string // Tool names this worker can use
+	Model         string   // Overrides the orchestrator's model when set
+	Temperature   float32  // Sampling temperature; zero uses the client default
+	MaxIterations int      // Bounds the worker's ReAct loop; default is 5
+	llm           LLMClient
+	registry      *tools.Registry
+	parent        Config // Config of the agent the worker is registered with
 }
 
 // TaskPlan represents the orchestrator's plan.
@@ -76,15 +89,32 @@ type Subtask struct {
 
 // SubtaskResult contains the result of a subtask execution.
 type SubtaskResult struct {
-	ID       string `json:"id"`
-	Success  bool   `json:"success"`
-	Output   string `json:"output"`
-	Error    string `json:"error,omitempty"`
-	Duration int64  `json:"duration_ms"`
+	ID         string `json:"id"`
+	Worker     string `json:"worker"` // Worker that ran the subtask, or "default"
+	Success    bool   `json:"success"`
+	Output     string `json:"output"`
+	Incomplete bool   `json:"incomplete,omitempty"` // Stopped at a limit; Output is a best-effort answer
+	Error      string `json:"error,omitempty"`
+	Duration   int64  `json:"duration_ms"`
+	Usage      Usage  `json:"usage"`
+	Steps      []Step `json:"steps,omitempty"` // Worker's ReAct trace
+}
+
+// OrchestratorTrace records how an orchestrator run reached its answer.
+type OrchestratorTrace struct {
+	// Plan is the validated plan that was executed.
+	Plan *TaskPlan `json:"plan"`
+
+	// Results holds the subtask results in completion order, with worker,
+	// timing and usage.
+	Results []SubtaskResult `json:"results"`
+
+	// Workers lists the workers registered at the time of the run.
+	Workers []*WorkerAgent `json:"workers,omitempty"`
 }
 
 // NewOrchestratorAgent creates a new orchestrator agent.
-func NewOrchestratorAgent(llmClient *llm.OpenAIClient, toolRegistry *tools.Registry, config OrchestratorConfig) *OrchestratorAgent {
+func NewOrchestratorAgent(llmClient LLMClient, toolRegistry *tools.Registry, config OrchestratorConfig) *OrchestratorAgent {
 	if config.MaxIterations <= 0 {
 		config.MaxIterations = 10
 	}
@@ -100,9 +130,15 @@ func NewOrchestratorAgent(llmClient *llm.OpenAIClient, toolRegistry *tools.Regis
 	if config.SynthesisPrompt == "" {
 		config.SynthesisPrompt = defaultSynthesisPrompt
 	}
+	if config.PlanRepairPrompt == "" {
+		config.PlanRepairPrompt = defaultPlanRepairPrompt
+	}
+	if config.MaxPlanRepairs <= 0 {
+		config.MaxPlanRepairs = 2
+	}
 
 	return &OrchestratorAgent{
-		llm:     llmClient,
+		llm:     metered(llmClient),
 		tools:   toolRegistry,
 		workers: make(map[string]*WorkerAgent),
 		config:  config,
@@ -147,6 +183,24 @@ Create a JSON plan with this structure:
 
 Return only valid JSON.`
 
+const defaultPlanRepairPrompt = `The following plan for a task is invalid.
+
+Task: %s
+
+Plan:
+%s
+
+Problems:
+%s
+
+Fix the plan so that:
+- every subtask has a unique id
+- dependencies only reference existing subtask ids
+- dependencies contain no cycles
+- worker_type is one of: %s
+
+Return the corrected plan as JSON with the same structure. Return only valid JSON.`
+
 const defaultSynthesisPrompt = `Synthesize these subtask results into a final response:
 
 Original Task: %s
@@ -158,18 +212,23 @@ Create a coherent, comprehensive response that addresses the original task.
 Integrate all relevant information from the subtask results.`
 
 // RegisterWorker adds a specialized worker agent.
+// Workers without their own LLM client use the orchestrator's.
 func (o *OrchestratorAgent) RegisterWorker(worker *WorkerAgent) {
-	worker.llm = o.llm
+	if worker.llm == nil {
+		worker.llm = o.llm
+	}
 	worker.registry = o.tools
+	worker.parent = o.config.Config
 	o.workers[worker.Name] = worker
 }
 
-// ListWorkers returns all registered worker agents.
+// ListWorkers returns all registered worker agents, sorted by name.
 func (o *OrchestratorAgent) ListWorkers() []*WorkerAgent {
 	workers := make([]*WorkerAgent, 0, len(o.workers))
 	for _, w := range o.workers {
 		workers = append(workers, w)
 	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
 	return workers
 }
 
@@ -179,10 +238,29 @@ func (o *OrchestratorAgent) Run(ctx context.Context, query string) (*Response, e
 }
 
 // RunWithHistory processes a query with conversation history.
+//
+// The response's Metadata["orchestrator"] holds the run's *OrchestratorTrace.
+//
+// The run is traced as an "invoke_agent orchestrator" span with one
+// "orchestrator.subtask" span per subtask.
 func (o *OrchestratorAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "orchestrator")
+	resp, err := withMemory(ctx, o.config.Config, history, query, o.run)
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (o *OrchestratorAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
 	var allSteps []Step
 	var totalUsage Usage
 
+	ctx = guardrailContext(ctx, o.config.Guardrails)
+	query, err := o.config.Guardrails.CheckInput(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
 	// Step 1: Create execution plan
 	if o.config.Verbose {
 		log.Printf("[Orchestrator] Creating plan for: %s", truncate(query, 50))
@@ -224,25 +302,47 @@ func (o *OrchestratorAgent) RunWithHistory(ctx context.Context, history []Messag
 		log.Printf("[Orchestrator] Synthesizing %d results", len(results))
 	}
 
-	synthesis, synthUsage, err := o.synthesize(ctx, query, results)
-	if err != nil {
-		return nil, fmt.Errorf("synthesis failed: %w", err)
+	var synthesis string
+	if err := BudgetFrom(ctx).Check(); err != nil {
+		if o.config.Verbose {
+			log.Printf("[Orchestrator] Skipping synthesis: %v", err)
+		}
+		synthesis = partialSynthesis(results)
+	} else {
+		var synthUsage Usage
+		synthesis, synthUsage, err = o.synthesize(ctx, query, results)
+		if err != nil {
+			return nil, fmt.Errorf("synthesis failed: %w", err)
+		}
+		totalUsage = addUsage(totalUsage, synthUsage)
 	}
-	totalUsage = addUsage(totalUsage, synthUsage)
 
 	allSteps = append(allSteps, Step{
 		Type:    "synthesis",
 		Content: synthesis,
 	})
 
-	return &Response{
-		Output: synthesis,
+	output, err := o.config.Guardrails.CheckOutput(ctx, synthesis)
+	if err != nil {
+		return nil, err
+	}
+
+	return withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+		Output: output,
 		Steps:  allSteps,
 		Usage:  totalUsage,
-	}, nil
+		Metadata: map[string]any{
+			"orchestrator": &OrchestratorTrace{
+				Plan:    plan,
+				Results: results,
+				Workers: o.ListWorkers(),
+			},
+		},
+	})), nil
 }
 
-// createPlan generates an execution plan.
+// createPlan generates an execution plan and validates it as a dependency DAG.
+// Invalid plans are sent back to the planner for repair up to MaxPlanRepairs times.
 func (o *OrchestratorAgent) createPlan(ctx context.Context, query string) (*TaskPlan, Usage, error) {
 	prompt := fmt.Sprintf(o.config.PlanningPrompt, query)
 
@@ -256,6 +356,7 @@ func (o *OrchestratorAgent) createPlan(ctx context.Context, query string) (*Task
 	if err != nil {
 		return nil, Usage{}, err
 	}
+	totalUsage := toUsage(resp.Usage)
 
 	var plan TaskPlan
 	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &plan); err != nil {
@@ -271,115 +372,141 @@ func (o *OrchestratorAgent) createPlan(ctx context.Context, query string) (*Task
 		}
 	}
 
-	return &plan, Usage{
-		PromptTokens:     resp.Usage.PromptTokens,
-		CompletionTokens: resp.Usage.CompletionTokens,
-		TotalTokens:      resp.Usage.TotalTokens,
-	}, nil
-}
+	workerTypes := o.workerTypes()
+	validationErr := plan.Validate(workerTypes)
 
-// executeSubtasks runs all subtasks, respecting dependencies.
-func (o *OrchestratorAgent) executeSubtasks(ctx context.Context, plan *TaskPlan) ([]SubtaskResult, Usage) {
-	var totalUsage Usage
-	results := make([]SubtaskResult, 0, len(plan.Subtasks))
-	resultMap := make(map[string]*SubtaskResult)
-	var mu sync.Mutex
+	for repair := 0; validationErr != nil && repair < o.config.MaxPlanRepairs; repair++ {
+		if o.config.Verbose {
+			log.Printf("[Orchestrator] Repairing plan (%d/%d): %v", repair+1, o.config.MaxPlanRepairs, validationErr)
+		}
 
-	// Group tasks by dependency level
-	levels := o.groupByLevel(plan)
+		repaired, usage, err := o.repairPlan(ctx, query, &plan, validationErr, workerTypes)
+		totalUsage = addUsage(totalUsage, usage)
+		if err != nil {
+			return nil, totalUsage, fmt.Errorf("plan repair failed: %w", err)
+		}
 
-	for _, level := range levels {
-		// Execute tasks at this level in parallel
-		var wg sync.WaitGroup
-		semaphore := make(chan struct{}, o.config.MaxWorkers)
+		plan = *repaired
+		validationErr = plan.Validate(workerTypes)
+	}
 
-		for _, task := range level {
-			wg.Add(1)
-			go func(t Subtask) {
-				defer wg.Done()
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
+	if validationErr != nil {
+		return nil, totalUsage, validationErr
+	}
 
-				// Build input with dependency results
-				input := o.buildTaskInput(t, plan.Dependencies, resultMap)
+	return &plan, totalUsage, nil
+}
 
-				result, usage := o.executeSubtask(ctx, t, input)
+// repairPlan asks the planner to fix a plan that failed validation.
+func (o *OrchestratorAgent) repairPlan(ctx context.Context, query string, plan *TaskPlan, validationErr error, workerTypes []string) (*TaskPlan, Usage, error) {
+	planJSON, _ := json.Marshal(plan)
+	prompt := fmt.Sprintf(o.config.PlanRepairPrompt, query, string(planJSON), validationErr.Error(), strings.Join(workerTypes, ", "))
 
-				mu.Lock()
-				results = append(results, result)
-				resultMap[t.ID] = &result
-				totalUsage = addUsage(totalUsage, usage)
-				mu.Unlock()
-			}(task)
-		}
+	resp, err := o.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: o.config.SystemPrompt},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	usage := toUsage(resp.Usage)
 
-		wg.Wait()
+	var repaired TaskPlan
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &repaired); err != nil {
+		return nil, usage, fmt.Errorf("failed to parse repaired plan: %w", err)
 	}
 
-	return results, totalUsage
+	return &repaired, usage, nil
 }
 
-// groupByLevel groups subtasks by dependency level.
-func (o *OrchestratorAgent) groupByLevel(plan *TaskPlan) [][]Subtask {
-	if len(plan.Subtasks) == 0 {
-		return nil
+// workerTypes returns the worker types a plan may reference: the registered
+// workers, or the default types handled by executeDefault when none are registered.
+func (o *OrchestratorAgent) workerTypes() []string {
+	if len(o.workers) == 0 {
+		return defaultWorkerTypes
 	}
 
-	// Simple case: no dependencies
-	if len(plan.Dependencies) == 0 {
-		return [][]Subtask{plan.Subtasks}
+	types := make([]string, 0, len(o.workers))
+	for name := range o.workers {
+		types = append(types, name)
 	}
+	sort.Strings(types)
+	return types
+}
+
+// defaultWorkerTypes are the worker types named in the default orchestrator prompt.
+var defaultWorkerTypes = []string{"calculator", "general", "researcher", "writer"}
+
+// executeSubtasks runs all subtasks, respecting dependencies.
+// Scheduling is event-driven: a subtask starts as soon as all of its own
+// dependencies have finished, bounded by MaxWorkers concurrent subtasks.
+// The plan must have passed Validate.
+func (o *OrchestratorAgent) executeSubtasks(ctx context.Context, plan *TaskPlan) ([]SubtaskResult, Usage) {
+	var totalUsage Usage
+	results := make([]SubtaskResult, 0, len(plan.Subtasks))
+	resultMap := make(map[string]*SubtaskResult)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, o.config.MaxWorkers)
 
-	// Build dependency graph
-	taskMap := make(map[string]Subtask)
+	taskMap := make(map[string]Subtask, len(plan.Subtasks))
 	for _, t := range plan.Subtasks {
 		taskMap[t.ID] = t
 	}
-
-	// Calculate levels using topological sort
-	levels := make([][]Subtask, 0)
-	completed := make(map[string]bool)
-
-	for len(completed) < len(plan.Subtasks) {
-		var currentLevel []Subtask
-
-		for _, task := range plan.Subtasks {
-			if completed[task.ID] {
-				continue
-			}
-
-			// Check if all dependencies are completed
-			deps := plan.Dependencies[task.ID]
-			allDepsCompleted := true
-			for _, dep := range deps {
-				if !completed[dep] {
-					allDepsCompleted = false
-					break
+	dependents, pending := plan.dependentsOf()
+
+	var launch func(t Subtask)
+	launch = func(t Subtask) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			// Build input with dependency results
+			mu.Lock()
+			input := o.buildTaskInput(t, plan.Dependencies, resultMap)
+			mu.Unlock()
+
+			result, usage := o.executeSubtask(ctx, t, input)
+			<-semaphore
+
+			mu.Lock()
+			results = append(results, result)
+			resultMap[t.ID] = &result
+			totalUsage = addUsage(totalUsage, usage)
+
+			var ready []Subtask
+			for _, id := range dependents[t.ID] {
+				pending[id]--
+				if pending[id] == 0 {
+					ready = append(ready, taskMap[id])
 				}
 			}
+			mu.Unlock()
 
-			if allDepsCompleted {
-				currentLevel = append(currentLevel, task)
+			for _, next := range ready {
+				launch(next)
 			}
-		}
-
-		if len(currentLevel) == 0 {
-			// Circular dependency or missing task - add remaining
-			for _, task := range plan.Subtasks {
-				if !completed[task.ID] {
-					currentLevel = append(currentLevel, task)
-				}
-			}
-		}
+		}()
+	}
 
-		for _, task := range currentLevel {
-			completed[task.ID] = true
+	// Collect roots before launching, since workers update pending concurrently
+	var roots []Subtask
+	for _, t := range plan.Subtasks {
+		if pending[t.ID] == 0 {
+			roots = append(roots, t)
 		}
-
-		levels = append(levels, currentLevel)
 	}
+	for _, t := range roots {
+		launch(t)
+	}
+
+	wg.Wait()
 
-	return levels
+	return results, totalUsage
 }
 
 // buildTaskInput creates input with dependency results.
@@ -402,19 +529,48 @@ func (o *OrchestratorAgent) buildTaskInput(task Subtask, deps map[string][]strin
 	return sb.String()
 }
 
-// executeSubtask runs a single subtask.
+// executeSubtask runs a single subtask and records which worker ran it,
+// how long it took and the tokens it used.
 func (o *OrchestratorAgent) executeSubtask(ctx context.Context, task Subtask, input string) (SubtaskResult, Usage) {
+	ctx, span := startSubtaskSpan(ctx, task)
+	result, usage := o.runSubtask(ctx, task, input)
+	endSubtaskSpan(span, result)
+	return result, usage
+}
+
+// runSubtask implements executeSubtask.
+func (o *OrchestratorAgent) runSubtask(ctx context.Context, task Subtask, input string) (SubtaskResult, Usage) {
+	// Workers share the run's budget; once it is spent, remaining subtasks are skipped
+	if err := BudgetFrom(ctx).Check(); err != nil {
+		return SubtaskResult{
+			ID:      task.ID,
+			Success: false,
+			Error:   fmt.Sprintf("skipped: %v", err),
+		}, Usage{}
+	}
+
 	worker := o.workers[task.WorkerType]
 	if worker == nil {
 		worker = o.workers["general"]
 	}
 
+	start := time.Now()
+	var result SubtaskResult
+	var usage Usage
 	if worker == nil {
 		// Use default execution
-		return o.executeDefault(ctx, task, input)
+		result, usage = o.executeDefault(ctx, task, input)
+		result.Worker = "default"
+	} else {
+		result, usage = worker.Execute(ctx, input)
+		result.Worker = worker.Name
 	}
 
-	return worker.Execute(ctx, input)
+	result.ID = task.ID
+	result.Duration = time.Since(start).Milliseconds()
+	result.Usage = usage
+
+	return result, usage
 }
 
 // executeDefault runs a task without a specialized worker.
@@ -444,6 +600,21 @@ func (o *OrchestratorAgent) executeDefault(ctx context.Context, task Subtask, in
 		}
 }
 
+// partialSynthesis combines the successful results without an LLM call,
+// for runs whose budget is spent before synthesis.
+func partialSynthesis(results []SubtaskResult) string {
+	var sb strings.Builder
+	for _, r := range results {
+		if r.Success && r.Output != "" {
+			sb.WriteString(fmt.Sprintf("%s:\n%s\n\n", r.ID, r.Output))
+		}
+	}
+	if sb.Len() == 0 {
+		return "I could not complete this request within the allotted budget."
+	}
+	return "I could not finish within the allotted budget. Partial results:\n\n" + strings.TrimSpace(sb.String())
+}
+
 // synthesize combines subtask results.
 func (o *OrchestratorAgent) synthesize(ctx context.Context, query string, results []SubtaskResult) (string, Usage, error) {
 	var sb strings.Builder
@@ -474,7 +645,7 @@ func (o *OrchestratorAgent) synthesize(ctx context.Context, query string, result
 	}, nil
 }
 
-// Execute runs the worker on an input.
+// Execute runs the worker's ReAct loop on an input.
 func (w *WorkerAgent) Execute(ctx context.Context, input string) (SubtaskResult, Usage) {
 	// Nil check for LLM
 	if w.llm == nil {
@@ -490,104 +661,75 @@ func (w *WorkerAgent) Execute(ctx context.Context, input string) (SubtaskResult,
 		prompt = fmt.Sprintf("You are a %s agent. %s", w.Name, w.Description)
 	}
 
-	// If worker has specific tools, use them
-	if len(w.Tools) > 0 && w.registry != nil {
-		// Create filtered tool registry
-		filteredTools := make([]llm.ToolDefinition, 0)
-		for _, toolName := range w.Tools {
-			if tool := w.registry.Get(toolName); tool != nil {
-				params := tool.Parameters()
-				filteredTools = append(filteredTools, llm.ToolDefinition{
-					Type: "function",
-					Function: llm.FunctionDefinition{
-						Name:        tool.Name(),
-						Description: tool.Description(),
-						Parameters: map[string]any{
-							"type":       params.Type,
-							"properties": params.Properties,
-							"required":   params.Required,
-						},
-					},
-				})
-			}
-		}
+	maxIterations := w.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultWorkerMaxIterations
+	}
 
-		if len(filteredTools) > 0 {
-			return w.executeWithTools(ctx, input, prompt, filteredTools)
-		}
+	// Workers inherit the parent's guardrails, loop detection and logging;
+	// memory is recalled once by the parent
+	config := w.parent
+	config.SystemPrompt = prompt
+	config.MaxIterations = maxIterations
+	config.Temperature = w.Temperature
+	config.Memory = nil
+	if w.Model != "" {
+		config.Model = w.Model
 	}
 
-	// Simple execution without tools
-	resp, err := w.llm.Chat(ctx, &llm.ChatRequest{
-		Messages: []llm.Message{
-			{Role: llm.RoleSystem, Content: prompt},
-			{Role: llm.RoleUser, Content: input},
-		},
-	})
+	executor := NewReActAgent(w.llm, w.allowedTools(), config)
+
+	resp, err := executor.Run(ctx, input)
 	if err != nil {
+		steps, usage := progressOf(err)
 		return SubtaskResult{
 			Success: false,
 			Error:   err.Error(),
-		}, Usage{}
+			Steps:   steps,
+		}, usage
+	}
+
+	// A run stopped at a limit keeps its best-effort output for synthesis
+	if incomplete, _ := resp.Metadata[metadataIncomplete].(bool); incomplete {
+		return SubtaskResult{
+			Success:    false,
+			Output:     resp.Output,
+			Incomplete: true,
+			Error:      fmt.Sprintf("incomplete: %v", resp.Metadata[metadataIncompleteReason]),
+			Steps:      resp.Steps,
+		}, resp.Usage
 	}
 
 	return SubtaskResult{
-			Success: true,
-			Output:  resp.Content,
-		}, Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		}
+		Success: true,
+		Output:  resp.Output,
+		Steps:   resp.Steps,
+	}, resp.Usage
 }
 
-// executeWithTools runs the worker with tool calling.
-func (w *WorkerAgent) executeWithTools(ctx context.Context, input, prompt string, tools []llm.ToolDefinition) (SubtaskResult, Usage) {
-	resp, err := w.llm.ChatWithTools(ctx, &llm.ChatWithToolsRequest{
-		Messages: []llm.Message{
-			{Role: llm.RoleSystem, Content: prompt},
-			{Role: llm.RoleUser, Content: input},
-		},
-		Tools: tools,
-	})
-	if err != nil {
-		return SubtaskResult{
-			Success: false,
-			Error:   err.Error(),
-		}, Usage{}
+// defaultWorkerMaxIterations bounds a worker's ReAct loop when unset.
+const defaultWorkerMaxIterations = 5
+
+// allowedTools returns a registry restricted to the worker's Tools.
+func (w *WorkerAgent) allowedTools() *tools.Registry {
+	return restrictTools(w.registry, w.Tools)
+}
+
+// restrictTools returns a registry containing only the named tools.
+// Tools missing from registry are skipped.
+func restrictTools(registry *tools.Registry, names []string) *tools.Registry {
+	allowed := tools.NewRegistry()
+	if registry == nil {
+		return allowed
 	}
 
-	// Handle tool calls
-	if resp.HasToolCalls() {
-		var results []string
-		for _, tc := range resp.ToolCalls {
-			if tool := w.registry.Get(tc.Name); tool != nil {
-				result, err := tool.Execute(ctx, tc.Arguments)
-				if err != nil {
-					results = append(results, fmt.Sprintf("%s error: %v", tc.Name, err))
-				} else {
-					results = append(results, fmt.Sprintf("%s: %s", tc.Name, result.String()))
-				}
-			}
+	for _, name := range names {
+		if tool := registry.Get(name); tool != nil {
+			_ = allowed.Register(tool)
 		}
-		return SubtaskResult{
-				Success: true,
-				Output:  strings.Join(results, "\n"),
-			}, Usage{
-				PromptTokens:     resp.Usage.PromptTokens,
-				CompletionTokens: resp.Usage.CompletionTokens,
-				TotalTokens:      resp.Usage.TotalTokens,
-			}
 	}
 
-	return SubtaskResult{
-			Success: true,
-			Output:  resp.Content,
-		}, Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		}
+	return allowed
 }
 
 // addUsage combines two usage stats.
@@ -642,3 +784,14 @@ Create clear, engaging, and well-structured content.
 Adapt your style to the task requirements.`,
 	}
 }
+
+// DefaultWorkers returns the built-in worker set: general, calculator,
+// researcher and writer.
+func DefaultWorkers() []*WorkerAgent {
+	return []*WorkerAgent{
+		NewGeneralWorker(),
+		NewCalculatorWorker(),
+		NewResearcherWorker(),
+		NewWriterWorker(),
+	}
+}
@@ -2,7 +2,14 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
 )
 
 func TestOrchestratorAgent_TaskPlan(t *testing.T) {
@@ -168,41 +175,60 @@ func TestAddUsage(t *testing.T) {
 	}
 }
 
-func TestOrchestratorAgent_GroupByLevel_NoDependencies(t *testing.T) {
-	orch := &OrchestratorAgent{
-		config: OrchestratorConfig{},
+// newRecordingOrchestrator returns an orchestrator whose default executor
+// records the order in which subtask inputs are started and finished.
+func newRecordingOrchestrator(delays map[string]time.Duration, events *[]string, mu *sync.Mutex) *OrchestratorAgent {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			id := strings.SplitN(req.Messages[1].Content, "\n", 2)[0]
+			mu.Lock()
+			*events = append(*events, "start:"+id)
+			mu.Unlock()
+			time.Sleep(delays[id])
+			mu.Lock()
+			*events = append(*events, "end:"+id)
+			mu.Unlock()
+			return &llm.ChatResponse{Content: "out-" + id}, nil
+		},
 	}
+	return NewOrchestratorAgent(mock, tools.NewRegistry(), OrchestratorConfig{MaxWorkers: 5})
+}
+
+func TestOrchestratorAgent_ExecuteSubtasks_NoDependencies(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	orch := newRecordingOrchestrator(nil, &events, &mu)
 
 	plan := &TaskPlan{
 		Subtasks: []Subtask{
-			{ID: "task_1"},
-			{ID: "task_2"},
-			{ID: "task_3"},
+			{ID: "task_1", Input: "task_1"},
+			{ID: "task_2", Input: "task_2"},
+			{ID: "task_3", Input: "task_3"},
 		},
-		Dependencies: nil,
 	}
 
-	levels := orch.groupByLevel(plan)
+	results, _ := orch.executeSubtasks(context.Background(), plan)
 
-	if len(levels) != 1 {
-		t.Errorf("expected 1 level for independent tasks, got %d", len(levels))
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
 	}
-
-	if len(levels[0]) != 3 {
-		t.Errorf("expected 3 tasks in first level, got %d", len(levels[0]))
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("expected %s to succeed", r.ID)
+		}
 	}
 }
 
-func TestOrchestratorAgent_GroupByLevel_WithDependencies(t *testing.T) {
-	orch := &OrchestratorAgent{
-		config: OrchestratorConfig{},
-	}
+func TestOrchestratorAgent_ExecuteSubtasks_WithDependencies(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	orch := newRecordingOrchestrator(nil, &events, &mu)
 
 	plan := &TaskPlan{
 		Subtasks: []Subtask{
-			{ID: "task_1"},
-			{ID: "task_2"},
-			{ID: "task_3"},
+			{ID: "task_1", Input: "task_1"},
+			{ID: "task_2", Input: "task_2"},
+			{ID: "task_3", Input: "task_3"},
 		},
 		Dependencies: map[string][]string{
 			"task_2": {"task_1"},
@@ -210,41 +236,108 @@ func TestOrchestratorAgent_GroupByLevel_WithDependencies(t *testing.T) {
 		},
 	}
 
-	levels := orch.groupByLevel(plan)
+	results, _ := orch.executeSubtasks(context.Background(), plan)
 
-	if len(levels) != 3 {
-		t.Errorf("expected 3 levels for sequential tasks, got %d", len(levels))
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
 	}
 
-	// First level should have task_1
-	if len(levels[0]) != 1 || levels[0][0].ID != "task_1" {
-		t.Errorf("expected first level to have task_1")
+	expected := []string{"start:task_1", "end:task_1", "start:task_2", "end:task_2", "start:task_3", "end:task_3"}
+	for i, e := range expected {
+		if events[i] != e {
+			t.Fatalf("expected sequential execution %v, got %v", expected, events)
+		}
 	}
+}
+
+func TestOrchestratorAgent_ExecuteSubtasks_EventDriven(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	// slow and fast run in parallel; after_fast depends only on fast and
+	// must start before slow finishes instead of waiting for the whole level.
+	orch := newRecordingOrchestrator(map[string]time.Duration{
+		"slow": 100 * time.Millisecond,
+	}, &events, &mu)
 
-	// Second level should have task_2
-	if len(levels[1]) != 1 || levels[1][0].ID != "task_2" {
-		t.Errorf("expected second level to have task_2")
+	plan := &TaskPlan{
+		Subtasks: []Subtask{
+			{ID: "slow", Input: "slow"},
+			{ID: "fast", Input: "fast"},
+			{ID: "after_fast", Input: "after_fast"},
+		},
+		Dependencies: map[string][]string{
+			"after_fast": {"fast"},
+		},
+	}
+
+	orch.executeSubtasks(context.Background(), plan)
+
+	index := func(e string) int {
+		for i, ev := range events {
+			if ev == e {
+				return i
+			}
+		}
+		return -1
 	}
 
-	// Third level should have task_3
-	if len(levels[2]) != 1 || levels[2][0].ID != "task_3" {
-		t.Errorf("expected third level to have task_3")
+	if index("start:after_fast") < index("end:fast") {
+		t.Errorf("after_fast started before its dependency finished: %v", events)
+	}
+	if index("start:after_fast") > index("end:slow") {
+		t.Errorf("after_fast waited for unrelated slow task: %v", events)
 	}
 }
 
-func TestOrchestratorAgent_GroupByLevel_Empty(t *testing.T) {
-	orch := &OrchestratorAgent{
-		config: OrchestratorConfig{},
+func TestOrchestratorAgent_CreatePlan_Repair(t *testing.T) {
+	calls := 0
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+			calls++
+			if calls == 1 {
+				return &llm.ChatResponse{Content: `{"subtasks": [
+					{"id": "a", "worker_type": "general"},
+					{"id": "b", "worker_type": "general"}
+				], "dependencies": {"a": ["b"], "b": ["a"]}}`}, nil
+			}
+			return &llm.ChatResponse{Content: `{"subtasks": [
+				{"id": "a", "worker_type": "general"},
+				{"id": "b", "worker_type": "general"}
+			], "dependencies": {"b": ["a"]}}`}, nil
+		},
 	}
+	orch := NewOrchestratorAgent(mock, tools.NewRegistry(), OrchestratorConfig{})
 
-	plan := &TaskPlan{
-		Subtasks: []Subtask{},
+	plan, _, err := orch.createPlan(context.Background(), "task")
+	if err != nil {
+		t.Fatalf("expected repaired plan, got error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 1 planning and 1 repair call, got %d", calls)
+	}
+	if deps := plan.Dependencies["b"]; len(deps) != 1 || deps[0] != "a" {
+		t.Errorf("expected repaired dependencies, got %v", plan.Dependencies)
 	}
+}
+
+func TestOrchestratorAgent_CreatePlan_RepairExhausted(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+			return &llm.ChatResponse{Content: `{"subtasks": [
+				{"id": "a", "worker_type": "astrologer"}
+			]}`}, nil
+		},
+	}
+	orch := NewOrchestratorAgent(mock, tools.NewRegistry(), OrchestratorConfig{MaxPlanRepairs: 1})
 
-	levels := orch.groupByLevel(plan)
+	_, _, err := orch.createPlan(context.Background(), "task")
 
-	if levels != nil {
-		t.Errorf("expected nil for empty subtasks, got %v", levels)
+	var verr *PlanValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected PlanValidationError, got %v", err)
+	}
+	if verr.UnknownWorkers["a"] != "astrologer" {
+		t.Errorf("expected unknown worker to be reported, got %v", verr.UnknownWorkers)
 	}
 }
 
@@ -324,3 +417,188 @@ func TestWorkerAgent_ExecuteWithoutLLM(t *testing.T) {
 		t.Errorf("expected 0 tokens, got %d", usage.TotalTokens)
 	}
 }
+
+func TestWorkerAgent_ExecuteReActLoop(t *testing.T) {
+	var requests []*llm.ChatWithToolsRequest
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			requests = append(requests, req)
+			if len(requests) == 1 {
+				return &llm.ChatWithToolsResponse{
+					ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "6 * 7"}`}},
+					Usage:     llm.Usage{TotalTokens: 10},
+				}, nil
+			}
+			// The model sees the tool output before answering
+			last := req.Messages[len(req.Messages)-1]
+			return &llm.ChatWithToolsResponse{
+				Content: "The answer is " + last.Content,
+				Usage:   llm.Usage{TotalTokens: 5},
+			}, nil
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	orch := NewOrchestratorAgent(mock, registry, OrchestratorConfig{})
+	worker := NewCalculatorWorker()
+	worker.Model = "small-model"
+	worker.Temperature = 0.2
+	orch.RegisterWorker(worker)
+
+	result, usage := worker.Execute(context.Background(), "what is 6 * 7?")
+
+	if !result.Success {
+		t.Fatalf("expected success, got error %s", result.Error)
+	}
+	if result.Output != "The answer is 42" {
+		t.Errorf("expected tool output to be read by the model, got %q", result.Output)
+	}
+	if len(result.Steps) != 2 {
+		t.Errorf("expected action and observation steps, got %d", len(result.Steps))
+	}
+	if usage.TotalTokens != 15 {
+		t.Errorf("expected 15 tokens across iterations, got %d", usage.TotalTokens)
+	}
+	if requests[0].Model != "small-model" || requests[0].Temperature != 0.2 {
+		t.Errorf("expected worker model settings, got model=%q temperature=%v", requests[0].Model, requests[0].Temperature)
+	}
+}
+
+func TestWorkerAgent_InheritsParentAndKeepsPartialUsage(t *testing.T) {
+	var firstQuery string
+	calls := 0
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			calls++
+			if calls == 1 {
+				firstQuery = req.Messages[len(req.Messages)-1].Content
+				return &llm.ChatWithToolsResponse{
+					ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "6 * 7"}`}},
+					Usage:     llm.Usage{TotalTokens: 10},
+				}, nil
+			}
+			return nil, errors.New("rate limited")
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	orch := NewOrchestratorAgent(mock, registry, OrchestratorConfig{Config: Config{Guardrails: newTestGuardrails(t)}})
+	worker := NewCalculatorWorker()
+	orch.RegisterWorker(worker)
+
+	result, usage := worker.Execute(context.Background(), "what is 6 * 7? reply to jane@example.com")
+
+	if strings.Contains(firstQuery, "jane@example.com") {
+		t.Errorf("expected the parent's guardrails to redact the input, got %q", firstQuery)
+	}
+	if result.Success || !strings.Contains(result.Error, "rate limited") {
+		t.Fatalf("expected the LLM failure, got %+v", result)
+	}
+	if usage.TotalTokens != 10 || len(result.Steps) != 2 {
+		t.Errorf("expected the usage and steps before the failure, got %+v and %d steps", usage, len(result.Steps))
+	}
+}
+
+func TestWorkerAgent_AllowedTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	worker := &WorkerAgent{Name: "writer", registry: registry}
+	if worker.allowedTools().Count() != 0 {
+		t.Error("expected worker without Tools to get no tools")
+	}
+
+	worker.Tools = []string{"calculator", "missing"}
+	allowed := worker.allowedTools()
+	if allowed.Count() != 1 || !allowed.Has("calculator") {
+		t.Errorf("expected only calculator, got %v", allowed.Names())
+	}
+}
+
+func TestWorkerAgent_MaxIterations(t *testing.T) {
+	calls := 0
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			calls++
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "1 + 1"}`}},
+			}, nil
+		},
+	}
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	orch := NewOrchestratorAgent(mock, registry, OrchestratorConfig{})
+	worker := NewCalculatorWorker()
+	worker.MaxIterations = 3
+	orch.RegisterWorker(worker)
+
+	result, _ := worker.Execute(context.Background(), "loop forever")
+
+	if result.Success || !strings.Contains(result.Error, "incomplete") {
+		t.Errorf("expected an incomplete result when iterations are exhausted, got %+v", result)
+	}
+	// Three iterations and the final answer call
+	if calls != 4 {
+		t.Errorf("expected 4 LLM calls, got %d", calls)
+	}
+}
+
+func TestOrchestratorAgent_MetadataTrace(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			if strings.Contains(req.Messages[0].Content, "synthesizer") {
+				return &llm.ChatResponse{Content: "combined"}, nil
+			}
+			return &llm.ChatResponse{Content: `{
+				"analysis": "two parts",
+				"subtasks": [
+					{"id": "a", "worker_type": "general", "input": "part a"},
+					{"id": "b", "worker_type": "writer", "input": "part b"}
+				],
+				"dependencies": {"b": ["a"]}
+			}`}, nil
+		},
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return &llm.ChatWithToolsResponse{Content: "done", Usage: llm.Usage{TotalTokens: 7}}, nil
+		},
+	}
+
+	orch := NewOrchestratorAgent(mock, tools.NewRegistry(), OrchestratorConfig{})
+	orch.RegisterWorker(NewWriterWorker())
+	orch.RegisterWorker(NewGeneralWorker())
+
+	resp, err := orch.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta := resp.Metadata["orchestrator"].(*OrchestratorTrace)
+	if plan := meta.Plan; len(plan.Subtasks) != 2 {
+		t.Errorf("expected plan with 2 subtasks, got %d", len(plan.Subtasks))
+	}
+
+	results := meta.Results
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.ID == "" || r.Worker == "" {
+			t.Errorf("expected ID and worker to be set, got %+v", r)
+		}
+		if r.Usage.TotalTokens != 7 {
+			t.Errorf("expected per-subtask usage 7, got %d", r.Usage.TotalTokens)
+		}
+	}
+	if results[0].ID != "a" || results[0].Worker != "general" || results[1].Worker != "writer" {
+		t.Errorf("unexpected result order or workers: %+v", results)
+	}
+
+	workers := meta.Workers
+	if len(workers) != 2 || workers[0].Name != "general" {
+		t.Errorf("expected workers sorted by name, got %v", workers)
+	}
+}
@@ -0,0 +1,476 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// PlanExecuteAgent implements the Plan-and-Execute pattern with dynamic replanning.
+// Unlike OrchestratorAgent, which plans once up front, it revisits the plan
+// after every step and may revise the remaining steps or finish early.
+//
+// Reference: Wang et al., 2023 - "Plan-and-Solve Prompting"
+// https://arxiv.org/abs/2305.04091
+//
+// Flow:
+//  1. Planner creates an ordered list of steps
+//  2. The next step is executed by a ReAct executor
+//  3. Replanner decides to continue, revise the remaining steps, or finish
+//  4. Repeat until the replanner finishes or MaxSteps is reached
+type PlanExecuteAgent struct {
+	llm    LLMClient
+	tools  *tools.Registry
+	config PlanExecuteConfig
+}
+
+// PlanExecuteConfig contains configuration for the Plan-and-Execute agent.
+type PlanExecuteConfig struct {
+	Config
+
+	// MaxSteps is the maximum number of plan steps executed in one run.
+	MaxSteps int
+
+	// MaxReplans is the maximum number of plan revisions allowed.
+	MaxReplans int
+
+	// PlanningPrompt is the prompt used to create the initial plan.
+	PlanningPrompt string
+
+	// ReplanPrompt is the prompt used to decide how to proceed after each step.
+	ReplanPrompt string
+}
+
+// ExecutionPlan is an ordered list of steps produced by the planner.
+type ExecutionPlan struct {
+	// Steps are the remaining steps to execute, in order.
+	Steps []string `json:"steps"`
+}
+
+// PlanStepResult records the outcome of executing a single plan step.
+type PlanStepResult struct {
+	Step    string `json:"step"`
+	Output  string `json:"output"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Replan actions returned by the replanner.
+const (
+	ReplanActionContinue = "continue"
+	ReplanActionRevise   = "revise"
+	ReplanActionFinish   = "finish"
+)
+
+// ReplanDecision is the replanner's decision after a step completes.
+type ReplanDecision struct {
+	// Action is one of "continue", "revise" or "finish".
+	Action string `json:"action"`
+
+	// Steps replaces the remaining plan when Action is "revise".
+	Steps []string `json:"steps,omitempty"`
+
+	// Response is the final answer when Action is "finish".
+	Response string `json:"response,omitempty"`
+
+	// Reasoning explains the decision.
+	Reasoning string `json:"reasoning,omitempty"`
+}
+
+// NewPlanExecuteAgent creates a new Plan-and-Execute agent.
+func NewPlanExecuteAgent(llmClient LLMClient, toolRegistry *tools.Registry, config PlanExecuteConfig) *PlanExecuteAgent {
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = 10
+	}
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = 10
+	}
+	if config.MaxReplans <= 0 {
+		config.MaxReplans = 3
+	}
+	if config.SystemPrompt == "" {
+		config.SystemPrompt = defaultSystemPrompt
+	}
+	if config.PlanningPrompt == "" {
+		config.PlanningPrompt = defaultPlanExecutePlanningPrompt
+	}
+	if config.ReplanPrompt == "" {
+		config.ReplanPrompt = defaultReplanPrompt
+	}
+
+	return &PlanExecuteAgent{
+		llm:    metered(llmClient),
+		tools:  toolRegistry,
+		config: config,
+	}
+}
+
+const defaultPlanExecutePlanningPrompt = `Devise a simple step-by-step plan to accomplish this objective:
+
+Objective: %s
+
+Each step should be a self-contained task that, executed in order, yields the
+correct answer. Do not add superfluous steps. The result of the final step
+should be the final answer.
+
+Respond with a JSON object:
+{
+  "steps": ["step 1", "step 2"]
+}`
+
+const defaultReplanPrompt = `You are updating a plan after executing a step.
+
+Objective: %s
+
+Completed steps:
+%s
+
+Remaining plan:
+%s
+
+Decide how to proceed:
+- "continue" if the remaining plan is still appropriate
+- "revise" if the remaining steps should change (provide the new remaining steps)
+- "finish" if the objective is achieved (provide the final response)
+
+Respond with a JSON object:
+{
+  "action": "continue|revise|finish",
+  "steps": ["new remaining step"],
+  "response": "final answer when finishing",
+  "reasoning": "why you chose this action"
+}`
+
+// Run processes a query using the Plan-and-Execute pattern.
+func (a *PlanExecuteAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return a.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory processes a query with conversation history. The run is
+// traced as an "invoke_agent plan_execute" span.
+func (a *PlanExecuteAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "plan_execute")
+	resp, err := withMemory(ctx, a.config.Config, history, query, a.run)
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (a *PlanExecuteAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	var allSteps []Step
+	var totalUsage Usage
+	budget := BudgetFrom(ctx)
+
+	ctx = guardrailContext(ctx, a.config.Guardrails)
+	query, err := a.config.Guardrails.CheckInput(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 1: Create the initial plan
+	if a.config.Verbose {
+		log.Printf("[PlanExecute] Creating plan for: %s", truncate(query, 50))
+	}
+
+	plan, planUsage, err := a.createPlan(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("planning failed: %w", err)
+	}
+	totalUsage = addUsage(totalUsage, planUsage)
+
+	initialPlan := append([]string(nil), plan.Steps...)
+	allSteps = append(allSteps, Step{
+		Type:    "planning",
+		Content: formatPlanSteps(plan.Steps),
+	})
+
+	var completed []PlanStepResult
+	replans := 0
+
+	// Step 2: Execute and replan
+	for len(plan.Steps) > 0 && len(completed) < a.config.MaxSteps {
+		// Steps share the run's budget; once it is spent, the rest are skipped
+		if err := budget.Check(); err != nil {
+			if a.config.Verbose {
+				log.Printf("[PlanExecute] Stopping after %d steps: %v", len(completed), err)
+			}
+			break
+		}
+
+		current := plan.Steps[0]
+		plan.Steps = plan.Steps[1:]
+
+		if a.config.Verbose {
+			log.Printf("[PlanExecute] Executing step %d: %s", len(completed)+1, truncate(current, 80))
+		}
+
+		result, stepSteps, stepUsage := a.executeStep(ctx, history, query, completed, current)
+		completed = append(completed, result)
+		totalUsage = addUsage(totalUsage, stepUsage)
+		allSteps = append(allSteps, stepSteps...)
+
+		stepType := StepTypeObservation
+		if !result.Success {
+			stepType = "error"
+		}
+		allSteps = append(allSteps, Step{
+			Type:       stepType,
+			Content:    current,
+			ToolOutput: result.Output,
+		})
+
+		decision, replanUsage, err := a.replan(ctx, query, completed, plan.Steps)
+		totalUsage = addUsage(totalUsage, replanUsage)
+		if err != nil {
+			if a.config.Verbose {
+				log.Printf("[PlanExecute] Replanning failed: %v, continuing with current plan", err)
+			}
+			continue
+		}
+
+		switch decision.Action {
+		case ReplanActionFinish:
+			if a.config.Verbose {
+				log.Printf("[PlanExecute] Replanner finished after %d steps", len(completed))
+			}
+			allSteps = append(allSteps, Step{
+				Type:    "synthesis",
+				Content: decision.Response,
+			})
+			return a.buildResponse(ctx, decision.Response, allSteps, totalUsage, initialPlan, completed, replans)
+
+		case ReplanActionRevise:
+			if replans >= a.config.MaxReplans {
+				if a.config.Verbose {
+					log.Printf("[PlanExecute] Max replans reached, ignoring revision")
+				}
+				continue
+			}
+			replans++
+			plan.Steps = decision.Steps
+			allSteps = append(allSteps, Step{
+				Type:    "replan",
+				Content: fmt.Sprintf("%s\n%s", decision.Reasoning, formatPlanSteps(plan.Steps)),
+			})
+			if a.config.Verbose {
+				log.Printf("[PlanExecute] Plan revised (%d/%d): %d steps remaining", replans, a.config.MaxReplans, len(plan.Steps))
+			}
+		}
+	}
+
+	// Step 3: Synthesize when the plan is exhausted without an explicit finish
+	var output string
+	if err := budget.Check(); err != nil {
+		if a.config.Verbose {
+			log.Printf("[PlanExecute] Skipping synthesis: %v", err)
+		}
+		output = partialPlanResults(completed)
+	} else {
+		var synthUsage Usage
+		output, synthUsage, err = a.synthesize(ctx, query, completed)
+		if err != nil {
+			return nil, fmt.Errorf("synthesis failed: %w", err)
+		}
+		totalUsage = addUsage(totalUsage, synthUsage)
+	}
+
+	allSteps = append(allSteps, Step{
+		Type:    "synthesis",
+		Content: output,
+	})
+
+	return a.buildResponse(ctx, output, allSteps, totalUsage, initialPlan, completed, replans)
+}
+
+// createPlan asks the planner for an initial list of steps.
+func (a *PlanExecuteAgent) createPlan(ctx context.Context, query string) (*ExecutionPlan, Usage, error) {
+	prompt := fmt.Sprintf(a.config.PlanningPrompt, query)
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a planner. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+
+	var plan ExecutionPlan
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &plan); err != nil || len(plan.Steps) == 0 {
+		// Fall back to a single-step plan if parsing fails
+		plan = ExecutionPlan{Steps: []string{query}}
+	}
+
+	return &plan, toUsage(resp.Usage), nil
+}
+
+// executeStep runs a single plan step with a ReAct executor. It returns the
+// executor's steps and usage, including those of a failed step.
+func (a *PlanExecuteAgent) executeStep(ctx context.Context, history []Message, query string, completed []PlanStepResult, step string) (PlanStepResult, []Step, Usage) {
+	executor := NewReActAgent(a.llm, a.tools, a.config.Config)
+
+	stepHistory := make([]Message, 0, len(history)+1)
+	stepHistory = append(stepHistory, history...)
+	stepHistory = append(stepHistory, Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Overall objective: %s\n\nCompleted steps:\n%s", query, formatCompletedSteps(completed)),
+	})
+
+	resp, err := executor.RunWithHistory(ctx, stepHistory, step)
+	if err != nil {
+		steps, usage := progressOf(err)
+		return PlanStepResult{
+			Step:    step,
+			Success: false,
+			Error:   err.Error(),
+		}, steps, usage
+	}
+
+	return PlanStepResult{
+		Step:    step,
+		Output:  resp.Output,
+		Success: true,
+	}, resp.Steps, resp.Usage
+}
+
+// replan asks the replanner how to proceed given the completed steps.
+func (a *PlanExecuteAgent) replan(ctx context.Context, query string, completed []PlanStepResult, remaining []string) (ReplanDecision, Usage, error) {
+	prompt := fmt.Sprintf(a.config.ReplanPrompt, query, formatCompletedSteps(completed), formatPlanSteps(remaining))
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a replanner. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return ReplanDecision{}, Usage{}, fmt.Errorf("replan request failed: %w", err)
+	}
+	usage := toUsage(resp.Usage)
+
+	var decision ReplanDecision
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &decision); err != nil {
+		return ReplanDecision{}, usage, fmt.Errorf("failed to parse replan decision: %w", err)
+	}
+
+	switch decision.Action {
+	case ReplanActionContinue:
+	case ReplanActionFinish:
+		if decision.Response == "" {
+			return ReplanDecision{}, usage, fmt.Errorf("finish decision has no response")
+		}
+	case ReplanActionRevise:
+		if len(decision.Steps) == 0 {
+			// Revising to an empty plan means there is nothing left to do
+			decision.Action = ReplanActionContinue
+		}
+	default:
+		return ReplanDecision{}, usage, fmt.Errorf("unknown replan action %q", decision.Action)
+	}
+
+	return decision, usage, nil
+}
+
+// synthesize produces a final answer from the completed steps.
+func (a *PlanExecuteAgent) synthesize(ctx context.Context, query string, completed []PlanStepResult) (string, Usage, error) {
+	// A single successful step already is the answer
+	if len(completed) == 1 && completed[0].Success {
+		return completed[0].Output, Usage{}, nil
+	}
+
+	prompt := fmt.Sprintf(defaultSynthesisPrompt, query, formatCompletedSteps(completed))
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a skilled synthesizer. Create coherent responses from multiple inputs."},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+	})
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	return resp.Content, toUsage(resp.Usage), nil
+}
+
+// buildResponse checks the final output against the guardrails and
+// assembles the response with plan metadata.
+func (a *PlanExecuteAgent) buildResponse(ctx context.Context, output string, steps []Step, usage Usage, initialPlan []string, completed []PlanStepResult, replans int) (*Response, error) {
+	output, err := a.config.Guardrails.CheckOutput(ctx, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+		Output: output,
+		Steps:  steps,
+		Usage:  usage,
+		Metadata: map[string]any{
+			"plan_execute": map[string]any{
+				"initial_plan": initialPlan,
+				"completed":    completed,
+				"replans":      replans,
+			},
+		},
+	})), nil
+}
+
+// partialPlanResults answers from the completed steps when the budget runs
+// out before synthesis.
+func partialPlanResults(completed []PlanStepResult) string {
+	var sb strings.Builder
+	for _, r := range completed {
+		if r.Success && r.Output != "" {
+			sb.WriteString(fmt.Sprintf("%s:\n%s\n\n", r.Step, r.Output))
+		}
+	}
+	if sb.Len() == 0 {
+		return "I could not complete this request within the allotted budget."
+	}
+	return "I could not finish within the allotted budget. Partial results:\n\n" + strings.TrimSpace(sb.String())
+}
+
+// formatPlanSteps renders plan steps as a numbered list.
+func formatPlanSteps(steps []string) string {
+	if len(steps) == 0 {
+		return "(none)"
+	}
+	var sb strings.Builder
+	for i, s := range steps {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, s))
+	}
+	return sb.String()
+}
+
+// formatCompletedSteps renders completed steps and their outputs.
+func formatCompletedSteps(completed []PlanStepResult) string {
+	if len(completed) == 0 {
+		return "(none)"
+	}
+	var sb strings.Builder
+	for i, r := range completed {
+		if r.Success {
+			sb.WriteString(fmt.Sprintf("%d. %s\n   Result: %s\n", i+1, r.Step, truncate(r.Output, 500)))
+		} else {
+			sb.WriteString(fmt.Sprintf("%d. %s\n   Failed: %s\n", i+1, r.Step, r.Error))
+		}
+	}
+	return sb.String()
+}
+
+// toUsage converts LLM usage to agent usage.
+func toUsage(u llm.Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
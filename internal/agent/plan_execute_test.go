@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func TestNewPlanExecuteAgent_Defaults(t *testing.T) {
+	a := NewPlanExecuteAgent(&MockLLMClient{}, tools.NewRegistry(), PlanExecuteConfig{})
+
+	if a.config.MaxSteps != 10 {
+		t.Errorf("expected MaxSteps 10, got %d", a.config.MaxSteps)
+	}
+	if a.config.MaxReplans != 3 {
+		t.Errorf("expected MaxReplans 3, got %d", a.config.MaxReplans)
+	}
+	if a.config.PlanningPrompt == "" || a.config.ReplanPrompt == "" {
+		t.Error("expected default prompts to be set")
+	}
+}
+
+func TestPlanExecuteAgent_ReviseThenFinish(t *testing.T) {
+	replanCalls := 0
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			system := req.Messages[0].Content
+			switch {
+			case strings.Contains(system, "replanner"):
+				replanCalls++
+				if replanCalls == 1 {
+					return &llm.ChatResponse{Content: `{"action": "revise", "steps": ["find c"], "reasoning": "b is unnecessary"}`}, nil
+				}
+				return &llm.ChatResponse{Content: `{"action": "finish", "response": "a and c"}`}, nil
+			case strings.Contains(system, "planner"):
+				return &llm.ChatResponse{Content: `{"steps": ["find a", "find b"]}`}, nil
+			}
+			return &llm.ChatResponse{Content: "unexpected"}, nil
+		},
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			last := req.Messages[len(req.Messages)-1].Content
+			return &llm.ChatWithToolsResponse{Content: "done: " + last}, nil
+		},
+	}
+
+	a := NewPlanExecuteAgent(mock, tools.NewRegistry(), PlanExecuteConfig{})
+	resp, err := a.Run(context.Background(), "objective")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Output != "a and c" {
+		t.Errorf("expected output 'a and c', got %q", resp.Output)
+	}
+
+	var executed []string
+	var replanSteps int
+	for _, s := range resp.Steps {
+		if s.Type == StepTypeObservation {
+			executed = append(executed, s.Content)
+		}
+		if s.Type == "replan" {
+			replanSteps++
+		}
+	}
+	if len(executed) != 2 || executed[0] != "find a" || executed[1] != "find c" {
+		t.Errorf("expected steps [find a, find c], got %v", executed)
+	}
+	if replanSteps != 1 {
+		t.Errorf("expected 1 replan step, got %d", replanSteps)
+	}
+
+	meta := resp.Metadata["plan_execute"].(map[string]any)
+	if meta["replans"] != 1 {
+		t.Errorf("expected 1 replan in metadata, got %v", meta["replans"])
+	}
+}
+
+func TestPlanExecuteAgent_MaxReplans(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			system := req.Messages[0].Content
+			switch {
+			case strings.Contains(system, "replanner"):
+				return &llm.ChatResponse{Content: `{"action": "revise", "steps": ["again"]}`}, nil
+			case strings.Contains(system, "planner"):
+				return &llm.ChatResponse{Content: `{"steps": ["step"]}`}, nil
+			}
+			return &llm.ChatResponse{Content: "synthesized"}, nil
+		},
+	}
+
+	a := NewPlanExecuteAgent(mock, tools.NewRegistry(), PlanExecuteConfig{MaxReplans: 2, MaxSteps: 5})
+	resp, err := a.Run(context.Background(), "objective")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta := resp.Metadata["plan_execute"].(map[string]any)
+	if meta["replans"] != 2 {
+		t.Errorf("expected replans capped at 2, got %v", meta["replans"])
+	}
+	if resp.Output != "synthesized" {
+		t.Errorf("expected synthesized output, got %q", resp.Output)
+	}
+}
+
+func TestPlanExecuteAgent_BudgetStopsSteps(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			system := req.Messages[0].Content
+			switch {
+			case strings.Contains(system, "replanner"):
+				return &llm.ChatResponse{Content: `{"action": "continue"}`}, nil
+			case strings.Contains(system, "planner"):
+				return &llm.ChatResponse{
+					Content: `{"steps": ["find a", "find b", "find c"]}`,
+					Usage:   llm.Usage{PromptTokens: 100, TotalTokens: 100},
+				}, nil
+			}
+			t.Error("expected synthesis to be skipped")
+			return &llm.ChatResponse{Content: "synthesized"}, nil
+		},
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return &llm.ChatWithToolsResponse{
+				Content: "a is 1",
+				Usage:   llm.Usage{PromptTokens: 100, TotalTokens: 100},
+			}, nil
+		},
+	}
+
+	budget := NewBudget(BudgetLimits{MaxTokens: 150})
+	a := NewPlanExecuteAgent(mock, tools.NewRegistry(), PlanExecuteConfig{})
+	resp, err := a.Run(WithBudget(context.Background(), budget), "objective")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(resp.Output, "find a:\na is 1") {
+		t.Errorf("expected partial results from the first step, got %q", resp.Output)
+	}
+	if completed := resp.Metadata["plan_execute"].(map[string]any)["completed"].([]PlanStepResult); len(completed) != 1 {
+		t.Errorf("expected the remaining steps to be skipped, got %+v", completed)
+	}
+	if tokens := budget.Usage().Tokens; tokens != 200 {
+		t.Errorf("expected planner and executor tokens to be recorded, got %d", tokens)
+	}
+	if _, ok := resp.Metadata["budget"]; !ok {
+		t.Error("expected budget usage in metadata")
+	}
+}
+
+func TestPlanExecuteAgent_FailedStepUsage(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			usage := llm.Usage{TotalTokens: 10}
+			if strings.Contains(req.Messages[0].Content, "replanner") {
+				return &llm.ChatResponse{Content: `{"action": "finish", "response": "could not find a"}`, Usage: usage}, nil
+			}
+			return &llm.ChatResponse{Content: `{"steps": ["find a"]}`, Usage: usage}, nil
+		},
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: "missing", Arguments: `{}`}},
+				Usage:     llm.Usage{TotalTokens: 10},
+			}, nil
+		},
+	}
+
+	a := NewPlanExecuteAgent(mock, tools.NewRegistry(), PlanExecuteConfig{})
+	resp, err := a.Run(context.Background(), "objective")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Usage.TotalTokens != 30 {
+		t.Errorf("expected the failed step's usage to be counted, got %d", resp.Usage.TotalTokens)
+	}
+	if countSteps(resp.Steps, StepTypeAction) != 1 || countSteps(resp.Steps, "error") != 1 {
+		t.Errorf("expected the failed step's action and error steps, got %+v", resp.Steps)
+	}
+}
+
+func TestPlanExecuteAgent_ReplanParsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		action  string
+		wantErr bool
+	}{
+		{"continue", `{"action": "continue"}`, ReplanActionContinue, false},
+		{"empty revise becomes continue", `{"action": "revise", "steps": []}`, ReplanActionContinue, false},
+		{"finish without response", `{"action": "finish"}`, "", true},
+		{"unknown action", `{"action": "explode"}`, "", true},
+		{"invalid json", `not json`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockLLMClient{
+				ChatFunc: func(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+					return &llm.ChatResponse{Content: tt.content}, nil
+				},
+			}
+			a := NewPlanExecuteAgent(mock, tools.NewRegistry(), PlanExecuteConfig{})
+
+			decision, _, err := a.replan(context.Background(), "q", nil, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && decision.Action != tt.action {
+				t.Errorf("expected action %q, got %q", tt.action, decision.Action)
+			}
+		})
+	}
+}
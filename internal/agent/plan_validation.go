@@ -0,0 +1,186 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrEmptyPlan is returned when a plan contains no subtasks.
+var ErrEmptyPlan = errors.New("plan has no subtasks")
+
+// PlanValidationError describes why a TaskPlan is not a valid dependency DAG.
+type PlanValidationError struct {
+	// DuplicateIDs lists subtask IDs that appear more than once.
+	DuplicateIDs []string `json:"duplicate_ids,omitempty"`
+
+	// MissingNodes lists IDs referenced in Dependencies that are not subtasks.
+	MissingNodes []string `json:"missing_nodes,omitempty"`
+
+	// UnknownWorkers maps subtask IDs to worker types that are not available.
+	UnknownWorkers map[string]string `json:"unknown_workers,omitempty"`
+
+	// Cycles lists dependency cycles, each as a path of subtask IDs.
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *PlanValidationError) Error() string {
+	var problems []string
+	if len(e.DuplicateIDs) > 0 {
+		problems = append(problems, fmt.Sprintf("duplicate subtask IDs: %s", strings.Join(e.DuplicateIDs, ", ")))
+	}
+	if len(e.MissingNodes) > 0 {
+		problems = append(problems, fmt.Sprintf("dependencies reference unknown subtasks: %s", strings.Join(e.MissingNodes, ", ")))
+	}
+	if len(e.UnknownWorkers) > 0 {
+		ids := make([]string, 0, len(e.UnknownWorkers))
+		for id := range e.UnknownWorkers {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		parts := make([]string, 0, len(ids))
+		for _, id := range ids {
+			parts = append(parts, fmt.Sprintf("%s=%q", id, e.UnknownWorkers[id]))
+		}
+		problems = append(problems, fmt.Sprintf("unknown worker types: %s", strings.Join(parts, ", ")))
+	}
+	for _, cycle := range e.Cycles {
+		problems = append(problems, fmt.Sprintf("dependency cycle: %s", strings.Join(cycle, " -> ")))
+	}
+	return "invalid plan: " + strings.Join(problems, "; ")
+}
+
+// empty reports whether no problems were found.
+func (e *PlanValidationError) empty() bool {
+	return len(e.DuplicateIDs) == 0 && len(e.MissingNodes) == 0 &&
+		len(e.UnknownWorkers) == 0 && len(e.Cycles) == 0
+}
+
+// Validate checks that the plan forms a valid dependency DAG.
+// If workerTypes is non-empty, every subtask's WorkerType must be one of them.
+// Returns ErrEmptyPlan or a *PlanValidationError describing all problems found.
+func (p *TaskPlan) Validate(workerTypes []string) error {
+	if len(p.Subtasks) == 0 {
+		return ErrEmptyPlan
+	}
+
+	verr := &PlanValidationError{}
+
+	// Nodes
+	ids := make(map[string]bool, len(p.Subtasks))
+	for _, t := range p.Subtasks {
+		if ids[t.ID] {
+			verr.DuplicateIDs = append(verr.DuplicateIDs, t.ID)
+		}
+		ids[t.ID] = true
+	}
+
+	// Worker types
+	if len(workerTypes) > 0 {
+		known := make(map[string]bool, len(workerTypes))
+		for _, w := range workerTypes {
+			known[w] = true
+		}
+		for _, t := range p.Subtasks {
+			if !known[t.WorkerType] {
+				if verr.UnknownWorkers == nil {
+					verr.UnknownWorkers = make(map[string]string)
+				}
+				verr.UnknownWorkers[t.ID] = t.WorkerType
+			}
+		}
+	}
+
+	// Edges
+	missing := make(map[string]bool)
+	for id, deps := range p.Dependencies {
+		if !ids[id] {
+			missing[id] = true
+		}
+		for _, dep := range deps {
+			if !ids[dep] {
+				missing[dep] = true
+			}
+		}
+	}
+	for id := range missing {
+		verr.MissingNodes = append(verr.MissingNodes, id)
+	}
+	sort.Strings(verr.MissingNodes)
+
+	verr.Cycles = p.findCycles()
+
+	if verr.empty() {
+		return nil
+	}
+	return verr
+}
+
+// findCycles returns the dependency cycles in the plan using depth-first search.
+func (p *TaskPlan) findCycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := make(map[string]int, len(p.Subtasks))
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+
+		for _, dep := range p.Dependencies[id] {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				// Found a back edge: extract the cycle from the stack
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == dep {
+						cycle := append([]string(nil), stack[i:]...)
+						cycles = append(cycles, append(cycle, dep))
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, t := range p.Subtasks {
+		if state[t.ID] == unvisited {
+			visit(t.ID)
+		}
+	}
+
+	return cycles
+}
+
+// dependentsOf builds the reverse dependency graph and the number of
+// distinct unfinished dependencies for each subtask.
+func (p *TaskPlan) dependentsOf() (map[string][]string, map[string]int) {
+	dependents := make(map[string][]string)
+	pending := make(map[string]int, len(p.Subtasks))
+
+	for _, t := range p.Subtasks {
+		seen := make(map[string]bool)
+		for _, dep := range p.Dependencies[t.ID] {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			dependents[dep] = append(dependents[dep], t.ID)
+			pending[t.ID]++
+		}
+	}
+
+	return dependents, pending
+}
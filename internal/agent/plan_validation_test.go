@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTaskPlan_Validate_Valid(t *testing.T) {
+	plan := &TaskPlan{
+		Subtasks: []Subtask{
+			{ID: "task_1", WorkerType: "general"},
+			{ID: "task_2", WorkerType: "calculator"},
+			{ID: "task_3", WorkerType: "general"},
+		},
+		Dependencies: map[string][]string{
+			"task_2": {"task_1"},
+			"task_3": {"task_1", "task_2"},
+		},
+	}
+
+	if err := plan.Validate([]string{"general", "calculator"}); err != nil {
+		t.Errorf("expected valid plan, got %v", err)
+	}
+}
+
+func TestTaskPlan_Validate_Empty(t *testing.T) {
+	plan := &TaskPlan{}
+
+	if err := plan.Validate(nil); !errors.Is(err, ErrEmptyPlan) {
+		t.Errorf("expected ErrEmptyPlan, got %v", err)
+	}
+}
+
+func TestTaskPlan_Validate_Problems(t *testing.T) {
+	tests := []struct {
+		name  string
+		plan  TaskPlan
+		check func(t *testing.T, verr *PlanValidationError)
+	}{
+		{
+			name: "cycle",
+			plan: TaskPlan{
+				Subtasks: []Subtask{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+				Dependencies: map[string][]string{
+					"a": {"c"},
+					"b": {"a"},
+					"c": {"b"},
+				},
+			},
+			check: func(t *testing.T, verr *PlanValidationError) {
+				if len(verr.Cycles) != 1 || len(verr.Cycles[0]) != 4 {
+					t.Errorf("expected one 3-node cycle, got %v", verr.Cycles)
+				}
+			},
+		},
+		{
+			name: "self dependency",
+			plan: TaskPlan{
+				Subtasks:     []Subtask{{ID: "a"}},
+				Dependencies: map[string][]string{"a": {"a"}},
+			},
+			check: func(t *testing.T, verr *PlanValidationError) {
+				if len(verr.Cycles) != 1 {
+					t.Errorf("expected self-cycle, got %v", verr.Cycles)
+				}
+			},
+		},
+		{
+			name: "missing nodes",
+			plan: TaskPlan{
+				Subtasks: []Subtask{{ID: "a"}},
+				Dependencies: map[string][]string{
+					"a":     {"ghost"},
+					"other": {"a"},
+				},
+			},
+			check: func(t *testing.T, verr *PlanValidationError) {
+				if strings.Join(verr.MissingNodes, ",") != "ghost,other" {
+					t.Errorf("expected missing [ghost other], got %v", verr.MissingNodes)
+				}
+			},
+		},
+		{
+			name: "duplicate ids",
+			plan: TaskPlan{
+				Subtasks: []Subtask{{ID: "a"}, {ID: "a"}},
+			},
+			check: func(t *testing.T, verr *PlanValidationError) {
+				if len(verr.DuplicateIDs) != 1 || verr.DuplicateIDs[0] != "a" {
+					t.Errorf("expected duplicate a, got %v", verr.DuplicateIDs)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.plan.Validate(nil)
+
+			var verr *PlanValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected PlanValidationError, got %v", err)
+			}
+			tt.check(t, verr)
+		})
+	}
+}
+
+func TestTaskPlan_Validate_UnknownWorker(t *testing.T) {
+	plan := &TaskPlan{
+		Subtasks: []Subtask{{ID: "a", WorkerType: "poet"}},
+	}
+
+	err := plan.Validate([]string{"general"})
+
+	var verr *PlanValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected PlanValidationError, got %v", err)
+	}
+	if verr.UnknownWorkers["a"] != "poet" {
+		t.Errorf("expected unknown worker poet, got %v", verr.UnknownWorkers)
+	}
+	if !strings.Contains(err.Error(), `a="poet"`) {
+		t.Errorf("expected error message to name the worker, got %s", err.Error())
+	}
+
+	// Without a worker list, worker types are not checked
+	if err := plan.Validate(nil); err != nil {
+		t.Errorf("expected no error without worker list, got %v", err)
+	}
+}
+
+func TestTaskPlan_DependentsOf(t *testing.T) {
+	plan := &TaskPlan{
+		Subtasks: []Subtask{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		Dependencies: map[string][]string{
+			"b": {"a", "a"},
+			"c": {"a", "b"},
+		},
+	}
+
+	dependents, pending := plan.dependentsOf()
+
+	if pending["a"] != 0 || pending["b"] != 1 || pending["c"] != 2 {
+		t.Errorf("unexpected pending counts: %v", pending)
+	}
+	if len(dependents["a"]) != 2 {
+		t.Errorf("expected a to have 2 dependents, got %v", dependents["a"])
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/hassan123789/go-ai-agent/internal/llm"
 	"github.com/hassan123789/go-ai-agent/internal/tools"
@@ -18,13 +22,13 @@ import (
 // Reference: Yao et al., 2022 - "ReAct: Synergizing Reasoning and Acting in Language Models"
 // https://arxiv.org/abs/2210.03629
 type ReActAgent struct {
-	llm    *llm.OpenAIClient
+	llm    LLMClient
 	tools  *tools.Registry
 	config Config
 }
 
 // NewReActAgent creates a new ReAct agent with the given LLM client and tools.
-func NewReActAgent(llmClient *llm.OpenAIClient, toolRegistry *tools.Registry, config Config) *ReActAgent {
+func NewReActAgent(llmClient LLMClient, toolRegistry *tools.Registry, config Config) *ReActAgent {
 	if config.MaxIterations <= 0 {
 		config.MaxIterations = 10
 	}
@@ -33,7 +37,7 @@ func NewReActAgent(llmClient *llm.OpenAIClient, toolRegistry *tools.Registry, co
 	}
 
 	return &ReActAgent{
-		llm:    llmClient,
+		llm:    metered(llmClient),
 		tools:  toolRegistry,
 		config: config,
 	}
@@ -60,15 +64,43 @@ func (a *ReActAgent) Run(ctx context.Context, query string) (*Response, error) {
 }
 
 // RunWithHistory processes a query with conversation history.
+// The run is traced as an "invoke_agent react" span with one
+// "react.iteration" span per loop iteration.
 func (a *ReActAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "react")
+	resp, err := withMemory(ctx, a.config, history, query, a.run)
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (a *ReActAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx = guardrailContext(ctx, a.config.Guardrails)
+	query, err := a.config.Guardrails.CheckInput(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build initial messages
 	messages := a.buildMessages(history, query)
 
+	// Older and oversized observations are compressed as configured
+	compressor := newContextCompressor(a.config, a.llm, messages, query)
+
 	// Build tool definitions
-	toolDefs := a.buildToolDefinitions()
+	toolDefs := compressor.toolDefinitions(a.buildToolDefinitions())
+
+	// Repeated tool calls and oscillating answers are intervened in as configured
+	detector := newLoopDetector(a.config.LoopDetection)
 
 	var allSteps []Step
 	var totalUsage Usage
+	var lastContent string
+	budget := BudgetFrom(ctx)
+
+	// Each iteration's span ends when the next starts or the run returns
+	var iterSpan trace.Span
+	defer func() { endSpan(iterSpan, nil) }()
 
 	// ReAct loop
 	for i := 0; i < a.config.MaxIterations; i++ {
@@ -76,13 +108,31 @@ func (a *ReActAgent) RunWithHistory(ctx context.Context, history []Message, quer
 			log.Printf("[ReAct] Iteration %d/%d", i+1, a.config.MaxIterations)
 		}
 
+		if err := budget.Check(); err != nil {
+			return a.finalize(ctx, err, messages, lastContent, allSteps, totalUsage)
+		}
+
+		endSpan(iterSpan, nil)
+		var iterCtx context.Context
+		iterCtx, iterSpan = tracer().Start(ctx, "react.iteration", trace.WithAttributes(attrIteration.Int(i+1)))
+
+		var compressUsage Usage
+		var compressStep *Step
+		messages, compressUsage, compressStep = compressor.compress(iterCtx, messages)
+		totalUsage = addUsage(totalUsage, compressUsage)
+		if compressStep != nil {
+			allSteps = append(allSteps, *compressStep)
+		}
+
 		// Call LLM with tools
-		resp, err := a.llm.ChatWithTools(ctx, &llm.ChatWithToolsRequest{
-			Messages: a.toLLMMessages(messages),
-			Tools:    toolDefs,
+		resp, err := a.llm.ChatWithTools(iterCtx, &llm.ChatWithToolsRequest{
+			Messages:    a.toLLMMessages(messages),
+			Tools:       toolDefs,
+			Model:       a.config.Model,
+			Temperature: a.config.Temperature,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("LLM call failed: %w", err)
+			return nil, failed(fmt.Errorf("LLM call failed: %w", err), allSteps, totalUsage)
 		}
 
 		// Accumulate usage
@@ -92,8 +142,39 @@ func (a *ReActAgent) RunWithHistory(ctx context.Context, history []Message, quer
 
 		// Check if we have tool calls
 		if resp.HasToolCalls() {
+			var hints []string
+			if resp.Content != "" {
+				lastContent = resp.Content
+				if loop := detector.checkAnswer(resp.Content); loop != "" {
+					allSteps = append(allSteps, a.loopStep(detector, loop, ""))
+					if detector.stops() {
+						return a.finalize(ctx, fmt.Errorf("%w: %s", ErrLoopDetected, loop), messages, lastContent, allSteps, totalUsage)
+					}
+					hints = append(hints, fmt.Sprintf(loopHint, loop))
+				}
+			}
+
 			// Process each tool call
 			for _, toolCall := range resp.ToolCalls {
+				loop := detector.checkCall(toolCall)
+				if loop != "" {
+					allSteps = append(allSteps, a.loopStep(detector, loop, toolCall.Name))
+					if detector.stops() {
+						return a.finalize(ctx, fmt.Errorf("%w: %s", ErrLoopDetected, loop), messages, lastContent, allSteps, totalUsage)
+					}
+				}
+
+				// A repeated call may be answered from its earlier result
+				result, cached := detector.cached(toolCall, loop)
+				if !cached {
+					if err := budget.ReserveToolCall(); err != nil {
+						return a.finalize(ctx, err, messages, lastContent, allSteps, totalUsage)
+					}
+					if loop != "" {
+						hints = append(hints, fmt.Sprintf(loopHint, loop))
+					}
+				}
+
 				// Record action step
 				actionStep := Step{
 					Type:      StepTypeAction,
@@ -106,10 +187,27 @@ func (a *ReActAgent) RunWithHistory(ctx context.Context, history []Message, quer
 					log.Printf("[ReAct] Action: %s(%s)", toolCall.Name, toolCall.Arguments)
 				}
 
-				// Execute the tool
-				result, err := a.executeTool(ctx, toolCall)
-				if err != nil {
-					return nil, fmt.Errorf("tool execution failed: %w", err)
+				if !cached {
+					// Execute the tool; artifacts are read from the run itself
+					var toolResult tools.Result
+					if compressor.handles(toolCall.Name) {
+						toolResult = compressor.fetch(toolCall.Arguments)
+					} else if toolResult, err = a.executeTool(iterCtx, toolCall); err != nil {
+						return nil, failed(fmt.Errorf("tool execution failed: %w", err), allSteps, totalUsage)
+					}
+					result, err = guardObservation(iterCtx, a.config.Guardrails, toolCall.Name, toolResult.String())
+					if err != nil {
+						return nil, failed(err, allSteps, totalUsage)
+					}
+					detector.record(toolCall, result)
+
+					// Merge runs of nested agents called as tools
+					if usage, ok := toolResult.Metadata[metadataAgentUsage].(Usage); ok {
+						totalUsage = addUsage(totalUsage, usage)
+					}
+					if steps, ok := toolResult.Metadata[metadataAgentSteps].([]Step); ok {
+						allSteps = append(allSteps, steps...)
+					}
 				}
 
 				// Record observation step
@@ -131,10 +229,12 @@ func (a *ReActAgent) RunWithHistory(ctx context.Context, history []Message, quer
 				})
 
 				// Add tool result message
-				messages = append(messages, Message{
-					Role:    "tool",
-					Content: result,
-				})
+				messages = compressor.appendObservation(messages, toolCall.Name, result)
+			}
+
+			// Corrective hints follow the observations
+			if len(hints) > 0 {
+				messages = append(messages, Message{Role: "system", Content: strings.Join(hints, "\n")})
 			}
 		} else {
 			// No tool calls - we have the final answer
@@ -142,15 +242,105 @@ func (a *ReActAgent) RunWithHistory(ctx context.Context, history []Message, quer
 				log.Printf("[ReAct] Final answer: %s", resp.Content)
 			}
 
-			return &Response{
-				Output: resp.Content,
+			output, err := a.config.Guardrails.CheckOutput(ctx, resp.Content)
+			if err != nil {
+				return nil, failed(err, allSteps, totalUsage)
+			}
+
+			return withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+				Output: output,
 				Steps:  allSteps,
 				Usage:  totalUsage,
-			}, nil
+			})), nil
 		}
 	}
 
-	return nil, errors.New("max iterations exceeded without reaching a final answer")
+	endSpan(iterSpan, nil)
+	iterSpan = nil
+	return a.finalize(ctx, fmt.Errorf("%w (%d)", ErrMaxIterations, a.config.MaxIterations), messages, lastContent, allSteps, totalUsage)
+}
+
+// finalize ends a run that hit a limit. Unless the run is strict, one last
+// call without tools asks the model to answer from the observations so far;
+// the response is flagged incomplete with the reason. The call is skipped
+// once the token or cost limit is spent, and if it fails the answer is built
+// from the model's last reply or observations instead.
+func (a *ReActAgent) finalize(ctx context.Context, reason error, messages []Message, lastContent string, steps []Step, usage Usage) (*Response, error) {
+	if a.config.Verbose {
+		log.Printf("[ReAct] Stopping early: %v", reason)
+	}
+	if strictLimits(ctx) {
+		return nil, failed(reason, steps, usage)
+	}
+
+	answer := ""
+	if !BudgetFrom(ctx).spent() {
+		resp, err := a.llm.ChatWithTools(ctx, &llm.ChatWithToolsRequest{
+			Messages:    a.toLLMMessages(append(messages, Message{Role: "user", Content: fmt.Sprintf(finalizePrompt, reason)})),
+			Model:       a.config.Model,
+			Temperature: a.config.Temperature,
+		})
+		switch {
+		case err != nil:
+			if a.config.Verbose {
+				log.Printf("[ReAct] Final answer call failed: %v", err)
+			}
+		case !resp.HasToolCalls():
+			answer = resp.Content
+			usage = addUsage(usage, toUsage(resp.Usage))
+		default:
+			usage = addUsage(usage, toUsage(resp.Usage))
+		}
+	}
+	if strings.TrimSpace(answer) == "" {
+		answer = bestEffortAnswer(lastContent, steps)
+	}
+
+	output, err := a.config.Guardrails.CheckOutput(ctx, answer)
+	if err != nil {
+		return nil, failed(err, steps, usage)
+	}
+
+	return withIncomplete(withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+		Output: output,
+		Steps:  steps,
+		Usage:  usage,
+	})), reason), nil
+}
+
+// runFailure is an error that ended a ReAct run, carrying the steps taken
+// and usage spent before it.
+type runFailure struct {
+	err   error
+	steps []Step
+	usage Usage
+}
+
+func (f *runFailure) Error() string { return f.err.Error() }
+
+func (f *runFailure) Unwrap() error { return f.err }
+
+// failed wraps err with the run's progress.
+func failed(err error, steps []Step, usage Usage) error {
+	return &runFailure{err: err, steps: steps, usage: usage}
+}
+
+// progressOf returns the steps and usage of a run that failed with err, or
+// nothing if err does not carry them.
+func progressOf(err error) ([]Step, Usage) {
+	var f *runFailure
+	if errors.As(err, &f) {
+		return f.steps, f.usage
+	}
+	return nil, Usage{}
+}
+
+// loopStep records a loop intervention as a step.
+func (a *ReActAgent) loopStep(detector *loopDetector, loop, toolName string) Step {
+	if a.config.Verbose {
+		log.Printf("[ReAct] Loop detected: %s", loop)
+	}
+	return detector.step(loop, toolName)
 }
 
 // buildMessages constructs the initial message list.
@@ -175,9 +365,11 @@ func (a *ReActAgent) buildMessages(history []Message, query string) []Message {
 	return messages
 }
 
-// buildToolDefinitions converts the tool registry to LLM tool definitions.
+// buildToolDefinitions converts the tool registry to LLM tool definitions,
+// sorted by name so that identical runs send identical requests.
 func (a *ReActAgent) buildToolDefinitions() []llm.ToolDefinition {
 	toolList := a.tools.List()
+	sort.Slice(toolList, func(i, j int) bool { return toolList[i].Name() < toolList[j].Name() })
 	defs := make([]llm.ToolDefinition, len(toolList))
 
 	for i, tool := range toolList {
@@ -212,18 +404,17 @@ func (a *ReActAgent) toLLMMessages(messages []Message) []llm.Message {
 }
 
 // executeTool executes a tool call and returns the result.
-func (a *ReActAgent) executeTool(ctx context.Context, toolCall llm.ToolCall) (string, error) {
-	tool := a.tools.Get(toolCall.Name)
-	if tool == nil {
-		return "", fmt.Errorf("tool %q not found", toolCall.Name)
+func (a *ReActAgent) executeTool(ctx context.Context, toolCall llm.ToolCall) (tools.Result, error) {
+	if !a.tools.Has(toolCall.Name) {
+		return tools.Result{}, fmt.Errorf("tool %q not found", toolCall.Name)
 	}
 
-	result, err := tool.Execute(ctx, toolCall.Arguments)
+	result, err := executeTool(ctx, a.tools, toolCall.Name, toolCall.Arguments)
 	if err != nil {
-		return "", fmt.Errorf("tool %q execution error: %w", toolCall.Name, err)
+		return tools.Result{}, fmt.Errorf("tool %q execution error: %w", toolCall.Name, err)
 	}
 
-	return result.String(), nil
+	return result, nil
 }
 
 // formatToolCallMessage formats a tool call for the message history.
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hassan123789/go-ai-agent/internal/memory"
+)
+
+// MemorySource supplies context recalled for a query and keeps the
+// exchanges of finished runs. *memory.HierarchicalMemory implements it.
+type MemorySource interface {
+	// Recall returns up to limit messages relevant to the query.
+	Recall(ctx context.Context, query string, limit int) ([]memory.Message, error)
+
+	// Add stores a message.
+	Add(ctx context.Context, msg memory.Message) error
+}
+
+const (
+	// defaultMemoryTokens bounds the recalled context when Config.MemoryTokens is unset.
+	defaultMemoryTokens = 1000
+
+	// memoryRecallLimit is the number of messages requested from the memory source.
+	memoryRecallLimit = 20
+)
+
+// memoryActiveKey marks a context whose run already recalls memory, so that
+// nested agents sharing the configuration neither recall nor store twice.
+type memoryActiveKey struct{}
+
+// withMemory runs run with context recalled from config.Memory for the query.
+// The recalled messages are injected ahead of the history as one system
+// message, shown as "memory" steps, and the query and answer are stored
+// afterwards. Failing to recall or store is logged and does not fail the run.
+func withMemory(ctx context.Context, config Config, history []Message, query string,
+	run func(context.Context, []Message, string) (*Response, error)) (*Response, error) {
+	if config.Memory == nil || ctx.Value(memoryActiveKey{}) != nil {
+		return run(ctx, history, query)
+	}
+	ctx = context.WithValue(ctx, memoryActiveKey{}, true)
+
+	recalled, err := config.Memory.Recall(ctx, query, memoryRecallLimit)
+	if err != nil {
+		log.Printf("[Memory] Recall failed: %v", err)
+	}
+	recalled = selectRecalled(recalled, history, config.MemoryTokens)
+	trace.SpanFromContext(ctx).SetAttributes(attrMemoryRecalled.Int(len(recalled)))
+
+	if len(recalled) > 0 {
+		if config.Verbose {
+			log.Printf("[Memory] Recalled %d items for: %s", len(recalled), truncate(query, 50))
+		}
+		block := Message{Role: "system", Content: formatRecalled(recalled)}
+		history = append([]Message{block}, history...)
+	}
+
+	resp, err := run(ctx, history, query)
+	if err != nil {
+		return resp, err
+	}
+
+	steps := make([]Step, 0, len(recalled)+len(resp.Steps))
+	for _, m := range recalled {
+		steps = append(steps, Step{Type: "memory", Content: fmt.Sprintf("[%s] %s", m.Role, m.Content)})
+	}
+	resp.Steps = append(steps, resp.Steps...)
+
+	for _, m := range []memory.Message{
+		memory.NewMessage(memory.RoleUser, query),
+		memory.NewMessage(memory.RoleAssistant, resp.Output),
+	} {
+		if err := config.Memory.Add(ctx, m); err != nil {
+			log.Printf("[Memory] Failed to store exchange: %v", err)
+			break
+		}
+	}
+	return resp, nil
+}
+
+// selectRecalled drops empty and duplicate messages, and those already in
+// the history, and keeps the rest in order until the token budget is spent.
+// Tokens are estimated at four characters each.
+func selectRecalled(recalled []memory.Message, history []Message, maxTokens int) []memory.Message {
+	if maxTokens <= 0 {
+		maxTokens = defaultMemoryTokens
+	}
+	seen := make(map[string]bool, len(history))
+	for _, m := range history {
+		seen[m.Content] = true
+	}
+
+	var selected []memory.Message
+	remaining := maxTokens * 4
+	for _, m := range recalled {
+		content := strings.TrimSpace(m.Content)
+		if content == "" || seen[content] {
+			continue
+		}
+		if len(content) > remaining {
+			break
+		}
+		seen[content] = true
+		remaining -= len(content)
+		m.Content = content
+		selected = append(selected, m)
+	}
+	return selected
+}
+
+// formatRecalled renders recalled messages as a system context block.
+func formatRecalled(recalled []memory.Message) string {
+	var sb strings.Builder
+	sb.WriteString("Relevant context from memory of earlier conversations. Use it if it helps answer the question; it may be out of date.\n")
+	for _, m := range recalled {
+		sb.WriteString(fmt.Sprintf("- [%s] %s\n", m.Role, m.Content))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
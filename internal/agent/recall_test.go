@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+func TestWithMemory_ReActRecallsAndStores(t *testing.T) {
+	mem := memory.NewHierarchicalMemory(nil, memory.HierarchicalConfig{})
+	var lastRequest *llm.ChatWithToolsRequest
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			lastRequest = req
+			return &llm.ChatWithToolsResponse{Content: "Nice to meet you, Ada."}, nil
+		},
+	}
+	a := NewReActAgent(mock, tools.NewRegistry(), Config{Memory: mem})
+
+	if _, err := a.Run(context.Background(), "My name is Ada"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := mem.Count(context.Background()); n != 2 {
+		t.Fatalf("expected the query and answer to be stored, got %d messages", n)
+	}
+
+	resp, err := a.Run(context.Background(), "What is my name?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The recalled exchange is injected after the agent's system prompt
+	block := lastRequest.Messages[1]
+	if block.Role != llm.RoleSystem || !strings.Contains(block.Content, "[user] My name is Ada") {
+		t.Errorf("expected a memory context block, got %+v", block)
+	}
+	if len(resp.Steps) != 2 || resp.Steps[0].Type != "memory" || resp.Steps[1].Content != "[assistant] Nice to meet you, Ada." {
+		t.Errorf("expected recalled items as memory steps, got %+v", resp.Steps)
+	}
+}
+
+func TestWithMemory_NestedAgentsStoreOnce(t *testing.T) {
+	mem := memory.NewHierarchicalMemory(nil, memory.HierarchicalConfig{})
+	mock := &MockLLMClient{
+		ChatFunc: func(context.Context, *llm.ChatRequest) (*llm.ChatResponse, error) {
+			return &llm.ChatResponse{Content: `{"score": 9, "reasoning": "good"}`}, nil
+		},
+	}
+
+	// Reflexion runs a ReAct agent built from the same Config
+	a := NewReflexionAgent(mock, tools.NewRegistry(), ReflexionConfig{Config: Config{Memory: mem}})
+	if _, err := a.Run(context.Background(), "q"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := mem.Count(context.Background()); n != 2 {
+		t.Errorf("expected one stored exchange, got %d messages", n)
+	}
+}
+
+func TestSelectRecalled(t *testing.T) {
+	recalled := []memory.Message{
+		{Role: "user", Content: "already in history"},
+		{Role: "user", Content: "  "},
+		{Role: "user", Content: strings.Repeat("a", 20)},
+		{Role: "assistant", Content: strings.Repeat("a", 20)},
+		{Role: "assistant", Content: strings.Repeat("b", 20)},
+		{Role: "system", Content: "short"},
+	}
+	history := []Message{{Role: "user", Content: "already in history"}}
+
+	// A 10 token budget fits 40 characters: two messages, then stops
+	selected := selectRecalled(recalled, history, 10)
+	if len(selected) != 2 || selected[0].Content != strings.Repeat("a", 20) || selected[1].Content != strings.Repeat("b", 20) {
+		t.Errorf("unexpected selection %+v", selected)
+	}
+}
@@ -0,0 +1,513 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/embedding"
+	"github.com/hassan123789/go-ai-agent/internal/vectorstore"
+)
+
+// ErrReflectionNotFound is returned when a reflection ID is not in scope.
+var ErrReflectionNotFound = errors.New("reflection not found")
+
+// ReflectionScope identifies whose reflections are stored and retrieved.
+// Reflections are always partitioned by UserID. An empty SessionID reads
+// across all of the user's sessions; a non-empty one restricts to that session.
+type ReflectionScope struct {
+	UserID    string `json:"user_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// matches reports whether a reflection stored under s is visible to scope.
+func (s ReflectionScope) matches(scope ReflectionScope) bool {
+	if s.UserID != scope.UserID {
+		return false
+	}
+	return scope.SessionID == "" || s.SessionID == scope.SessionID
+}
+
+// reflectionScopeKey is the context key holding the ReflectionScope.
+type reflectionScopeKey struct{}
+
+// WithReflectionScope returns a context whose Reflexion runs read and write
+// reflections in scope.
+func WithReflectionScope(ctx context.Context, scope ReflectionScope) context.Context {
+	return context.WithValue(ctx, reflectionScopeKey{}, scope)
+}
+
+// reflectionScopeFrom returns the scope set by WithReflectionScope.
+func reflectionScopeFrom(ctx context.Context) ReflectionScope {
+	scope, _ := ctx.Value(reflectionScopeKey{}).(ReflectionScope)
+	return scope
+}
+
+// ReflectionStore persists reflections and retrieves those relevant to a query.
+// Implementations must be safe for concurrent use.
+type ReflectionStore interface {
+	// Add stores a reflection, replacing a near-duplicate in the same scope.
+	// It returns the stored reflection with its ID and timestamp set.
+	Add(ctx context.Context, scope ReflectionScope, r Reflection) (Reflection, error)
+
+	// Search returns up to limit reflections relevant to query,
+	// ranked by similarity and recency.
+	Search(ctx context.Context, scope ReflectionScope, query string, limit int) ([]Reflection, error)
+
+	// List returns all reflections in scope, newest first.
+	List(ctx context.Context, scope ReflectionScope) ([]Reflection, error)
+
+	// Delete removes a reflection by ID.
+	Delete(ctx context.Context, scope ReflectionScope, id string) error
+
+	// Clear removes all reflections in scope.
+	Clear(ctx context.Context, scope ReflectionScope) error
+}
+
+// ReflectionMemory is the default ReflectionStore. Similarity retrieval uses
+// a vectorstore.SemanticStore when configured and word overlap otherwise.
+// With a Path set, reflections survive restarts.
+type ReflectionMemory struct {
+	config  ReflectionMemoryConfig
+	records map[string]*reflectionRecord
+	seq     int
+	mu      sync.Mutex
+}
+
+// ReflectionMemoryConfig contains configuration for ReflectionMemory.
+type ReflectionMemoryConfig struct {
+	// Semantic is used for similarity retrieval. If nil, word overlap is used.
+	Semantic vectorstore.SemanticStore
+
+	// Path is a JSON file reflections are persisted to. Empty keeps them in memory.
+	Path string
+
+	// MaxPerScope caps reflections per user and session; the oldest are evicted.
+	// Default is 100.
+	MaxPerScope int
+
+	// DedupThreshold is the similarity (0-1) above which a new reflection
+	// replaces an existing one. Default is 0.92.
+	DedupThreshold float32
+
+	// RecencyWeight is the share (0-1) of the ranking score given to recency.
+	// Default is 0.3.
+	RecencyWeight float64
+
+	// RecencyHalfLife is the age at which recency counts half. Default is 7 days.
+	RecencyHalfLife time.Duration
+}
+
+// reflectionRecord is a stored reflection with its scope.
+type reflectionRecord struct {
+	Reflection Reflection       `json:"reflection"`
+	Scope      ReflectionScope  `json:"scope"`
+	Embedding  embedding.Vector `json:"embedding,omitempty"`
+}
+
+// maxStoredResponse bounds the response text kept with each reflection.
+const maxStoredResponse = 2000
+
+// NewReflectionMemory creates a reflection store, loading persisted
+// reflections from config.Path if it exists.
+func NewReflectionMemory(ctx context.Context, config ReflectionMemoryConfig) (*ReflectionMemory, error) {
+	if config.MaxPerScope <= 0 {
+		config.MaxPerScope = 100
+	}
+	if config.DedupThreshold <= 0 {
+		config.DedupThreshold = 0.92
+	}
+	if config.RecencyWeight <= 0 {
+		config.RecencyWeight = 0.3
+	}
+	if config.RecencyHalfLife <= 0 {
+		config.RecencyHalfLife = 7 * 24 * time.Hour
+	}
+
+	m := &ReflectionMemory{
+		config:  config,
+		records: make(map[string]*reflectionRecord),
+	}
+	if err := m.load(ctx); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Add stores a reflection, replacing a near-duplicate in the same scope and
+// evicting the oldest reflections beyond MaxPerScope.
+func (m *ReflectionMemory) Add(ctx context.Context, scope ReflectionScope, r Reflection) (Reflection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.ID = fmt.Sprintf("ref_%d_%d", r.CreatedAt.UnixNano(), m.seq)
+	r.Response = truncate(r.Response, maxStoredResponse)
+
+	// Replace the closest near-duplicate from the same user and session
+	similar, err := m.similar(ctx, scope, reflectionText(r))
+	if err != nil {
+		return Reflection{}, err
+	}
+	var removed []string
+	var best *scoredRecord
+	for i := range similar {
+		if similar[i].record.Scope == scope && (best == nil || similar[i].similarity > best.similarity) {
+			best = &similar[i]
+		}
+	}
+	if best != nil && best.similarity >= m.config.DedupThreshold {
+		removed = append(removed, best.record.Reflection.ID)
+	}
+
+	rec := &reflectionRecord{Reflection: r, Scope: scope}
+	if m.config.Semantic != nil {
+		docs := []embedding.Document{m.document(rec)}
+		if err := m.config.Semantic.AddTexts(ctx, docs); err != nil {
+			return Reflection{}, fmt.Errorf("failed to index reflection: %w", err)
+		}
+		rec.Embedding = docs[0].Embedding
+	}
+	m.records[r.ID] = rec
+
+	// Evict the oldest beyond the per-scope limit
+	kept := 0
+	for _, other := range m.inScope(scope) {
+		if other.Scope != scope || slices.Contains(removed, other.Reflection.ID) {
+			continue
+		}
+		kept++
+		if kept > m.config.MaxPerScope {
+			removed = append(removed, other.Reflection.ID)
+		}
+	}
+
+	if err := m.remove(ctx, removed); err != nil {
+		return Reflection{}, err
+	}
+	return r, m.save()
+}
+
+// Search returns up to limit reflections relevant to query.
+func (m *ReflectionMemory) Search(ctx context.Context, scope ReflectionScope, query string, limit int) ([]Reflection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ranked, err := m.rank(ctx, scope, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	reflections := make([]Reflection, len(ranked))
+	for i, r := range ranked {
+		reflections[i] = r.record.Reflection
+	}
+	return reflections, nil
+}
+
+// List returns all reflections in scope, newest first.
+func (m *ReflectionMemory) List(_ context.Context, scope ReflectionScope) ([]Reflection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := m.inScope(scope)
+	reflections := make([]Reflection, len(records))
+	for i, rec := range records {
+		reflections[i] = rec.Reflection
+	}
+	return reflections, nil
+}
+
+// Delete removes a reflection by ID.
+func (m *ReflectionMemory) Delete(ctx context.Context, scope ReflectionScope, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.records[id]
+	if !ok || !rec.Scope.matches(scope) {
+		return ErrReflectionNotFound
+	}
+	if err := m.remove(ctx, []string{id}); err != nil {
+		return err
+	}
+	return m.save()
+}
+
+// Clear removes all reflections in scope.
+func (m *ReflectionMemory) Clear(ctx context.Context, scope ReflectionScope) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []string
+	for _, rec := range m.inScope(scope) {
+		ids = append(ids, rec.Reflection.ID)
+	}
+	if err := m.remove(ctx, ids); err != nil {
+		return err
+	}
+	return m.save()
+}
+
+// scoredRecord is a record with its similarity to a query.
+type scoredRecord struct {
+	record     *reflectionRecord
+	similarity float32
+	score      float64
+}
+
+// rank scores reflections in scope by similarity blended with recency.
+// Callers must hold m.mu.
+func (m *ReflectionMemory) rank(ctx context.Context, scope ReflectionScope, query string, limit int) ([]scoredRecord, error) {
+	if limit <= 0 {
+		limit = 3
+	}
+
+	candidates, err := m.similar(ctx, scope, query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range candidates {
+		age := now.Sub(candidates[i].record.Reflection.CreatedAt)
+		recency := math.Exp2(-float64(age) / float64(m.config.RecencyHalfLife))
+		candidates[i].score = (1-m.config.RecencyWeight)*float64(candidates[i].similarity) +
+			m.config.RecencyWeight*recency
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// similar returns the reflections in scope with their similarity to query.
+// Callers must hold m.mu.
+func (m *ReflectionMemory) similar(ctx context.Context, scope ReflectionScope, query string) ([]scoredRecord, error) {
+	inScope := m.inScope(scope)
+	if len(inScope) == 0 {
+		return nil, nil
+	}
+
+	if m.config.Semantic == nil {
+		queryWords := wordSet(query)
+		candidates := make([]scoredRecord, 0, len(inScope))
+		for _, rec := range inScope {
+			candidates = append(candidates, scoredRecord{
+				record:     rec,
+				similarity: jaccard(queryWords, wordSet(reflectionText(rec.Reflection))),
+			})
+		}
+		return candidates, nil
+	}
+
+	// Search the whole scope so that recency can promote weaker matches
+	var results []vectorstore.SearchResult
+	var err error
+	filter := func(doc embedding.Document) bool {
+		rec, ok := m.records[doc.ID]
+		return ok && rec.Scope.matches(scope)
+	}
+	if filtered, ok := m.config.Semantic.(vectorstore.FilteredVectorStore); ok {
+		var vector embedding.Vector
+		vector, err = m.config.Semantic.Embedder().Embed(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed query: %w", err)
+		}
+		results, err = filtered.SearchWithFilter(ctx, vector, len(inScope), filter)
+	} else {
+		var count int
+		count, err = m.config.Semantic.Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results, err = m.config.Semantic.SearchText(ctx, query, count)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reflection search failed: %w", err)
+	}
+
+	candidates := make([]scoredRecord, 0, len(results))
+	for _, r := range results {
+		if filter(r.Document) {
+			candidates = append(candidates, scoredRecord{record: m.records[r.Document.ID], similarity: r.Score})
+		}
+	}
+	return candidates, nil
+}
+
+// inScope returns the records visible to scope, newest first.
+// Callers must hold m.mu.
+func (m *ReflectionMemory) inScope(scope ReflectionScope) []*reflectionRecord {
+	var records []*reflectionRecord
+	for _, rec := range m.records {
+		if rec.Scope.matches(scope) {
+			records = append(records, rec)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Reflection.CreatedAt.After(records[j].Reflection.CreatedAt)
+	})
+	return records
+}
+
+// remove deletes records from the index and the semantic store.
+// Callers must hold m.mu.
+func (m *ReflectionMemory) remove(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, id := range ids {
+		delete(m.records, id)
+	}
+	if m.config.Semantic != nil {
+		if err := m.config.Semantic.Delete(ctx, ids); err != nil {
+			return fmt.Errorf("failed to delete reflections: %w", err)
+		}
+	}
+	return nil
+}
+
+// document converts a record to a vector store document.
+func (m *ReflectionMemory) document(rec *reflectionRecord) embedding.Document {
+	return embedding.NewDocumentWithMetadata(rec.Reflection.ID, reflectionText(rec.Reflection), map[string]any{
+		"user_id":    rec.Scope.UserID,
+		"session_id": rec.Scope.SessionID,
+	})
+}
+
+// load restores persisted reflections from Path.
+func (m *ReflectionMemory) load(ctx context.Context) error {
+	if m.config.Path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.config.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read reflections: %w", err)
+	}
+
+	var records []*reflectionRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to parse reflections: %w", err)
+	}
+
+	var embedded, unembedded []embedding.Document
+	for _, rec := range records {
+		m.records[rec.Reflection.ID] = rec
+		doc := m.document(rec)
+		doc.Embedding = rec.Embedding
+		if len(doc.Embedding) > 0 {
+			embedded = append(embedded, doc)
+		} else {
+			unembedded = append(unembedded, doc)
+		}
+	}
+
+	if m.config.Semantic == nil {
+		return nil
+	}
+	if len(embedded) > 0 {
+		if err := m.config.Semantic.Add(ctx, embedded); err != nil {
+			return fmt.Errorf("failed to index reflections: %w", err)
+		}
+	}
+	if len(unembedded) > 0 {
+		if err := m.config.Semantic.AddTexts(ctx, unembedded); err != nil {
+			return fmt.Errorf("failed to index reflections: %w", err)
+		}
+		for _, doc := range unembedded {
+			m.records[doc.ID].Embedding = doc.Embedding
+		}
+	}
+	return nil
+}
+
+// save writes all reflections to Path atomically. Callers must hold m.mu.
+func (m *ReflectionMemory) save() error {
+	if m.config.Path == "" {
+		return nil
+	}
+
+	records := make([]*reflectionRecord, 0, len(m.records))
+	for _, rec := range m.records {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Reflection.CreatedAt.Before(records[j].Reflection.CreatedAt)
+	})
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to encode reflections: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.config.Path), ".reflections-*")
+	if err != nil {
+		return fmt.Errorf("failed to save reflections: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save reflections: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save reflections: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.config.Path); err != nil {
+		return fmt.Errorf("failed to save reflections: %w", err)
+	}
+	return nil
+}
+
+// reflectionText is the text a reflection is matched on.
+func reflectionText(r Reflection) string {
+	return r.Query + "\n" + r.Feedback
+}
+
+// wordSet returns the lowercased words in s.
+func wordSet(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r > 127)
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// jaccard returns the Jaccard similarity of two word sets.
+func jaccard(a, b map[string]bool) float32 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float32(shared) / float32(len(a)+len(b)-shared)
+}
+
+// Ensure ReflectionMemory implements ReflectionStore.
+var _ ReflectionStore = (*ReflectionMemory)(nil)
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/embedding"
+	"github.com/hassan123789/go-ai-agent/internal/vectorstore"
+)
+
+// bagOfWordsEmbedder hashes words into a small vector so that texts sharing
+// words are similar.
+type bagOfWordsEmbedder struct {
+	calls int
+}
+
+func (e *bagOfWordsEmbedder) Embed(_ context.Context, text string) (embedding.Vector, error) {
+	e.calls++
+	v := make(embedding.Vector, 32)
+	for w := range wordSet(text) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(w))
+		v[h.Sum32()%32]++
+	}
+	return v, nil
+}
+
+func (e *bagOfWordsEmbedder) EmbedBatch(ctx context.Context, texts []string) ([]embedding.Vector, error) {
+	vectors := make([]embedding.Vector, len(texts))
+	for i, t := range texts {
+		vectors[i], _ = e.Embed(ctx, t)
+	}
+	return vectors, nil
+}
+
+func (e *bagOfWordsEmbedder) Dimension() int { return 32 }
+func (e *bagOfWordsEmbedder) Model() string  { return "bag-of-words" }
+
+func newTestReflectionMemory(t *testing.T, config ReflectionMemoryConfig) *ReflectionMemory {
+	t.Helper()
+	m, err := NewReflectionMemory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestReflectionMemory_Scoping(t *testing.T) {
+	ctx := context.Background()
+	m := newTestReflectionMemory(t, ReflectionMemoryConfig{})
+
+	alice1 := ReflectionScope{UserID: "alice", SessionID: "s1"}
+	alice2 := ReflectionScope{UserID: "alice", SessionID: "s2"}
+	bob := ReflectionScope{UserID: "bob"}
+
+	_, _ = m.Add(ctx, alice1, Reflection{Query: "tax rates", Feedback: "cite the year"})
+	_, _ = m.Add(ctx, alice2, Reflection{Query: "tax deductions", Feedback: "list examples"})
+	_, _ = m.Add(ctx, bob, Reflection{Query: "tax rates", Feedback: "secret"})
+
+	all, _ := m.List(ctx, ReflectionScope{UserID: "alice"})
+	if len(all) != 2 {
+		t.Errorf("expected both of alice's sessions, got %d", len(all))
+	}
+	session, _ := m.List(ctx, alice1)
+	if len(session) != 1 || session[0].Feedback != "cite the year" {
+		t.Errorf("expected only session s1, got %+v", session)
+	}
+
+	found, _ := m.Search(ctx, ReflectionScope{UserID: "alice"}, "tax rates", 5)
+	for _, r := range found {
+		if r.Feedback == "secret" {
+			t.Error("expected bob's reflections to be hidden from alice")
+		}
+	}
+
+	if err := m.Delete(ctx, bob, session[0].ID); !errors.Is(err, ErrReflectionNotFound) {
+		t.Errorf("expected not found deleting another user's reflection, got %v", err)
+	}
+
+	if err := m.Clear(ctx, ReflectionScope{UserID: "alice"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if left, _ := m.List(ctx, bob); len(left) != 1 {
+		t.Errorf("expected clear to leave bob's reflections, got %d", len(left))
+	}
+}
+
+func TestReflectionMemory_DedupAndLimit(t *testing.T) {
+	ctx := context.Background()
+	m := newTestReflectionMemory(t, ReflectionMemoryConfig{MaxPerScope: 2})
+	scope := ReflectionScope{UserID: "u"}
+
+	_, _ = m.Add(ctx, scope, Reflection{Query: "convert units", Feedback: "show the formula"})
+	_, _ = m.Add(ctx, scope, Reflection{Query: "convert units", Feedback: "show the formula"})
+	if got, _ := m.List(ctx, scope); len(got) != 1 {
+		t.Errorf("expected duplicate to replace the original, got %d", len(got))
+	}
+
+	_, _ = m.Add(ctx, scope, Reflection{Query: "summarize article", Feedback: "keep it short"})
+	_, _ = m.Add(ctx, scope, Reflection{Query: "write poem", Feedback: "use rhyme"})
+
+	got, _ := m.List(ctx, scope)
+	if len(got) != 2 {
+		t.Fatalf("expected limit of 2, got %d", len(got))
+	}
+	if got[0].Query != "write poem" || got[1].Query != "summarize article" {
+		t.Errorf("expected oldest to be evicted, got %q and %q", got[0].Query, got[1].Query)
+	}
+}
+
+func TestReflectionMemory_RecencyRanking(t *testing.T) {
+	ctx := context.Background()
+	m := newTestReflectionMemory(t, ReflectionMemoryConfig{RecencyWeight: 0.5, RecencyHalfLife: time.Hour})
+	scope := ReflectionScope{}
+
+	_, _ = m.Add(ctx, scope, Reflection{Query: "plan a trip", Feedback: "old advice", CreatedAt: time.Now().Add(-48 * time.Hour)})
+	_, _ = m.Add(ctx, scope, Reflection{Query: "plan a trip", Feedback: "new advice"})
+
+	found, _ := m.Search(ctx, scope, "plan a trip", 1)
+	if len(found) != 1 || found[0].Feedback != "new advice" {
+		t.Errorf("expected recent reflection first, got %+v", found)
+	}
+}
+
+func TestReflectionMemory_SemanticPersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "reflections.json")
+	embedder := &bagOfWordsEmbedder{}
+
+	m := newTestReflectionMemory(t, ReflectionMemoryConfig{
+		Semantic: vectorstore.NewMemoryStore(embedder),
+		Path:     path,
+	})
+	scope := ReflectionScope{UserID: "u"}
+	_, _ = m.Add(ctx, scope, Reflection{Query: "compound interest formula", Feedback: "state the rate period"})
+	_, _ = m.Add(ctx, scope, Reflection{Query: "french revolution dates", Feedback: "give exact years"})
+
+	// Reload from disk without re-embedding stored reflections
+	calls := embedder.calls
+	reloaded := newTestReflectionMemory(t, ReflectionMemoryConfig{
+		Semantic: vectorstore.NewMemoryStore(embedder),
+		Path:     path,
+	})
+	if embedder.calls != calls {
+		t.Errorf("expected persisted embeddings to be reused, got %d new embed calls", embedder.calls-calls)
+	}
+
+	found, err := reloaded.Search(ctx, scope, "how does compound interest work", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 1 || !strings.Contains(found[0].Query, "compound") {
+		t.Errorf("expected compound interest reflection, got %+v", found)
+	}
+}
+
+func TestReflexionAgent_RecallsScopedReflections(t *testing.T) {
+	ctx := context.Background()
+	store := newTestReflectionMemory(t, ReflectionMemoryConfig{})
+	scope := ReflectionScope{UserID: "u"}
+	_, _ = store.Add(ctx, scope, Reflection{Query: "capital of australia", Feedback: "it is Canberra, not Sydney"})
+
+	a := NewReflexionAgent(&MockLLMClient{}, nil, ReflexionConfig{Memory: store})
+
+	recalled := a.buildReflectionContext(ctx, scope, nil, "what is the capital of australia?")
+	if len(recalled) != 1 || !strings.Contains(recalled[0].Content, "Canberra") {
+		t.Errorf("expected recalled reflection in context, got %+v", recalled)
+	}
+	if other := a.buildReflectionContext(ctx, ReflectionScope{UserID: "v"}, nil, "capital of australia"); len(other) != 0 {
+		t.Errorf("expected no reflections for another user, got %+v", other)
+	}
+}
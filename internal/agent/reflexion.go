@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
 
 	"github.com/hassan123789/go-ai-agent/internal/llm"
 	"github.com/hassan123789/go-ai-agent/internal/tools"
@@ -21,13 +24,12 @@ import (
 //  1. Execute task using ReAct pattern
 //  2. Evaluate the result with a critic
 //  3. If unsatisfactory, reflect and retry with feedback
-//  4. Store successful strategies in episodic memory
+//  4. Store reflections in episodic memory for later runs
 type ReflexionAgent struct {
-	llm             *llm.OpenAIClient
-	tools           *tools.Registry
-	config          ReflexionConfig
-	episodicMemory  []Reflection
-	maxReflections  int
+	llm            LLMClient
+	tools          *tools.Registry
+	config         ReflexionConfig
+	maxReflections int
 }
 
 // ReflexionConfig contains configuration for the Reflexion agent.
@@ -37,18 +39,49 @@ type ReflexionConfig struct {
 	// MaxReflections is the maximum number of self-reflection attempts.
 	MaxReflections int
 
-	// EvaluationPrompt is the prompt used for self-evaluation.
+	// EvaluationPrompt is the prompt used by the default LLM judge.
 	EvaluationPrompt string
 
+	// Evaluator gates attempts on quality. Default is an LLMJudge using
+	// EvaluationPrompt. WithEvalOptions overrides it per run.
+	Evaluator Evaluator
+
+	// Evaluators are named evaluators that callers can select per request.
+	Evaluators map[string]Evaluator
+
 	// ReflectionPrompt is the prompt used for generating reflections.
 	ReflectionPrompt string
 
 	// QualityThreshold is the minimum quality score (0-10) to accept.
 	QualityThreshold float64
+
+	// Memory stores reflections across runs, scoped by WithReflectionScope.
+	// Default is an in-memory ReflectionMemory.
+	Memory ReflectionStore
+
+	// MaxRecalled is the number of past reflections added to each attempt.
+	// Default is 3.
+	MaxRecalled int
+}
+
+// ReflexionTrace records how a reflexion run reached its answer.
+type ReflexionTrace struct {
+	// Attempts is the number of attempts made.
+	Attempts int `json:"attempts"`
+
+	// Reflections holds one entry per evaluated attempt, in order.
+	Reflections []Reflection `json:"reflections"`
+
+	// FinalEvaluation is the evaluation of the returned response, or nil if
+	// it was not evaluated.
+	FinalEvaluation *Evaluation `json:"final_evaluation,omitempty"`
 }
 
 // Reflection represents a single reflection episode.
 type Reflection struct {
+	// ID identifies a stored reflection. Set by the ReflectionStore.
+	ID string `json:"id,omitempty"`
+
 	// Query is the original user query.
 	Query string `json:"query"`
 
@@ -63,6 +96,9 @@ type Reflection struct {
 
 	// Feedback is the self-generated feedback for improvement.
 	Feedback string `json:"feedback"`
+
+	// CreatedAt is when the reflection was stored.
+	CreatedAt time.Time `json:"created_at,omitempty"`
 }
 
 // Evaluation represents the result of self-evaluation.
@@ -81,7 +117,8 @@ type Evaluation struct {
 }
 
 // NewReflexionAgent creates a new Reflexion agent.
-func NewReflexionAgent(llmClient *llm.OpenAIClient, toolRegistry *tools.Registry, config ReflexionConfig) *ReflexionAgent {
+func NewReflexionAgent(llmClient LLMClient, toolRegistry *tools.Registry, config ReflexionConfig) *ReflexionAgent {
+	llmClient = metered(llmClient)
 	if config.MaxIterations <= 0 {
 		config.MaxIterations = 10
 	}
@@ -100,12 +137,21 @@ func NewReflexionAgent(llmClient *llm.OpenAIClient, toolRegistry *tools.Registry
 	if config.ReflectionPrompt == "" {
 		config.ReflectionPrompt = defaultReflectionPrompt
 	}
+	if config.Evaluator == nil {
+		config.Evaluator = NewLLMJudge(llmClient, LLMJudgeConfig{Prompt: config.EvaluationPrompt})
+	}
+	if config.Memory == nil {
+		// Without a Path, creating the store cannot fail
+		config.Memory, _ = NewReflectionMemory(context.Background(), ReflectionMemoryConfig{})
+	}
+	if config.MaxRecalled <= 0 {
+		config.MaxRecalled = 3
+	}
 
 	return &ReflexionAgent{
 		llm:            llmClient,
 		tools:          toolRegistry,
 		config:         config,
-		episodicMemory: make([]Reflection, 0),
 		maxReflections: config.MaxReflections,
 	}
 }
@@ -157,40 +203,102 @@ func (a *ReflexionAgent) Run(ctx context.Context, query string) (*Response, erro
 }
 
 // RunWithHistory processes a query with conversation history and self-reflection.
+// Reflections are read from and written to the scope set by WithReflectionScope.
+//
+// The response's Metadata["reflexion"] holds the run's *ReflexionTrace.
+//
+// The run is traced as an "invoke_agent reflexion" span with one
+// "reflexion.attempt" span per attempt.
 func (a *ReflexionAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "reflexion")
+	resp, err := withMemory(ctx, a.config.Config, history, query, a.run)
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (a *ReflexionAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
 	var bestResponse *Response
-	var bestScore float64
+	var bestEval *Evaluation
+	var trace []Reflection
+	attempts := 0
+	scope := reflectionScopeFrom(ctx)
+	evalOpts := evalOptionsFrom(ctx)
+	budget := BudgetFrom(ctx)
+	ctx = guardrailContext(ctx, a.config.Guardrails)
+
+	// Redact before the query reaches evaluators and reflection memory
+	query, err := a.config.Guardrails.CheckInput(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each attempt's span ends when the next starts or the run returns
+	var attemptSpan oteltrace.Span
+	defer func() { endSpan(attemptSpan, nil) }()
 
 	for attempt := 0; attempt < a.maxReflections; attempt++ {
+		// Once the budget runs out, settle for the best attempt so far
+		if bestResponse != nil && budget.Check() != nil {
+			if a.config.Verbose {
+				log.Printf("[Reflexion] Budget exhausted, returning best attempt")
+			}
+			break
+		}
+
+		attempts = attempt + 1
 		if a.config.Verbose {
 			log.Printf("[Reflexion] Attempt %d/%d", attempt+1, a.maxReflections)
 		}
 
+		endSpan(attemptSpan, nil)
+		var attemptCtx context.Context
+		attemptCtx, attemptSpan = tracer().Start(ctx, "reflexion.attempt", oteltrace.WithAttributes(attrAttempt.Int(attempts)))
+
 		// Build context with past reflections
-		enhancedHistory := a.buildReflectionContext(history, query)
+		enhancedHistory := a.buildReflectionContext(attemptCtx, scope, history, query)
 
 		// Execute using inner ReAct agent
-		resp, err := a.executeWithReAct(ctx, enhancedHistory, query)
+		resp, err := a.executeWithReAct(attemptCtx, enhancedHistory, query)
 		if err != nil {
 			return nil, fmt.Errorf("execution failed: %w", err)
 		}
 
+		// Skip evaluating an attempt cut short by the budget
+		if bestResponse == nil && budget.Check() != nil {
+			return withBudgetUsage(ctx, withGuardrailViolations(ctx, withReflexionTrace(resp, attempts, trace, nil))), nil
+		}
+
 		// Evaluate the response
-		eval, err := a.evaluate(ctx, query, resp.Output)
+		eval, err := a.evaluate(attemptCtx, EvalInput{
+			Query:     query,
+			Response:  resp.Output,
+			Reference: evalOpts.Reference,
+			Steps:     resp.Steps,
+		})
 		if err != nil {
 			if a.config.Verbose {
 				log.Printf("[Reflexion] Evaluation failed: %v, accepting response", err)
 			}
-			return resp, nil
+			return withBudgetUsage(ctx, withGuardrailViolations(ctx, withReflexionTrace(resp, attempts, trace, nil))), nil
 		}
 
 		if a.config.Verbose {
 			log.Printf("[Reflexion] Score: %.1f (threshold: %.1f)", eval.Score, a.config.QualityThreshold)
 		}
 
+		attemptSpan.SetAttributes(attrScore.Float64(eval.Score))
+
+		reflection := Reflection{
+			Query:      query,
+			Attempt:    attempt + 1,
+			Response:   resp.Output,
+			Evaluation: eval,
+		}
+
 		// Track best response
-		if eval.Score > bestScore {
-			bestScore = eval.Score
+		if bestEval == nil || eval.Score > bestEval.Score {
+			bestEval = &reflection.Evaluation
 			bestResponse = resp
 		}
 
@@ -199,27 +307,36 @@ func (a *ReflexionAgent) RunWithHistory(ctx context.Context, history []Message,
 			if a.config.Verbose {
 				log.Printf("[Reflexion] Quality threshold met, accepting response")
 			}
-			return resp, nil
+			trace = append(trace, reflection)
+			return withBudgetUsage(ctx, withGuardrailViolations(ctx, withReflexionTrace(resp, attempts, trace, &reflection.Evaluation))), nil
+		}
+
+		// No budget left for another attempt, so there is nothing to reflect for
+		if budget.Check() != nil {
+			trace = append(trace, reflection)
+			continue
 		}
 
 		// Generate reflection for next attempt
-		feedback, err := a.reflect(ctx, query, resp.Output, eval)
+		feedback, err := a.reflect(attemptCtx, query, resp.Output, eval)
 		if err != nil {
 			if a.config.Verbose {
 				log.Printf("[Reflexion] Reflection failed: %v", err)
 			}
+			trace = append(trace, reflection)
 			continue
 		}
 
 		// Store reflection for learning
-		reflection := Reflection{
-			Query:      query,
-			Attempt:    attempt + 1,
-			Response:   resp.Output,
-			Evaluation: eval,
-			Feedback:   feedback,
+		reflection.Feedback = feedback
+		if stored, err := a.config.Memory.Add(attemptCtx, scope, reflection); err != nil {
+			if a.config.Verbose {
+				log.Printf("[Reflexion] Failed to store reflection: %v", err)
+			}
+		} else {
+			reflection = stored
 		}
-		a.episodicMemory = append(a.episodicMemory, reflection)
+		trace = append(trace, reflection)
 
 		if a.config.Verbose {
 			log.Printf("[Reflexion] Feedback: %s", truncate(feedback, 100))
@@ -228,25 +345,33 @@ func (a *ReflexionAgent) RunWithHistory(ctx context.Context, history []Message,
 
 	// Return best response after all attempts
 	if bestResponse != nil {
-		return bestResponse, nil
+		return withBudgetUsage(ctx, withGuardrailViolations(ctx, withReflexionTrace(bestResponse, attempts, trace, bestEval))), nil
 	}
 
 	return nil, fmt.Errorf("all %d reflection attempts failed", a.maxReflections)
 }
 
-// buildReflectionContext enhances history with past reflections.
-func (a *ReflexionAgent) buildReflectionContext(history []Message, query string) []Message {
-	if len(a.episodicMemory) == 0 {
-		return history
+// withReflexionTrace attaches the reflexion trace to resp's metadata.
+func withReflexionTrace(resp *Response, attempts int, reflections []Reflection, final *Evaluation) *Response {
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata["reflexion"] = &ReflexionTrace{
+		Attempts:        attempts,
+		Reflections:     reflections,
+		FinalEvaluation: final,
 	}
+	return resp
+}
 
-	// Find relevant past reflections for this query
-	var relevantReflections []Reflection
-	for _, r := range a.episodicMemory {
-		if strings.Contains(strings.ToLower(query), strings.ToLower(r.Query)) ||
-			strings.Contains(strings.ToLower(r.Query), strings.ToLower(query)) {
-			relevantReflections = append(relevantReflections, r)
+// buildReflectionContext enhances history with the most relevant past reflections.
+func (a *ReflexionAgent) buildReflectionContext(ctx context.Context, scope ReflectionScope, history []Message, query string) []Message {
+	relevantReflections, err := a.config.Memory.Search(ctx, scope, query, a.config.MaxRecalled)
+	if err != nil {
+		if a.config.Verbose {
+			log.Printf("[Reflexion] Failed to recall reflections: %v", err)
 		}
+		return history
 	}
 
 	if len(relevantReflections) == 0 {
@@ -279,27 +404,19 @@ func (a *ReflexionAgent) executeWithReAct(ctx context.Context, history []Message
 	return reactAgent.RunWithHistory(ctx, history, query)
 }
 
-// evaluate assesses the quality of a response.
-func (a *ReflexionAgent) evaluate(ctx context.Context, query, response string) (Evaluation, error) {
-	prompt := fmt.Sprintf(a.config.EvaluationPrompt, query, response)
-
-	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
-		Messages: []llm.Message{
-			{Role: llm.RoleSystem, Content: "You are a critical evaluator. Respond only with valid JSON."},
-			{Role: llm.RoleUser, Content: prompt},
-		},
-		Temperature: 0.3, // Lower temperature for more consistent evaluation
-	})
-	if err != nil {
-		return Evaluation{}, fmt.Errorf("evaluation request failed: %w", err)
-	}
-
-	var eval Evaluation
-	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &eval); err != nil {
-		return Evaluation{}, fmt.Errorf("failed to parse evaluation: %w", err)
+// evaluate assesses the quality of a response with the run's evaluator.
+func (a *ReflexionAgent) evaluate(ctx context.Context, input EvalInput) (Evaluation, error) {
+	evaluator := a.config.Evaluator
+	if opts := evalOptionsFrom(ctx); opts.Evaluator != nil {
+		evaluator = opts.Evaluator
 	}
+	return evaluator.Evaluate(ctx, input)
+}
 
-	return eval, nil
+// Evaluator returns the named evaluator from ReflexionConfig.Evaluators.
+func (a *ReflexionAgent) Evaluator(name string) (Evaluator, bool) {
+	e, ok := a.config.Evaluators[name]
+	return e, ok
 }
 
 // reflect generates feedback for improvement.
@@ -321,14 +438,24 @@ func (a *ReflexionAgent) reflect(ctx context.Context, query, response string, ev
 	return resp.Content, nil
 }
 
-// GetEpisodicMemory returns the agent's episodic memory.
+// Memory returns the store the agent keeps reflections in.
+func (a *ReflexionAgent) Memory() ReflectionStore {
+	return a.config.Memory
+}
+
+// GetEpisodicMemory returns the reflections stored without a scope.
+//
+// Deprecated: use Memory().List with a ReflectionScope.
 func (a *ReflexionAgent) GetEpisodicMemory() []Reflection {
-	return a.episodicMemory
+	reflections, _ := a.config.Memory.List(context.Background(), ReflectionScope{})
+	return reflections
 }
 
-// ClearEpisodicMemory clears the agent's episodic memory.
+// ClearEpisodicMemory clears the reflections stored without a scope.
+//
+// Deprecated: use Memory().Clear with a ReflectionScope.
 func (a *ReflexionAgent) ClearEpisodicMemory() {
-	a.episodicMemory = make([]Reflection, 0)
+	_ = a.config.Memory.Clear(context.Background(), ReflectionScope{})
 }
 
 // extractJSON extracts JSON from a string that may contain markdown code blocks.
@@ -191,3 +191,45 @@ func TestReflection_Structure(t *testing.T) {
 		t.Errorf("expected Score 7.0, got %f", reflection.Evaluation.Score)
 	}
 }
+
+func TestReflexionAgent_MetadataTrace(t *testing.T) {
+	scores := []string{`{"score": 4, "weaknesses": ["vague"]}`, `{"score": 8, "reasoning": "good"}`}
+	evals := 0
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			if req.Messages[0].Content == "Generate specific, actionable feedback for improvement." {
+				return &llm.ChatResponse{Content: "be specific"}, nil
+			}
+			evals++
+			return &llm.ChatResponse{Content: scores[evals-1]}, nil
+		},
+	}
+
+	a := NewReflexionAgent(mock, tools.NewRegistry(), ReflexionConfig{})
+	resp, err := a.Run(context.Background(), "q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	meta := resp.Metadata["reflexion"].(*ReflexionTrace)
+	if meta.Attempts != 2 {
+		t.Errorf("expected 2 attempts, got %v", meta.Attempts)
+	}
+
+	reflections := meta.Reflections
+	if len(reflections) != 2 {
+		t.Fatalf("expected 2 reflections, got %d", len(reflections))
+	}
+	if reflections[0].Feedback != "be specific" || reflections[1].Feedback != "" {
+		t.Errorf("expected feedback only on the rejected attempt, got %+v", reflections)
+	}
+
+	final := meta.FinalEvaluation
+	if final == nil || final.Score != 8 {
+		t.Errorf("expected final evaluation score 8, got %v", final)
+	}
+	stored, _ := a.Memory().List(context.Background(), ReflectionScope{})
+	if len(stored) != 1 || stored[0].ID != reflections[0].ID {
+		t.Errorf("expected only the rejected attempt in episodic memory, got %+v", stored)
+	}
+}
@@ -0,0 +1,336 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// RouterAgent classifies a query and dispatches it to the registered agent
+// whose capabilities fit it best, so that clients need not pick a strategy.
+//
+// Flow:
+//  1. Heuristics: if the query matches the patterns of exactly one route,
+//     that route is chosen without a model call
+//  2. Otherwise an LLM classifier picks a route from the capability
+//     descriptions and reports its confidence
+//  3. Below ConfidenceThreshold, or when classification fails, the query
+//     goes to the fallback route
+//
+// The routing decision is recorded in Response.Metadata["router"].
+// Guardrails and budgets are applied by the routed agents.
+type RouterAgent struct {
+	llm    LLMClient
+	routes []Route
+	config RouterConfig
+}
+
+// RouterConfig contains configuration for the router agent.
+type RouterConfig struct {
+	Config
+
+	// ConfidenceThreshold is the minimum classifier confidence, from 0 to 1,
+	// required to follow its choice. Default is 0.6.
+	ConfidenceThreshold float64
+
+	// Fallback names the route used when the classifier is unsure or fails.
+	// Default is the first registered route.
+	Fallback string
+
+	// ClassificationPrompt is the prompt used to classify a query.
+	// It is formatted with the route list and the query.
+	ClassificationPrompt string
+}
+
+// Route is an agent the router can dispatch to.
+type Route struct {
+	// Name identifies the route in classifier output and routing decisions.
+	Name string
+
+	// Description tells the classifier what the agent is good at.
+	Description string
+
+	// Agent handles the queries routed here.
+	Agent Agent
+
+	// Patterns are heuristics that select this route without a model call
+	// when no other route's patterns match the query.
+	Patterns []*regexp.Regexp
+}
+
+// Routing methods recorded in a RoutingDecision.
+const (
+	RouteMethodHeuristic = "heuristic"
+	RouteMethodLLM       = "llm"
+	RouteMethodFallback  = "fallback"
+)
+
+// RoutingDecision records how a query was routed.
+type RoutingDecision struct {
+	// Route is the name of the route the query was dispatched to.
+	Route string `json:"route"`
+
+	// Method is "heuristic", "llm" or "fallback".
+	Method string `json:"method"`
+
+	// Confidence is the classifier's confidence in its choice.
+	Confidence float64 `json:"confidence"`
+
+	// Reasoning explains the choice.
+	Reasoning string `json:"reasoning,omitempty"`
+
+	// Candidate is the classifier's choice when the fallback was used instead.
+	Candidate string `json:"candidate,omitempty"`
+}
+
+// routeClassification is the classifier's structured output.
+type routeClassification struct {
+	Route      string  `json:"route"`
+	Confidence float64 `json:"confidence"`
+	Reasoning  string  `json:"reasoning"`
+}
+
+// routeToolName is the function the classifier calls to report its choice.
+const routeToolName = "select_route"
+
+// NewRouterAgent creates a new router agent. Routes are added with Register.
+func NewRouterAgent(llmClient LLMClient, config RouterConfig) *RouterAgent {
+	if config.ConfidenceThreshold <= 0 {
+		config.ConfidenceThreshold = 0.6
+	}
+	if config.SystemPrompt == "" {
+		config.SystemPrompt = defaultRouterPrompt
+	}
+	if config.ClassificationPrompt == "" {
+		config.ClassificationPrompt = defaultClassificationPrompt
+	}
+
+	return &RouterAgent{
+		llm:    metered(llmClient),
+		config: config,
+	}
+}
+
+const defaultRouterPrompt = `You are a router that sends each user query to the agent best suited to answer it.
+Judge by the agents' capabilities, not by surface wording. Be honest about your confidence.`
+
+const defaultClassificationPrompt = `Choose the agent for this query.
+
+Agents:
+%s
+
+Query: %s
+
+Call select_route with the chosen agent, your confidence from 0 to 1, and a one-sentence reason.
+If you cannot call functions, return only JSON: {"route": "...", "confidence": 0.0, "reasoning": "..."}`
+
+// Register adds a route, replacing any route with the same name.
+func (r *RouterAgent) Register(route Route) {
+	for i, existing := range r.routes {
+		if existing.Name == route.Name {
+			r.routes[i] = route
+			return
+		}
+	}
+	r.routes = append(r.routes, route)
+}
+
+// Routes returns the registered routes in registration order.
+func (r *RouterAgent) Routes() []Route {
+	return append([]Route(nil), r.routes...)
+}
+
+// Run processes a query.
+func (r *RouterAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return r.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory routes a query and runs it, with history, on the chosen agent.
+//
+// The routed agent's response is returned with a leading "routing" step, the
+// classifier's token usage added, and Metadata["router"] set to the
+// *RoutingDecision. The run is traced as an "invoke_agent router" span.
+func (r *RouterAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "router")
+	resp, err := withMemory(ctx, r.config.Config, history, query, r.run)
+	if resp != nil {
+		if decision, ok := resp.Metadata["router"].(*RoutingDecision); ok {
+			span.SetAttributes(attrRoute.String(decision.Route), attrRouteMethod.String(decision.Method))
+		}
+	}
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (r *RouterAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	decision, usage, err := r.Route(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	route, _ := r.route(decision.Route)
+
+	if r.config.Verbose {
+		log.Printf("[Router] %s -> %s (%s, confidence %.2f)", truncate(query, 50), decision.Route, decision.Method, decision.Confidence)
+	}
+
+	resp, err := route.Agent.RunWithHistory(ctx, history, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s agent failed: %w", decision.Route, err)
+	}
+
+	resp.Steps = append([]Step{{
+		Type:    "routing",
+		Content: fmt.Sprintf("Routed to %s by %s (confidence %.2f): %s", decision.Route, decision.Method, decision.Confidence, decision.Reasoning),
+	}}, resp.Steps...)
+	resp.Usage = addUsage(resp.Usage, usage)
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata["router"] = decision
+	return resp, nil
+}
+
+// Route decides which registered route should handle query without running it.
+// It returns the classifier's token usage, which is zero for heuristic matches.
+func (r *RouterAgent) Route(ctx context.Context, query string) (*RoutingDecision, Usage, error) {
+	if len(r.routes) == 0 {
+		return nil, Usage{}, errors.New("router has no routes")
+	}
+	fallback := r.config.Fallback
+	if fallback == "" {
+		fallback = r.routes[0].Name
+	}
+	if _, ok := r.route(fallback); !ok {
+		return nil, Usage{}, fmt.Errorf("fallback route %q is not registered", fallback)
+	}
+
+	if name, ok := r.matchHeuristics(query); ok {
+		return &RoutingDecision{
+			Route:      name,
+			Method:     RouteMethodHeuristic,
+			Confidence: 1,
+			Reasoning:  "query matched the route's patterns",
+		}, Usage{}, nil
+	}
+
+	classification, usage, err := r.classify(ctx, query)
+	if err != nil {
+		if r.config.Verbose {
+			log.Printf("[Router] Classification failed, using %s: %v", fallback, err)
+		}
+		return &RoutingDecision{
+			Route:     fallback,
+			Method:    RouteMethodFallback,
+			Reasoning: fmt.Sprintf("classification failed: %v", err),
+		}, usage, nil
+	}
+
+	if classification.Confidence < r.config.ConfidenceThreshold {
+		return &RoutingDecision{
+			Route:      fallback,
+			Method:     RouteMethodFallback,
+			Confidence: classification.Confidence,
+			Reasoning:  fmt.Sprintf("confidence below %.2f: %s", r.config.ConfidenceThreshold, classification.Reasoning),
+			Candidate:  classification.Route,
+		}, usage, nil
+	}
+
+	return &RoutingDecision{
+		Route:      classification.Route,
+		Method:     RouteMethodLLM,
+		Confidence: classification.Confidence,
+		Reasoning:  classification.Reasoning,
+	}, usage, nil
+}
+
+// route looks up a registered route by name.
+func (r *RouterAgent) route(name string) (Route, bool) {
+	for _, route := range r.routes {
+		if route.Name == name {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// matchHeuristics returns the only route whose patterns match query.
+// It reports false when no route or more than one route matches.
+func (r *RouterAgent) matchHeuristics(query string) (string, bool) {
+	var matched []string
+	for _, route := range r.routes {
+		for _, p := range route.Patterns {
+			if p.MatchString(query) {
+				matched = append(matched, route.Name)
+				break
+			}
+		}
+	}
+	if len(matched) != 1 {
+		return "", false
+	}
+	return matched[0], true
+}
+
+// classify asks the model to choose a route. The choice is read from a
+// select_route call, or from JSON content for models that answer in text.
+func (r *RouterAgent) classify(ctx context.Context, query string) (*routeClassification, Usage, error) {
+	names := make([]any, 0, len(r.routes))
+	var list strings.Builder
+	for _, route := range r.routes {
+		names = append(names, route.Name)
+		fmt.Fprintf(&list, "- %s: %s\n", route.Name, route.Description)
+	}
+
+	resp, err := r.llm.ChatWithTools(ctx, &llm.ChatWithToolsRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: r.config.SystemPrompt},
+			{Role: llm.RoleUser, Content: fmt.Sprintf(r.config.ClassificationPrompt, strings.TrimSuffix(list.String(), "\n"), query)},
+		},
+		Tools: []llm.ToolDefinition{{
+			Type: "function",
+			Function: llm.FunctionDefinition{
+				Name:        routeToolName,
+				Description: "Selects the agent that will answer the query.",
+				Parameters: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"route":      map[string]any{"type": "string", "enum": names},
+						"confidence": map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+						"reasoning":  map[string]any{"type": "string"},
+					},
+					"required": []string{"route", "confidence", "reasoning"},
+				},
+			},
+		}},
+		Model:       r.config.Model,
+		Temperature: r.config.Temperature,
+	})
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	usage := toUsage(resp.Usage)
+
+	raw := extractJSON(resp.Content)
+	for _, call := range resp.ToolCalls {
+		if call.Name == routeToolName {
+			raw = call.Arguments
+			break
+		}
+	}
+
+	var classification routeClassification
+	if err := json.Unmarshal([]byte(raw), &classification); err != nil {
+		return nil, usage, fmt.Errorf("invalid classification: %w", err)
+	}
+	if _, ok := r.route(classification.Route); !ok {
+		return nil, usage, fmt.Errorf("unknown route %q", classification.Route)
+	}
+	return &classification, usage, nil
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// newTestRouter registers react, reflexion and orchestrator routes whose
+// agents answer with their own name.
+func newTestRouter(mock *MockLLMClient, config RouterConfig) *RouterAgent {
+	r := NewRouterAgent(mock, config)
+	for _, name := range []string{"react", "reflexion", "orchestrator"} {
+		r.Register(Route{
+			Name:        name,
+			Description: name + " agent",
+			Agent: &funcAgent{run: func(_ context.Context, _ string) (*Response, error) {
+				return &Response{Output: name, Usage: Usage{TotalTokens: 100}}, nil
+			}},
+		})
+	}
+	return r
+}
+
+// classifierMock answers the routing call with the given tool arguments.
+func classifierMock(arguments string, calls *int) *MockLLMClient {
+	return &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			*calls++
+			if len(req.Tools) != 1 || req.Tools[0].Function.Name != routeToolName {
+				return nil, errors.New("expected the select_route tool")
+			}
+			return &llm.ChatWithToolsResponse{
+				ToolCalls: []llm.ToolCall{{ID: "1", Name: routeToolName, Arguments: arguments}},
+				Usage:     llm.Usage{PromptTokens: 40, CompletionTokens: 10, TotalTokens: 50},
+			}, nil
+		},
+	}
+}
+
+func TestRouterAgent_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		arguments  string
+		wantRoute  string
+		wantMethod string
+		wantCand   string
+	}{
+		{
+			name:       "confident classification",
+			arguments:  `{"route": "orchestrator", "confidence": 0.9, "reasoning": "multi-part task"}`,
+			wantRoute:  "orchestrator",
+			wantMethod: RouteMethodLLM,
+		},
+		{
+			name:       "low confidence uses fallback",
+			arguments:  `{"route": "orchestrator", "confidence": 0.3, "reasoning": "unsure"}`,
+			wantRoute:  "reflexion",
+			wantMethod: RouteMethodFallback,
+			wantCand:   "orchestrator",
+		},
+		{
+			name:       "unknown route uses fallback",
+			arguments:  `{"route": "oracle", "confidence": 0.99, "reasoning": "made up"}`,
+			wantRoute:  "reflexion",
+			wantMethod: RouteMethodFallback,
+		},
+		{
+			name:       "invalid output uses fallback",
+			arguments:  `not json`,
+			wantRoute:  "reflexion",
+			wantMethod: RouteMethodFallback,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			r := newTestRouter(classifierMock(tt.arguments, &calls), RouterConfig{Fallback: "reflexion"})
+
+			resp, err := r.Run(context.Background(), "Research Go and Rust, then write a comparison")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Output != tt.wantRoute {
+				t.Errorf("expected %s agent to answer, got %q", tt.wantRoute, resp.Output)
+			}
+
+			decision, ok := resp.Metadata["router"].(*RoutingDecision)
+			if !ok {
+				t.Fatalf("expected routing decision in metadata, got %v", resp.Metadata)
+			}
+			if decision.Route != tt.wantRoute || decision.Method != tt.wantMethod || decision.Candidate != tt.wantCand {
+				t.Errorf("unexpected decision %+v", decision)
+			}
+			if resp.Usage.TotalTokens != 150 {
+				t.Errorf("expected classifier usage to be added, got %d tokens", resp.Usage.TotalTokens)
+			}
+			if len(resp.Steps) == 0 || resp.Steps[0].Type != "routing" {
+				t.Errorf("expected a leading routing step, got %+v", resp.Steps)
+			}
+		})
+	}
+}
+
+func TestRouterAgent_Heuristics(t *testing.T) {
+	calls := 0
+	r := newTestRouter(classifierMock(`{"route": "reflexion", "confidence": 0.9, "reasoning": "essay"}`, &calls), RouterConfig{})
+
+	math := regexp.MustCompile(`^[\d\s+\-*/().]+$`)
+	react, _ := r.route("react")
+	react.Patterns = []*regexp.Regexp{math}
+	r.Register(react)
+
+	resp, err := r.Run(context.Background(), "(12 + 30) * 2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decision := resp.Metadata["router"].(*RoutingDecision)
+	if decision.Route != "react" || decision.Method != RouteMethodHeuristic {
+		t.Errorf("expected heuristic route to react, got %+v", decision)
+	}
+	if calls != 0 {
+		t.Errorf("expected no classifier call, got %d", calls)
+	}
+
+	// Ambiguous matches fall through to the classifier
+	reflexion, _ := r.route("reflexion")
+	reflexion.Patterns = []*regexp.Regexp{math}
+	r.Register(reflexion)
+
+	if _, err := r.Run(context.Background(), "(12 + 30) * 2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the classifier to break the tie, got %d calls", calls)
+	}
+	if len(r.Routes()) != 3 {
+		t.Errorf("expected re-registration to replace routes, got %d", len(r.Routes()))
+	}
+}
+
+func TestRouterAgent_Errors(t *testing.T) {
+	calls := 0
+	mock := classifierMock(`{}`, &calls)
+
+	if _, err := NewRouterAgent(mock, RouterConfig{}).Run(context.Background(), "hi"); err == nil {
+		t.Error("expected error without routes")
+	}
+	if _, err := newTestRouter(mock, RouterConfig{Fallback: "missing"}).Run(context.Background(), "hi"); err == nil {
+		t.Error("expected error for unregistered fallback")
+	}
+}
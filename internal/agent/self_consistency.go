@@ -0,0 +1,508 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// SelfConsistencyAgent samples several independent ReAct trajectories in
+// parallel and aggregates their final answers, trading tokens for accuracy
+// on queries with a single correct answer such as numbers or labels.
+//
+// Reference: Wang et al., 2022 - "Self-Consistency Improves Chain of Thought Reasoning in Language Models"
+// https://arxiv.org/abs/2203.11171
+//
+// Samples vary by temperature and, when several clients are configured, by
+// provider. The share of samples agreeing with the chosen answer is reported
+// as its confidence.
+type SelfConsistencyAgent struct {
+	llm    LLMClient
+	tools  *tools.Registry
+	config SelfConsistencyConfig
+}
+
+// Consensus methods for combining sampled answers.
+const (
+	// ConsensusMajority picks the most common answer after normalization.
+	ConsensusMajority = "majority"
+
+	// ConsensusJudge asks the LLM which answer the samples agree on.
+	ConsensusJudge = "judge"
+
+	// ConsensusEvaluator picks the answer the Evaluator scores highest.
+	ConsensusEvaluator = "evaluator"
+)
+
+// SelfConsistencyConfig contains configuration for the self-consistency agent.
+type SelfConsistencyConfig struct {
+	Config
+
+	// Samples is the number of trajectories to sample. Default is 5.
+	Samples int
+
+	// MaxParallel is the number of samples, and evaluations for the "evaluator"
+	// consensus, run at once. Default is Samples.
+	// Samples not yet started are skipped once the vote is decided or a
+	// token limit is reached, so with parallel samples the confidence and
+	// Usage depend on which samples finish first. Set it to 1 for
+	// reproducible results.
+	MaxParallel int
+
+	// Temperatures are assigned to samples in turn. Default is 0.3, 0.7 and 1.0.
+	Temperatures []float32
+
+	// Clients, when set, are assigned to samples in turn so that answers
+	// come from different providers. Default is the agent's client.
+	Clients []LLMClient
+
+	// Consensus is "majority", "judge" or "evaluator". Default is "majority".
+	Consensus string
+
+	// Evaluator scores answers for the "evaluator" consensus.
+	Evaluator Evaluator
+
+	// ConsensusPrompt is the prompt for the "judge" consensus.
+	// It is formatted with the query and the numbered answers.
+	ConsensusPrompt string
+
+	// MaxTokens stops new samples from starting once the samples so far have
+	// used this many tokens. Samples already running finish. Zero is unlimited.
+	MaxTokens int
+
+	// Normalize maps an answer to the key it is voted under.
+	// Default is NormalizeAnswer.
+	Normalize func(string) string
+}
+
+// SampleResult is the outcome of one sampled trajectory.
+type SampleResult struct {
+	Index       int     `json:"index"`
+	Temperature float32 `json:"temperature"`
+	Output      string  `json:"output,omitempty"`
+	Answer      string  `json:"answer,omitempty"` // Normalized output
+	Score       float64 `json:"score,omitempty"`  // Evaluator score, for the "evaluator" consensus
+	Usage       Usage   `json:"usage"`
+	Error       string  `json:"error,omitempty"`
+	Skipped     bool    `json:"skipped,omitempty"`
+
+	steps []Step
+}
+
+// ConsensusResult records how the final answer was chosen.
+type ConsensusResult struct {
+	// Method is the consensus method used.
+	Method string `json:"method"`
+
+	// Answer is the normalized chosen answer.
+	Answer string `json:"answer"`
+
+	// Confidence is the share of completed samples agreeing with Answer.
+	Confidence float64 `json:"confidence"`
+
+	// Votes counts completed samples per normalized answer.
+	Votes map[string]int `json:"votes"`
+
+	// Reasoning explains a judged consensus.
+	Reasoning string `json:"reasoning,omitempty"`
+
+	// Samples lists every sample, including failed and skipped ones.
+	Samples []SampleResult `json:"samples"`
+}
+
+// NewSelfConsistencyAgent creates a new self-consistency agent.
+func NewSelfConsistencyAgent(llmClient LLMClient, toolRegistry *tools.Registry, config SelfConsistencyConfig) *SelfConsistencyAgent {
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = 10
+	}
+	if config.Samples <= 0 {
+		config.Samples = 5
+	}
+	if config.MaxParallel <= 0 || config.MaxParallel > config.Samples {
+		config.MaxParallel = config.Samples
+	}
+	if len(config.Temperatures) == 0 {
+		config.Temperatures = []float32{0.3, 0.7, 1.0}
+	}
+	if config.Consensus == "" {
+		config.Consensus = ConsensusMajority
+	}
+	if config.ConsensusPrompt == "" {
+		config.ConsensusPrompt = defaultConsensusPrompt
+	}
+	if config.Normalize == nil {
+		config.Normalize = NormalizeAnswer
+	}
+
+	return &SelfConsistencyAgent{
+		llm:    metered(llmClient),
+		tools:  toolRegistry,
+		config: config,
+	}
+}
+
+const defaultConsensusPrompt = `Several independent attempts answered the same question.
+
+Question: %s
+
+Answers:
+%s
+
+Decide which final answer most attempts agree on, treating answers that say the same thing in different words as equal.
+
+Return JSON:
+{
+  "answer": "the agreed final answer, stated once",
+  "supporting": [1, 3],  // numbers of the answers that agree with it
+  "reasoning": "why this is the consensus"
+}
+
+Return only valid JSON.`
+
+// Run processes a query.
+func (a *SelfConsistencyAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return a.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory samples trajectories for a query and returns the consensus.
+//
+// The response's Output is the chosen sample's answer (or the judge's
+// statement of it), Steps are the chosen sample's trace followed by a
+// "consensus" step, Usage covers every sample, and Metadata["self_consistency"]
+// holds the *ConsensusResult.
+func (a *SelfConsistencyAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "self_consistency")
+	resp, err := withMemory(ctx, a.config.Config, history, query, a.run)
+	if resp != nil {
+		if result, ok := resp.Metadata["self_consistency"].(*ConsensusResult); ok {
+			span.SetAttributes(attrConfidence.Float64(result.Confidence))
+		}
+	}
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (a *SelfConsistencyAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx = guardrailContext(ctx, a.config.Guardrails)
+
+	if a.config.Verbose {
+		log.Printf("[SelfConsistency] Sampling %d trajectories for: %s", a.config.Samples, truncate(query, 50))
+	}
+
+	samples := a.sample(ctx, history, query)
+
+	var totalUsage Usage
+	var completed []int
+	var errs []error
+	for i, s := range samples {
+		totalUsage = addUsage(totalUsage, s.Usage)
+		switch {
+		case s.Error != "":
+			errs = append(errs, fmt.Errorf("sample %d: %s", i+1, s.Error))
+		case !s.Skipped:
+			completed = append(completed, i)
+		}
+	}
+	if len(completed) == 0 {
+		if len(errs) == 0 {
+			errs = append(errs, ErrBudgetExceeded)
+		}
+		return nil, fmt.Errorf("all samples failed: %w", errors.Join(errs...))
+	}
+
+	votes := make(map[string]int)
+	for _, i := range completed {
+		votes[samples[i].Answer]++
+	}
+	result := &ConsensusResult{
+		Method:  a.config.Consensus,
+		Votes:   votes,
+		Samples: samples,
+	}
+
+	chosen := majority(samples, completed)
+	output := samples[chosen].Output
+
+	switch a.config.Consensus {
+	case ConsensusJudge:
+		judged, supporting, reasoning, usage, err := a.judge(ctx, query, samples, completed)
+		totalUsage = addUsage(totalUsage, usage)
+		if err != nil {
+			// Fall back to the vote rather than failing a run that has answers
+			if a.config.Verbose {
+				log.Printf("[SelfConsistency] Judge failed, using majority vote: %v", err)
+			}
+			result.Method = ConsensusMajority
+			result.Reasoning = fmt.Sprintf("judge failed: %v", err)
+			break
+		}
+		output = judged
+		result.Answer = a.config.Normalize(judged)
+		result.Confidence = float64(len(supporting)) / float64(len(completed))
+		result.Reasoning = reasoning
+		if len(supporting) > 0 {
+			chosen = supporting[0]
+		}
+	case ConsensusEvaluator:
+		if a.config.Evaluator == nil {
+			return nil, errors.New("evaluator consensus requires an Evaluator")
+		}
+		var usage Usage
+		chosen, usage = a.bestScored(ctx, query, samples, completed)
+		totalUsage = addUsage(totalUsage, usage)
+		output = samples[chosen].Output
+	}
+
+	if result.Answer == "" {
+		result.Answer = samples[chosen].Answer
+		result.Confidence = float64(votes[result.Answer]) / float64(len(completed))
+	}
+
+	if a.config.Verbose {
+		log.Printf("[SelfConsistency] %s consensus on %q (confidence %.2f, %d/%d samples completed)",
+			result.Method, truncate(result.Answer, 50), result.Confidence, len(completed), len(samples))
+	}
+
+	output, err := a.config.Guardrails.CheckOutput(ctx, output)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := append(samples[chosen].steps, Step{
+		Type:    "consensus",
+		Content: fmt.Sprintf("%s of %d samples: %q with confidence %.2f", result.Method, len(completed), result.Answer, result.Confidence),
+	})
+
+	return withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+		Output: output,
+		Steps:  steps,
+		Usage:  totalUsage,
+		Metadata: map[string]any{
+			"self_consistency": result,
+		},
+	})), nil
+}
+
+// sample runs the trajectories, at most MaxParallel at a time. Samples not yet
+// started are skipped once MaxTokens or the run budget is spent, or, for a
+// majority vote, once the leading answer can no longer be overtaken.
+func (a *SelfConsistencyAgent) sample(ctx context.Context, history []Message, query string) []SampleResult {
+	samples := make([]SampleResult, a.config.Samples)
+	budget := BudgetFrom(ctx)
+
+	var (
+		mu      sync.Mutex
+		used    int
+		votes   = make(map[string]int)
+		settled int
+	)
+	// decided reports whether the remaining samples cannot change the vote.
+	decided := func() bool {
+		if a.config.Consensus != ConsensusMajority {
+			return false
+		}
+		var first, second int
+		for _, n := range votes {
+			if n > first {
+				first, second = n, first
+			} else if n > second {
+				second = n
+			}
+		}
+		return first > second+(a.config.Samples-settled)
+	}
+
+	sem := make(chan struct{}, a.config.MaxParallel)
+	var wg sync.WaitGroup
+	for i := range samples {
+		samples[i].Index = i + 1
+		samples[i].Temperature = a.config.Temperatures[i%len(a.config.Temperatures)]
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			mu.Lock()
+			stop := ctx.Err() != nil || budget.Check() != nil ||
+				(a.config.MaxTokens > 0 && used >= a.config.MaxTokens) || decided()
+			mu.Unlock()
+			if stop {
+				samples[i].Skipped = true
+				return
+			}
+
+			a.runSample(ctx, history, query, &samples[i])
+
+			mu.Lock()
+			defer mu.Unlock()
+			used += samples[i].Usage.TotalTokens
+			settled++
+			if samples[i].Error == "" {
+				votes[samples[i].Answer]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	return samples
+}
+
+// runSample runs one ReAct trajectory and records its answer.
+func (a *SelfConsistencyAgent) runSample(ctx context.Context, history []Message, query string, s *SampleResult) {
+	client := LLMClient(a.llm)
+	if len(a.config.Clients) > 0 {
+		client = a.config.Clients[(s.Index-1)%len(a.config.Clients)]
+	}
+
+	config := a.config.Config
+	config.Temperature = s.Temperature
+	resp, err := NewReActAgent(client, a.tools, config).RunWithHistory(ctx, history, query)
+	if err != nil {
+		s.Error = err.Error()
+		s.steps, s.Usage = progressOf(err)
+		return
+	}
+
+	s.Output = resp.Output
+	s.Answer = a.config.Normalize(resp.Output)
+	s.Usage = resp.Usage
+	s.steps = resp.Steps
+}
+
+// majority returns the index of the first completed sample with the most
+// common answer. Ties go to the answer reached first.
+func majority(samples []SampleResult, completed []int) int {
+	counts := make(map[string]int)
+	for _, i := range completed {
+		counts[samples[i].Answer]++
+	}
+	best := completed[0]
+	for _, i := range completed {
+		if counts[samples[i].Answer] > counts[samples[best].Answer] {
+			best = i
+		}
+	}
+	return best
+}
+
+// judge asks the LLM for the consensus answer. It returns the answer, the
+// indexes of the samples supporting it, the judge's reasoning and usage.
+func (a *SelfConsistencyAgent) judge(ctx context.Context, query string, samples []SampleResult, completed []int) (string, []int, string, Usage, error) {
+	var answers strings.Builder
+	for n, i := range completed {
+		fmt.Fprintf(&answers, "%d. %s\n\n", n+1, samples[i].Output)
+	}
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You compare answers to find their consensus. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: fmt.Sprintf(a.config.ConsensusPrompt, query, strings.TrimSpace(answers.String()))},
+		},
+		Model:       a.config.Model,
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return "", nil, "", Usage{}, err
+	}
+	usage := toUsage(resp.Usage)
+
+	var verdict struct {
+		Answer     string `json:"answer"`
+		Supporting []int  `json:"supporting"`
+		Reasoning  string `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &verdict); err != nil {
+		return "", nil, "", usage, fmt.Errorf("failed to parse consensus: %w", err)
+	}
+	if verdict.Answer == "" {
+		return "", nil, "", usage, errors.New("judge returned no answer")
+	}
+
+	var supporting []int
+	seen := make(map[int]bool)
+	for _, n := range verdict.Supporting {
+		if n >= 1 && n <= len(completed) && !seen[n] {
+			seen[n] = true
+			supporting = append(supporting, completed[n-1])
+		}
+	}
+	return verdict.Answer, supporting, verdict.Reasoning, usage, nil
+}
+
+// bestScored scores each completed sample with the Evaluator, at most
+// MaxParallel at a time, and returns the index of the highest scoring one
+// and the usage of the evaluations. Samples the evaluator fails on, or not
+// evaluated once the run budget is spent, score zero.
+func (a *SelfConsistencyAgent) bestScored(ctx context.Context, query string, samples []SampleResult, completed []int) (int, Usage) {
+	budget := BudgetFrom(ctx)
+	// Evaluators do not report usage, so their metered calls are tallied
+	evalCtx, tally := withUsageTally(ctx)
+
+	sem := make(chan struct{}, a.config.MaxParallel)
+	var wg sync.WaitGroup
+	for _, i := range completed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := budget.Check(); err != nil {
+				return
+			}
+			eval, err := a.config.Evaluator.Evaluate(evalCtx, EvalInput{
+				Query:    query,
+				Response: samples[i].Output,
+				Steps:    samples[i].steps,
+			})
+			if err == nil {
+				samples[i].Score = eval.Score
+			}
+		}()
+	}
+	wg.Wait()
+
+	best := completed[0]
+	for _, i := range completed {
+		if samples[i].Score > samples[best].Score {
+			best = i
+		}
+	}
+	return best, tally.total()
+}
+
+var (
+	numberPattern   = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?`)
+	spacePattern    = regexp.MustCompile(`\s+`)
+	boilerplateText = regexp.MustCompile(`^(?:the )?(?:final )?answer(?: is)?:?\s*`)
+)
+
+// NormalizeAnswer reduces an answer to a voting key. Answers containing a
+// number are keyed by their last number in canonical form, so "The total is
+// 1,200." and "1200.0" agree; other answers are lowercased with whitespace,
+// trailing punctuation and a leading "the answer is" removed.
+func NormalizeAnswer(answer string) string {
+	if numbers := numberPattern.FindAllString(answer, -1); len(numbers) > 0 {
+		last := strings.ReplaceAll(numbers[len(numbers)-1], ",", "")
+		if f, err := strconv.ParseFloat(last, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+
+	s := strings.ToLower(strings.TrimSpace(answer))
+	s = spacePattern.ReplaceAllString(s, " ")
+	s = boilerplateText.ReplaceAllString(s, "")
+	return strings.TrimRight(s, ".!?;: \"'")
+}
@@ -0,0 +1,259 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// answersByTemperature answers without tools, choosing the answer by the
+// request temperature so that samples disagree deterministically.
+func answersByTemperature(answers map[float32]string, calls *atomic.Int32) *MockLLMClient {
+	return &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			calls.Add(1)
+			return &llm.ChatWithToolsResponse{
+				Content: answers[req.Temperature],
+				Usage:   llm.Usage{PromptTokens: 8, CompletionTokens: 2, TotalTokens: 10},
+			}, nil
+		},
+	}
+}
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"The total is 1,200.", "1200.0"},
+		{"Answer: 42", "42"},
+		{"-3.50", "x = -3.5"},
+		{"Positive.", "  positive"},
+		{"The answer is Paris!", "paris"},
+	}
+	for _, tt := range tests {
+		if NormalizeAnswer(tt.a) != NormalizeAnswer(tt.b) {
+			t.Errorf("expected %q and %q to agree, got %q and %q", tt.a, tt.b, NormalizeAnswer(tt.a), NormalizeAnswer(tt.b))
+		}
+	}
+	if NormalizeAnswer("positive") == NormalizeAnswer("negative") {
+		t.Error("expected different labels to differ")
+	}
+}
+
+func TestSelfConsistencyAgent_Majority(t *testing.T) {
+	var calls atomic.Int32
+	mock := answersByTemperature(map[float32]string{
+		0.2: "The result is 42.",
+		0.6: "42",
+		1.0: "41",
+	}, &calls)
+
+	// Hold every sample until all have started, so the vote cannot be
+	// decided before the third sample runs
+	var started sync.WaitGroup
+	started.Add(3)
+	answer := mock.ChatWithToolsFunc
+	mock.ChatWithToolsFunc = func(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+		started.Done()
+		started.Wait()
+		return answer(ctx, req)
+	}
+
+	a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+		Samples:      3,
+		Temperatures: []float32{0.2, 0.6, 1.0},
+	})
+	resp, err := a.Run(context.Background(), "What is 6*7?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := resp.Metadata["self_consistency"].(*ConsensusResult)
+	if result.Answer != "42" {
+		t.Errorf("expected consensus 42, got %q", result.Answer)
+	}
+	if result.Confidence < 0.66 || result.Confidence > 0.67 {
+		t.Errorf("expected confidence 2/3, got %f", result.Confidence)
+	}
+	if resp.Output != "The result is 42." {
+		t.Errorf("expected the first agreeing sample's output, got %q", resp.Output)
+	}
+	if resp.Usage.TotalTokens != 30 {
+		t.Errorf("expected usage of all samples, got %d", resp.Usage.TotalTokens)
+	}
+	if last := resp.Steps[len(resp.Steps)-1]; last.Type != "consensus" {
+		t.Errorf("expected a trailing consensus step, got %+v", last)
+	}
+}
+
+func TestSelfConsistencyAgent_StopsEarly(t *testing.T) {
+	t.Run("decided vote", func(t *testing.T) {
+		var calls atomic.Int32
+		mock := answersByTemperature(map[float32]string{0.5: "7"}, &calls)
+
+		a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+			Samples:      5,
+			MaxParallel:  1,
+			Temperatures: []float32{0.5},
+		})
+		resp, err := a.Run(context.Background(), "What is 3+4?")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected sampling to stop after 3 agreeing samples, got %d", calls.Load())
+		}
+		if result := resp.Metadata["self_consistency"].(*ConsensusResult); result.Confidence != 1 {
+			t.Errorf("expected confidence 1, got %f", result.Confidence)
+		}
+	})
+
+	t.Run("token limit", func(t *testing.T) {
+		var calls atomic.Int32
+		mock := answersByTemperature(map[float32]string{0.2: "1", 0.6: "2"}, &calls)
+
+		a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+			Samples:      6,
+			MaxParallel:  1,
+			Temperatures: []float32{0.2, 0.6},
+			MaxTokens:    25,
+		})
+		resp, err := a.Run(context.Background(), "Pick a number")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("expected sampling to stop once 25 tokens were used, got %d samples", calls.Load())
+		}
+		if resp.Usage.TotalTokens != 30 {
+			t.Errorf("expected 30 tokens, got %d", resp.Usage.TotalTokens)
+		}
+	})
+}
+
+func TestSelfConsistencyAgent_Judge(t *testing.T) {
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			if req.Temperature == 1.0 {
+				return &llm.ChatWithToolsResponse{Content: "The sentiment is negative."}, nil
+			}
+			return &llm.ChatWithToolsResponse{Content: "Mostly positive in tone."}, nil
+		},
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			if !strings.Contains(req.Messages[1].Content, "3. The sentiment is negative.") {
+				t.Errorf("expected numbered answers in the prompt, got %q", req.Messages[1].Content)
+			}
+			return &llm.ChatResponse{
+				Content: `{"answer": "positive", "supporting": [1, 2, 7], "reasoning": "two say positive"}`,
+				Usage:   llm.Usage{TotalTokens: 20},
+			}, nil
+		},
+	}
+
+	a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+		Samples:   3,
+		Consensus: ConsensusJudge,
+	})
+	resp, err := a.Run(context.Background(), "Classify: 'great, mostly'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := resp.Metadata["self_consistency"].(*ConsensusResult)
+	if resp.Output != "positive" || result.Method != ConsensusJudge {
+		t.Errorf("expected judged answer 'positive', got %q via %s", resp.Output, result.Method)
+	}
+	if result.Confidence < 0.66 || result.Confidence > 0.67 {
+		t.Errorf("expected out-of-range supporters to be ignored, got confidence %f", result.Confidence)
+	}
+}
+
+func TestSelfConsistencyAgent_Evaluator(t *testing.T) {
+	var calls atomic.Int32
+	mock := answersByTemperature(map[float32]string{0.3: "short", 0.7: "a thorough answer", 1.0: "short"}, &calls)
+
+	a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+		Samples:   3,
+		Consensus: ConsensusEvaluator,
+		Evaluator: evaluatorFunc(func(_ context.Context, input EvalInput) (Evaluation, error) {
+			return Evaluation{Score: float64(len(input.Response))}, nil
+		}),
+	})
+	resp, err := a.Run(context.Background(), "Explain")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := resp.Metadata["self_consistency"].(*ConsensusResult)
+	if resp.Output != "a thorough answer" {
+		t.Errorf("expected the highest scored answer, got %q", resp.Output)
+	}
+	if result.Confidence < 0.33 || result.Confidence > 0.34 {
+		t.Errorf("expected agreement 1/3 for the chosen answer, got %f", result.Confidence)
+	}
+}
+
+func TestSelfConsistencyAgent_Usage(t *testing.T) {
+	t.Run("evaluations", func(t *testing.T) {
+		var calls atomic.Int32
+		mock := answersByTemperature(map[float32]string{0.3: "short", 0.7: "a thorough answer", 1.0: "short"}, &calls)
+		var evaluated atomic.Int32
+		mock.ChatFunc = func(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+			evaluated.Add(1)
+			return &llm.ChatResponse{Content: `{"score": 8}`, Usage: llm.Usage{TotalTokens: 5}}, nil
+		}
+
+		a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+			Samples:     3,
+			MaxParallel: 1,
+			Consensus:   ConsensusEvaluator,
+			Evaluator:   NewLLMJudge(mock, LLMJudgeConfig{}),
+		})
+		ctx := WithBudget(context.Background(), NewBudget(BudgetLimits{MaxTokens: 35}))
+		resp, err := a.Run(ctx, "Explain")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if evaluated.Load() != 1 {
+			t.Errorf("expected evaluations to stop once the budget was spent, got %d", evaluated.Load())
+		}
+		if resp.Usage.TotalTokens != 35 {
+			t.Errorf("expected samples and evaluation usage, got %d", resp.Usage.TotalTokens)
+		}
+	})
+
+	t.Run("failed samples", func(t *testing.T) {
+		mock := &MockLLMClient{
+			ChatWithToolsFunc: func(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+				resp := &llm.ChatWithToolsResponse{Content: "7", Usage: llm.Usage{TotalTokens: 10}}
+				if req.Temperature == 0.3 {
+					resp.ToolCalls = []llm.ToolCall{{ID: "1", Name: "missing", Arguments: `{}`}}
+				}
+				return resp, nil
+			},
+		}
+
+		a := NewSelfConsistencyAgent(mock, tools.NewRegistry(), SelfConsistencyConfig{
+			Samples:      2,
+			Temperatures: []float32{0.3, 0.7},
+		})
+		resp, err := a.Run(context.Background(), "What is 3+4?")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result := resp.Metadata["self_consistency"].(*ConsensusResult)
+		if result.Samples[0].Error == "" || result.Samples[0].Usage.TotalTokens != 10 {
+			t.Errorf("expected the failed sample to keep its usage, got %+v", result.Samples[0])
+		}
+		if resp.Usage.TotalTokens != 20 {
+			t.Errorf("expected usage of both samples, got %d", resp.Usage.TotalTokens)
+		}
+	})
+}
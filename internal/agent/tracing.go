@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer returns the tracer for agent runs and their steps, looked up on each
+// use so that spans go to the current global provider. Model calls and tool
+// executions nest under them through the llm and tools packages.
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/hassan123789/go-ai-agent/internal/agent")
+}
+
+// Span attributes for agent steps, which have no GenAI convention.
+const (
+	attrIteration       = attribute.Key("agent.react.iteration")
+	attrAttempt         = attribute.Key("agent.reflexion.attempt")
+	attrScore           = attribute.Key("agent.reflexion.score")
+	attrSubtaskID       = attribute.Key("agent.subtask.id")
+	attrWorkerType      = attribute.Key("agent.subtask.worker_type")
+	attrWorker          = attribute.Key("agent.subtask.worker")
+	attrBudgetReached   = attribute.Key("agent.budget.exhausted")
+	attrRoute           = attribute.Key("agent.router.route")
+	attrRouteMethod     = attribute.Key("agent.router.method")
+	attrConfidence      = attribute.Key("agent.self_consistency.confidence")
+	attrTurn            = attribute.Key("agent.group_chat.turn")
+	attrSpeaker         = attribute.Key("agent.group_chat.speaker")
+	attrMemoryRecalled  = attribute.Key("agent.memory.recalled")
+	attrCascadeTier     = attribute.Key("agent.cascade.tier")
+	attrCascadeAttempts = attribute.Key("agent.cascade.attempts")
+)
+
+// startAgentSpan starts an "invoke_agent {name}" span for a run.
+func startAgentSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "invoke_agent "+name, trace.WithAttributes(
+		semconv.GenAIOperationNameInvokeAgent,
+		semconv.GenAIAgentName(name),
+	))
+}
+
+// endAgentSpan records a run's token usage and outcome and ends its span.
+func endAgentSpan(span trace.Span, resp *Response, err error) {
+	if resp != nil {
+		span.SetAttributes(
+			semconv.GenAIUsageInputTokens(resp.Usage.PromptTokens),
+			semconv.GenAIUsageOutputTokens(resp.Usage.CompletionTokens),
+		)
+		if usage, ok := resp.Metadata["budget"].(BudgetUsage); ok && usage.Exhausted != "" {
+			span.SetAttributes(attrBudgetReached.String(usage.Exhausted))
+		}
+	}
+	endSpan(span, err)
+}
+
+// startSubtaskSpan starts an "orchestrator.subtask" span.
+func startSubtaskSpan(ctx context.Context, task Subtask) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "orchestrator.subtask", trace.WithAttributes(
+		attrSubtaskID.String(task.ID),
+		attrWorkerType.String(task.WorkerType),
+	))
+}
+
+// endSubtaskSpan records which worker ran a subtask, its usage and outcome,
+// and ends its span.
+func endSubtaskSpan(span trace.Span, result SubtaskResult) {
+	span.SetAttributes(
+		attrWorker.String(result.Worker),
+		semconv.GenAIUsageInputTokens(result.Usage.PromptTokens),
+		semconv.GenAIUsageOutputTokens(result.Usage.CompletionTokens),
+	)
+	if !result.Success {
+		span.SetStatus(codes.Error, result.Error)
+	}
+	span.End()
+}
+
+// endSpan marks the span failed if err is set and ends it. A nil span is ignored.
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/telemetry"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// spansByName indexes recorded spans by name, keeping the last of each.
+func spansByName(spans tracetest.SpanStubs) map[string]tracetest.SpanStub {
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	return byName
+}
+
+func TestReActAgent_Tracing(t *testing.T) {
+	exporter, restore := telemetry.SetupInMemory()
+	defer restore()
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+
+	calls := 0
+	mock := &MockLLMClient{
+		ChatWithToolsFunc: func(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+			calls++
+			if calls == 1 {
+				return &llm.ChatWithToolsResponse{
+					ToolCalls: []llm.ToolCall{{ID: "1", Name: "calculator", Arguments: `{"expression": "2+2"}`}},
+					Usage:     llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				}, nil
+			}
+			return &llm.ChatWithToolsResponse{Content: "4", Usage: llm.Usage{PromptTokens: 20, CompletionTokens: 1, TotalTokens: 21}}, nil
+		},
+	}
+
+	a := NewReActAgent(mock, registry, Config{})
+	if _, err := a.Run(context.Background(), "What is 2+2?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected run, 2 iteration and 1 tool span, got %d", len(spans))
+	}
+	byName := spansByName(spans)
+
+	run, ok := byName["invoke_agent react"]
+	if !ok {
+		t.Fatalf("missing run span in %v", spans)
+	}
+	tool, ok := byName["execute_tool calculator"]
+	if !ok {
+		t.Fatalf("missing tool span in %v", spans)
+	}
+
+	// The tool ran in the first iteration, under the run
+	var firstIteration tracetest.SpanStub
+	for _, s := range spans {
+		if s.Name == "react.iteration" && s.SpanContext.SpanID() == tool.Parent.SpanID() {
+			firstIteration = s
+		}
+	}
+	if !firstIteration.SpanContext.IsValid() {
+		t.Fatal("expected tool span to be a child of an iteration span")
+	}
+	if firstIteration.Parent.SpanID() != run.SpanContext.SpanID() {
+		t.Error("expected iteration span to be a child of the run span")
+	}
+
+	for _, kv := range run.Attributes {
+		if kv.Key == "gen_ai.usage.input_tokens" && kv.Value.AsInt64() != 30 {
+			t.Errorf("expected 30 input tokens on run span, got %d", kv.Value.AsInt64())
+		}
+	}
+}
+
+func TestOrchestratorAgent_Tracing(t *testing.T) {
+	exporter, restore := telemetry.SetupInMemory()
+	defer restore()
+
+	mock := &MockLLMClient{
+		ChatFunc: func(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+			if req.Messages[0].Content == defaultOrchestratorPrompt {
+				return &llm.ChatResponse{Content: `{"analysis": "two steps", "subtasks": [
+					{"id": "task_1", "description": "first", "worker_type": "general", "input": "one"},
+					{"id": "task_2", "description": "second", "worker_type": "writer", "input": "two"}
+				]}`}, nil
+			}
+			return &llm.ChatResponse{Content: "done"}, nil
+		},
+	}
+
+	o := NewOrchestratorAgent(mock, tools.NewRegistry(), OrchestratorConfig{})
+	if _, err := o.Run(context.Background(), "do two things"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var run tracetest.SpanStub
+	subtasks := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		switch s.Name {
+		case "invoke_agent orchestrator":
+			run = s
+		case "orchestrator.subtask":
+			for _, kv := range s.Attributes {
+				if kv.Key == attrSubtaskID {
+					subtasks[kv.Value.AsString()] = s
+				}
+			}
+		}
+	}
+
+	if len(subtasks) != 2 {
+		t.Fatalf("expected 2 subtask spans, got %d", len(subtasks))
+	}
+	for id, s := range subtasks {
+		if s.Parent.SpanID() != run.SpanContext.SpanID() {
+			t.Errorf("expected %s span to be a child of the run span", id)
+		}
+	}
+}
@@ -0,0 +1,630 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// TreeOfThoughtsAgent implements search-based reasoning over intermediate thoughts.
+// Instead of committing to a single trajectory like ReAct, it proposes several
+// candidate next thoughts, scores them, and searches the resulting tree.
+//
+// References:
+//   - Yao et al., 2023 - "Tree of Thoughts: Deliberate Problem Solving with Large Language Models"
+//     https://arxiv.org/abs/2305.10601
+//   - Zhou et al., 2023 - "Language Agent Tree Search" (LATS)
+//     https://arxiv.org/abs/2310.04406
+//
+// Flow:
+//  1. Generate BranchingFactor candidate thoughts from the current state
+//  2. Score each candidate with a value function
+//  3. Select states to expand according to the search strategy
+//  4. Return the best complete solution found within the budget
+type TreeOfThoughtsAgent struct {
+	llm       LLMClient
+	config    ToTConfig
+	evaluator *ReflexionAgent
+}
+
+// SearchStrategy selects how the thought tree is explored.
+type SearchStrategy string
+
+const (
+	// SearchBFS expands every frontier node level by level.
+	SearchBFS SearchStrategy = "bfs"
+	// SearchDFS follows the most promising branch first and backtracks on low scores.
+	SearchDFS SearchStrategy = "dfs"
+	// SearchBeam expands level by level, keeping only the BeamWidth best nodes.
+	SearchBeam SearchStrategy = "beam"
+	// SearchMCTS uses Monte Carlo Tree Search with UCT selection (LATS-style).
+	SearchMCTS SearchStrategy = "mcts"
+)
+
+// ToTConfig contains configuration for the Tree-of-Thoughts agent.
+type ToTConfig struct {
+	Config
+
+	// Strategy is the search strategy. Default is beam.
+	Strategy SearchStrategy
+
+	// BranchingFactor is the number of candidate thoughts generated per expansion.
+	BranchingFactor int
+
+	// MaxDepth is the maximum number of reasoning steps in a path.
+	MaxDepth int
+
+	// BeamWidth is the number of nodes kept per level for beam search.
+	BeamWidth int
+
+	// MaxNodes bounds the total number of thoughts generated (the search budget).
+	MaxNodes int
+
+	// PruneThreshold is the minimum score (0-10) for DFS to keep exploring a branch.
+	PruneThreshold float64
+
+	// SolutionThreshold is the score (0-10) at which a final thought ends the search early.
+	SolutionThreshold float64
+
+	// ExplorationWeight is the UCT exploration constant for MCTS.
+	ExplorationWeight float64
+
+	// UseReflexionValue scores final answers with the Reflexion evaluator
+	// instead of the default thought-value prompt.
+	UseReflexionValue bool
+}
+
+// ThoughtNode is a node in the explored thought tree.
+type ThoughtNode struct {
+	ID       string         `json:"id"`
+	Thought  string         `json:"thought"`
+	Depth    int            `json:"depth"`
+	Score    float64        `json:"score"`
+	IsFinal  bool           `json:"is_final"`
+	Pruned   bool           `json:"pruned,omitempty"` // Its next thoughts could not be parsed
+	Visits   int            `json:"visits,omitempty"`
+	Value    float64        `json:"value,omitempty"`
+	Children []*ThoughtNode `json:"children,omitempty"`
+
+	parent *ThoughtNode
+}
+
+// NewTreeOfThoughtsAgent creates a new Tree-of-Thoughts agent.
+func NewTreeOfThoughtsAgent(llmClient LLMClient, config ToTConfig) *TreeOfThoughtsAgent {
+	if config.Strategy == "" {
+		config.Strategy = SearchBeam
+	}
+	if config.BranchingFactor <= 0 {
+		config.BranchingFactor = 3
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = 3
+	}
+	if config.BeamWidth <= 0 {
+		config.BeamWidth = 2
+	}
+	if config.MaxNodes <= 0 {
+		config.MaxNodes = 30
+	}
+	if config.PruneThreshold <= 0 {
+		config.PruneThreshold = 5.0
+	}
+	if config.SolutionThreshold <= 0 {
+		config.SolutionThreshold = 9.0
+	}
+	if config.ExplorationWeight <= 0 {
+		config.ExplorationWeight = 1.4
+	}
+	if config.SystemPrompt == "" {
+		config.SystemPrompt = defaultToTSystemPrompt
+	}
+
+	llmClient = metered(llmClient)
+	a := &TreeOfThoughtsAgent{
+		llm:    llmClient,
+		config: config,
+	}
+	if config.UseReflexionValue {
+		a.evaluator = NewReflexionAgent(llmClient, nil, ReflexionConfig{})
+	}
+	return a
+}
+
+const defaultToTSystemPrompt = `You are a careful problem solver who reasons one step at a time.
+Each step should make concrete progress toward the solution.`
+
+const defaultThoughtGenerationPrompt = `Problem: %s
+
+Reasoning so far:
+%s
+
+Propose %d different possible next steps. Each step should be a single, concrete
+reasoning step. If a step states the complete final answer, mark it as final.
+
+Respond with a JSON object:
+{
+  "thoughts": [
+    {"content": "next step", "is_final": false}
+  ]
+}`
+
+const defaultThoughtValuePrompt = `Problem: %s
+
+Reasoning so far:
+%s
+
+Evaluate how likely this line of reasoning is to reach a correct solution.
+Penalize mistakes, dead ends and unjustified leaps.
+
+Respond with a JSON object:
+{
+  "score": <0-10>,
+  "reasoning": "short justification"
+}`
+
+const defaultThoughtAnswerPrompt = `Problem: %s
+
+Reasoning:
+%s
+
+Using the reasoning above, state the final answer clearly and concisely.`
+
+// searchState tracks progress and budget during a single run.
+type searchState struct {
+	query string
+	root  *ThoughtNode
+	nodes int
+	usage Usage
+	best  *ThoughtNode
+}
+
+// Run processes a query using tree search over thoughts.
+func (a *TreeOfThoughtsAgent) Run(ctx context.Context, query string) (*Response, error) {
+	return a.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory processes a query with conversation history.
+// History is folded into the problem statement. The run is traced as an
+// "invoke_agent tree_of_thoughts" span.
+func (a *TreeOfThoughtsAgent) RunWithHistory(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx, span := startAgentSpan(ctx, "tree_of_thoughts")
+	resp, err := withMemory(ctx, a.config.Config, history, query, a.run)
+	endAgentSpan(span, resp, err)
+	return resp, err
+}
+
+// run implements RunWithHistory.
+func (a *TreeOfThoughtsAgent) run(ctx context.Context, history []Message, query string) (*Response, error) {
+	ctx = guardrailContext(ctx, a.config.Guardrails)
+	query, err := a.config.Guardrails.CheckInput(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	problem := query
+	if len(history) > 0 {
+		var sb strings.Builder
+		sb.WriteString("Conversation so far:\n")
+		for _, m := range history {
+			sb.WriteString(fmt.Sprintf("%s: %s\n", m.Role, m.Content))
+		}
+		sb.WriteString("\nCurrent question: ")
+		sb.WriteString(query)
+		problem = sb.String()
+	}
+
+	state := &searchState{
+		query: problem,
+		root:  &ThoughtNode{ID: "root"},
+	}
+
+	if a.config.Verbose {
+		log.Printf("[ToT] Searching with %s (branching=%d, depth=%d, budget=%d)",
+			a.config.Strategy, a.config.BranchingFactor, a.config.MaxDepth, a.config.MaxNodes)
+	}
+
+	switch a.config.Strategy {
+	case SearchBFS:
+		err = a.searchLevels(ctx, state, 0)
+	case SearchBeam:
+		err = a.searchLevels(ctx, state, a.config.BeamWidth)
+	case SearchDFS:
+		err = a.searchDFS(ctx, state, state.root)
+	case SearchMCTS:
+		err = a.searchMCTS(ctx, state)
+	default:
+		return nil, fmt.Errorf("unknown search strategy %q", a.config.Strategy)
+	}
+	if err != nil && !errors.Is(err, errSearchDone) {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	if state.best == nil {
+		return nil, errors.New("search produced no thoughts")
+	}
+
+	path := pathTo(state.best)
+	output := state.best.Thought
+	if !state.best.IsFinal {
+		answer, usage, err := a.answer(ctx, problem, path)
+		if err != nil {
+			return nil, fmt.Errorf("final answer failed: %w", err)
+		}
+		state.usage = addUsage(state.usage, usage)
+		output = answer
+	}
+
+	steps := make([]Step, 0, len(path)+1)
+	bestPath := make([]string, 0, len(path))
+	for _, n := range path {
+		steps = append(steps, Step{
+			Type:    StepTypeThought,
+			Content: n.Thought,
+		})
+		bestPath = append(bestPath, n.ID)
+	}
+
+	if a.config.Verbose {
+		log.Printf("[ToT] Explored %d nodes, best score %.1f", state.nodes, state.best.Score)
+	}
+
+	output, err = a.config.Guardrails.CheckOutput(ctx, output)
+	if err != nil {
+		return nil, err
+	}
+
+	return withBudgetUsage(ctx, withGuardrailViolations(ctx, &Response{
+		Output: output,
+		Steps:  steps,
+		Usage:  state.usage,
+		Metadata: map[string]any{
+			"tree_of_thoughts": map[string]any{
+				"strategy":       a.config.Strategy,
+				"nodes_explored": state.nodes,
+				"best_path":      bestPath,
+				"best_score":     state.best.Score,
+				"tree":           state.root,
+			},
+		},
+	})), nil
+}
+
+// errSearchDone signals that the search found a solution or exhausted its budget.
+var errSearchDone = errors.New("search done")
+
+// errThoughtFormat is returned by generate when the model's reply cannot be
+// parsed as thoughts.
+var errThoughtFormat = errors.New("failed to parse thoughts")
+
+// searchLevels runs BFS (width <= 0) or beam search (width > 0) level by level.
+func (a *TreeOfThoughtsAgent) searchLevels(ctx context.Context, state *searchState, width int) error {
+	frontier := []*ThoughtNode{state.root}
+
+	for depth := 0; depth < a.config.MaxDepth && len(frontier) > 0; depth++ {
+		var next []*ThoughtNode
+		for _, node := range frontier {
+			children, err := a.expand(ctx, state, node)
+			if err != nil {
+				return err
+			}
+			for _, c := range children {
+				if !c.IsFinal {
+					next = append(next, c)
+				}
+			}
+		}
+
+		sort.SliceStable(next, func(i, j int) bool {
+			return next[i].Score > next[j].Score
+		})
+		if width > 0 && len(next) > width {
+			next = next[:width]
+		}
+		frontier = next
+	}
+
+	return nil
+}
+
+// searchDFS explores the highest-scoring child first and backtracks on low scores.
+func (a *TreeOfThoughtsAgent) searchDFS(ctx context.Context, state *searchState, node *ThoughtNode) error {
+	if node.Depth >= a.config.MaxDepth {
+		return nil
+	}
+
+	children, err := a.expand(ctx, state, node)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Score > children[j].Score
+	})
+
+	for _, c := range children {
+		if c.IsFinal || c.Score < a.config.PruneThreshold {
+			continue
+		}
+		if err := a.searchDFS(ctx, state, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// searchMCTS runs Monte Carlo Tree Search until the budget is spent.
+func (a *TreeOfThoughtsAgent) searchMCTS(ctx context.Context, state *searchState) error {
+	// Selecting terminal nodes spends iterations without generating thoughts,
+	// so iterations are bounded separately from the node budget.
+	for iter := 0; iter < 2*a.config.MaxNodes && state.nodes < a.config.MaxNodes; iter++ {
+		// Selection
+		node := state.root
+		for len(node.Children) > 0 {
+			node = a.selectUCT(node)
+		}
+
+		if node.Pruned {
+			// Dead end: discourage the path and try another
+			a.backpropagate(node, 0)
+			continue
+		}
+		if node.IsFinal || node.Depth >= a.config.MaxDepth {
+			// Terminal: reinforce its value and try another path
+			a.backpropagate(node, node.Score)
+			continue
+		}
+
+		// Expansion and evaluation
+		children, err := a.expand(ctx, state, node)
+		if err != nil {
+			return err
+		}
+		if node.Pruned {
+			a.backpropagate(node, 0)
+			continue
+		}
+		if len(children) == 0 {
+			return nil
+		}
+
+		// Backpropagation of the best child's value
+		bestChild := children[0]
+		for _, c := range children[1:] {
+			if c.Score > bestChild.Score {
+				bestChild = c
+			}
+		}
+		for _, c := range children {
+			c.Visits = 1
+			c.Value = c.Score
+		}
+		a.backpropagate(node, bestChild.Score)
+	}
+
+	return nil
+}
+
+// selectUCT picks the child maximizing the UCT score.
+func (a *TreeOfThoughtsAgent) selectUCT(node *ThoughtNode) *ThoughtNode {
+	var best *ThoughtNode
+	bestUCT := math.Inf(-1)
+	parentVisits := float64(node.Visits)
+	if parentVisits < 1 {
+		parentVisits = 1
+	}
+
+	for _, c := range node.Children {
+		visits := float64(c.Visits)
+		if visits == 0 {
+			return c
+		}
+		exploit := c.Value / visits / 10
+		explore := a.config.ExplorationWeight * math.Sqrt(math.Log(parentVisits)/visits)
+		if uct := exploit + explore; uct > bestUCT {
+			bestUCT = uct
+			best = c
+		}
+	}
+
+	return best
+}
+
+// backpropagate adds a value to a node and all its ancestors.
+func (a *TreeOfThoughtsAgent) backpropagate(node *ThoughtNode, value float64) {
+	for n := node; n != nil; n = n.parent {
+		n.Visits++
+		n.Value += value
+	}
+}
+
+// thoughtCandidate is a single generated thought.
+type thoughtCandidate struct {
+	Content string `json:"content"`
+	IsFinal bool   `json:"is_final"`
+}
+
+// expand generates and scores children for a node, respecting the budget.
+// A node whose next thoughts cannot be parsed is pruned as a dead end.
+func (a *TreeOfThoughtsAgent) expand(ctx context.Context, state *searchState, node *ThoughtNode) ([]*ThoughtNode, error) {
+	if state.nodes >= a.config.MaxNodes {
+		return nil, errSearchDone
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	candidates, usage, err := a.generate(ctx, state.query, pathTo(node))
+	state.usage = addUsage(state.usage, usage)
+	if errors.Is(err, errThoughtFormat) {
+		if a.config.Verbose {
+			log.Printf("[ToT] Pruning %s: %v", node.ID, err)
+		}
+		node.Pruned = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*ThoughtNode, 0, len(candidates))
+	for _, cand := range candidates {
+		if state.nodes >= a.config.MaxNodes {
+			break
+		}
+		state.nodes++
+
+		child := &ThoughtNode{
+			ID:      fmt.Sprintf("%s.%d", node.ID, len(node.Children)+1),
+			Thought: cand.Content,
+			Depth:   node.Depth + 1,
+			IsFinal: cand.IsFinal,
+			parent:  node,
+		}
+		node.Children = append(node.Children, child)
+
+		score, usage, err := a.score(ctx, state.query, child)
+		state.usage = addUsage(state.usage, usage)
+		if err != nil {
+			if a.config.Verbose {
+				log.Printf("[ToT] Scoring failed for %s: %v", child.ID, err)
+			}
+		}
+		child.Score = score
+		children = append(children, child)
+
+		if a.config.Verbose {
+			log.Printf("[ToT] %s (%.1f): %s", child.ID, child.Score, truncate(child.Thought, 80))
+		}
+
+		if state.best == nil || betterThought(child, state.best) {
+			state.best = child
+		}
+		if child.IsFinal && child.Score >= a.config.SolutionThreshold {
+			return children, errSearchDone
+		}
+	}
+
+	return children, nil
+}
+
+// betterThought reports whether a should replace b as the best node.
+// Final answers are preferred over partial reasoning.
+func betterThought(a, b *ThoughtNode) bool {
+	if a.IsFinal != b.IsFinal {
+		return a.IsFinal
+	}
+	return a.Score > b.Score
+}
+
+// generate proposes candidate next thoughts.
+func (a *TreeOfThoughtsAgent) generate(ctx context.Context, query string, path []*ThoughtNode) ([]thoughtCandidate, Usage, error) {
+	prompt := fmt.Sprintf(defaultThoughtGenerationPrompt, query, formatThoughtPath(path), a.config.BranchingFactor)
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: a.config.SystemPrompt},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Temperature: 0.8, // Higher temperature for diverse candidates
+	})
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("thought generation failed: %w", err)
+	}
+	usage := toUsage(resp.Usage)
+
+	var parsed struct {
+		Thoughts []thoughtCandidate `json:"thoughts"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &parsed); err != nil {
+		return nil, usage, fmt.Errorf("%w: %v", errThoughtFormat, err)
+	}
+
+	if len(parsed.Thoughts) > a.config.BranchingFactor {
+		parsed.Thoughts = parsed.Thoughts[:a.config.BranchingFactor]
+	}
+
+	return parsed.Thoughts, usage, nil
+}
+
+// score evaluates a node with the configured value function.
+func (a *TreeOfThoughtsAgent) score(ctx context.Context, query string, node *ThoughtNode) (float64, Usage, error) {
+	if node.IsFinal && a.evaluator != nil {
+		// Evaluators do not report usage, so their metered calls are tallied
+		evalCtx, tally := withUsageTally(ctx)
+		eval, err := a.evaluator.evaluate(evalCtx, EvalInput{Query: query, Response: node.Thought})
+		if err != nil {
+			return 0, tally.total(), err
+		}
+		return eval.Score, tally.total(), nil
+	}
+
+	prompt := fmt.Sprintf(defaultThoughtValuePrompt, query, formatThoughtPath(pathTo(node)))
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a critical evaluator. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return 0, Usage{}, fmt.Errorf("thought evaluation failed: %w", err)
+	}
+	usage := toUsage(resp.Usage)
+
+	var eval Evaluation
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &eval); err != nil {
+		return 0, usage, fmt.Errorf("failed to parse thought evaluation: %w", err)
+	}
+
+	return eval.Score, usage, nil
+}
+
+// answer turns a partial reasoning path into a final answer.
+func (a *TreeOfThoughtsAgent) answer(ctx context.Context, query string, path []*ThoughtNode) (string, Usage, error) {
+	prompt := fmt.Sprintf(defaultThoughtAnswerPrompt, query, formatThoughtPath(path))
+
+	resp, err := a.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: a.config.SystemPrompt},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+	})
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	return resp.Content, toUsage(resp.Usage), nil
+}
+
+// pathTo returns the thoughts from the root (exclusive) to node (inclusive).
+func pathTo(node *ThoughtNode) []*ThoughtNode {
+	var path []*ThoughtNode
+	for n := node; n != nil && n.parent != nil; n = n.parent {
+		path = append(path, n)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// formatThoughtPath renders a reasoning path as numbered steps.
+func formatThoughtPath(path []*ThoughtNode) string {
+	if len(path) == 0 {
+		return "(no steps yet)"
+	}
+	var sb strings.Builder
+	for i, n := range path {
+		sb.WriteString(fmt.Sprintf("Step %d: %s\n", i+1, n.Thought))
+	}
+	return sb.String()
+}
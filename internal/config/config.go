@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application.
@@ -15,24 +17,111 @@ type Config struct {
 	OpenAIAPIKey string
 	OpenAIModel  string
 
+	// Hedged chat settings; HedgeProvider (claude or ollama) enables hedging
+	HedgeProvider   string
+	HedgeModel      string
+	AnthropicAPIKey string
+	OllamaBaseURL   string
+
 	// Application settings
 	Environment string
 	LogLevel    string
 
+	// AgentsFile is an optional YAML or JSON file of agent definitions
+	AgentsFile string
+
+	// ReflectionsFile persists Reflexion reflections; empty keeps them in memory
+	ReflectionsFile string
+
+	// KnowledgeDir holds .md and .txt files indexed for /api/rag/query and the knowledge_search tool
+	KnowledgeDir string
+
+	// KnowledgeChunkSize is the maximum characters per indexed chunk
+	KnowledgeChunkSize int
+
+	// RecordDir stores a replayable trace of every agent run; empty disables recording
+	RecordDir string
+
 	ServerPort     int
 	OpenAIMaxToken int
+
+	// HedgeDelay is how long /api/chat waits for OpenAI before also asking HedgeProvider
+	HedgeDelay time.Duration
+
+	// SessionMaxMessages bounds the messages kept per conversation session
+	SessionMaxMessages int
+
+	// GuardrailsEnabled turns on prompt-injection, PII and length guardrails
+	GuardrailsEnabled bool
+
+	// GuardrailMaxInputChars limits each input message; zero disables the limit
+	GuardrailMaxInputChars int
+
+	// GuardrailBannedTopics are rejected using an LLM topic classifier. The
+	// classifier needs the whole answer, so it buffers streamed chat output
+	GuardrailBannedTopics []string
+
+	// Default per-run budget for agent requests; zero values are unlimited
+	RunMaxTokens    int
+	RunMaxCost      float64
+	RunTimeout      time.Duration
+	RunMaxToolCalls int
+
+	// TraceExporter selects the OpenTelemetry exporter: none, stdout or otlp
+	TraceExporter string
+
+	// TraceEndpoint is the OTLP/HTTP collector address; empty uses OTEL_EXPORTER_OTLP_* variables
+	TraceEndpoint string
+
+	// TraceInsecure disables TLS for the OTLP exporter
+	TraceInsecure bool
+
+	// TraceSampleRatio is the fraction of new traces recorded; zero records all
+	TraceSampleRatio float64
+
+	// ServiceName identifies this service in traces
+	ServiceName string
 }
 
 // Load reads configuration from environment variables.
 func Load() (*Config, error) {
 	cfg := &Config{
-		ServerPort:     getEnvInt("SERVER_PORT", 8080),
-		ServerHost:     getEnv("SERVER_HOST", "0.0.0.0"),
-		OpenAIAPIKey:   getEnv("OPENAI_API_KEY", ""),
-		OpenAIModel:    getEnv("OPENAI_MODEL", "gpt-4o-mini"),
-		OpenAIMaxToken: getEnvInt("OPENAI_MAX_TOKENS", 2048),
-		Environment:    getEnv("ENVIRONMENT", "development"),
-		LogLevel:       getEnv("LOG_LEVEL", "info"),
+		ServerPort:      getEnvInt("SERVER_PORT", 8080),
+		ServerHost:      getEnv("SERVER_HOST", "0.0.0.0"),
+		OpenAIAPIKey:    getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:     getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAIMaxToken:  getEnvInt("OPENAI_MAX_TOKENS", 2048),
+		Environment:     getEnv("ENVIRONMENT", "development"),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
+		AgentsFile:      getEnv("AGENTS_FILE", ""),
+		ReflectionsFile: getEnv("REFLECTIONS_FILE", ""),
+		RecordDir:       getEnv("RECORD_DIR", ""),
+		KnowledgeDir:    getEnv("KNOWLEDGE_DIR", ""),
+
+		KnowledgeChunkSize: getEnvInt("KNOWLEDGE_CHUNK_SIZE", 1000),
+
+		HedgeProvider:   getEnv("HEDGE_PROVIDER", ""),
+		HedgeModel:      getEnv("HEDGE_MODEL", ""),
+		HedgeDelay:      time.Duration(getEnvInt("HEDGE_DELAY_MS", 500)) * time.Millisecond,
+		AnthropicAPIKey: getEnv("ANTHROPIC_API_KEY", ""),
+		OllamaBaseURL:   getEnv("OLLAMA_BASE_URL", ""),
+
+		SessionMaxMessages: getEnvInt("SESSION_MAX_MESSAGES", 100),
+
+		GuardrailsEnabled:      getEnvBool("GUARDRAILS_ENABLED", true),
+		GuardrailMaxInputChars: getEnvInt("GUARDRAIL_MAX_INPUT_CHARS", 20000),
+		GuardrailBannedTopics:  getEnvList("GUARDRAIL_BANNED_TOPICS"),
+
+		RunMaxTokens:    getEnvInt("RUN_MAX_TOKENS", 0),
+		RunMaxCost:      getEnvFloat("RUN_MAX_COST", 0),
+		RunTimeout:      time.Duration(getEnvInt("RUN_TIMEOUT_SECONDS", 0)) * time.Second,
+		RunMaxToolCalls: getEnvInt("RUN_MAX_TOOL_CALLS", 0),
+
+		TraceExporter:    getEnv("TRACE_EXPORTER", "none"),
+		TraceEndpoint:    getEnv("TRACE_OTLP_ENDPOINT", ""),
+		TraceInsecure:    getEnvBool("TRACE_OTLP_INSECURE", false),
+		TraceSampleRatio: getEnvFloat("TRACE_SAMPLE_RATIO", 0),
+		ServiceName:      getEnv("OTEL_SERVICE_NAME", "go-ai-agent"),
 	}
 
 	if err := cfg.validate(); err != nil {
@@ -46,6 +135,15 @@ func (c *Config) validate() error {
 	if c.OpenAIAPIKey == "" {
 		return fmt.Errorf("OPENAI_API_KEY is required")
 	}
+	switch c.HedgeProvider {
+	case "", "ollama":
+	case "claude":
+		if c.AnthropicAPIKey == "" {
+			return fmt.Errorf("ANTHROPIC_API_KEY is required for HEDGE_PROVIDER=claude")
+		}
+	default:
+		return fmt.Errorf("unsupported HEDGE_PROVIDER %q", c.HedgeProvider)
+	}
 	return nil
 }
 
@@ -74,3 +172,32 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,72 @@
+// Package eval measures agent quality by running a dataset of queries with
+// expected answers through an agent, scoring each answer and comparing the
+// resulting report against a baseline run.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Case is a single evaluation example.
+type Case struct {
+	// ID identifies the case across runs. Default is the line number.
+	ID string `json:"id,omitempty"`
+
+	// Query is sent to the agent.
+	Query string `json:"query"`
+
+	// Expected is the expected answer.
+	Expected string `json:"expected"`
+
+	// ExpectedTools lists the tools the agent should call, in any order.
+	// Omitted, tool use is not scored; an empty list expects no tool calls.
+	ExpectedTools []string `json:"expected_tools,omitempty"`
+
+	// Tags group cases in reports.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// LoadDataset reads cases from a JSONL file, one case per line.
+// Blank lines and lines starting with # are skipped.
+func LoadDataset(path string) ([]Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var cases []Case
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var c Case
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if c.Query == "" {
+			return nil, fmt.Errorf("%s:%d: query is required", path, line)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("line_%d", line)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("%s:%d: duplicate id %q", path, line, c.ID)
+		}
+		seen[c.ID] = true
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read dataset: %w", err)
+	}
+	return cases, nil
+}
@@ -0,0 +1,213 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the result of an evaluation run. Its JSON form is the baseline
+// format read by ReadReport.
+type Report struct {
+	Agent     string       `json:"agent,omitempty"`
+	Dataset   string       `json:"dataset,omitempty"`
+	StartedAt time.Time    `json:"started_at"`
+	Duration  string       `json:"duration"`
+	Threshold float64      `json:"threshold"`
+	Summary   Summary      `json:"summary"`
+	Results   []CaseResult `json:"results"`
+}
+
+// Summary aggregates the case results of a run.
+type Summary struct {
+	Cases         int                `json:"cases"`
+	Passed        int                `json:"passed"`
+	Errors        int                `json:"errors"`
+	PassRate      float64            `json:"pass_rate"`
+	MeanScore     float64            `json:"mean_score"`
+	Scores        map[string]float64 `json:"scores"` // Mean per scorer over the cases it applied to
+	MeanLatencyMs int64              `json:"mean_latency_ms"`
+	TotalTokens   int                `json:"total_tokens"`
+}
+
+// Regression is a metric or case that got worse than the baseline.
+type Regression struct {
+	// Case is the case ID, or empty for a summary metric.
+	Case string `json:"case,omitempty"`
+
+	// Metric names what regressed, e.g. "pass_rate", "scores.fuzzy" or "passed".
+	Metric string `json:"metric"`
+
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+}
+
+// String describes the regression.
+func (r Regression) String() string {
+	if r.Case != "" {
+		return fmt.Sprintf("%s: %s %.2f -> %.2f", r.Case, r.Metric, r.Baseline, r.Current)
+	}
+	return fmt.Sprintf("%s %.2f -> %.2f", r.Metric, r.Baseline, r.Current)
+}
+
+// summarize aggregates case results.
+func summarize(results []CaseResult) Summary {
+	s := Summary{Cases: len(results), Scores: make(map[string]float64)}
+	if len(results) == 0 {
+		return s
+	}
+
+	counts := make(map[string]int)
+	var latency int64
+	var total float64
+	for _, r := range results {
+		if r.Passed {
+			s.Passed++
+		}
+		if r.Error != "" {
+			s.Errors++
+		}
+		for name, score := range r.Scores {
+			s.Scores[name] += score.Value
+			counts[name]++
+		}
+		total += r.Score
+		latency += r.LatencyMs
+		s.TotalTokens += r.Tokens
+	}
+	for name, n := range counts {
+		s.Scores[name] /= float64(n)
+	}
+	s.PassRate = float64(s.Passed) / float64(len(results))
+	s.MeanScore = total / float64(len(results))
+	s.MeanLatencyMs = latency / int64(len(results))
+	return s
+}
+
+// Compare lists the regressions of current against baseline: summary scores
+// that dropped by more than tolerance, and cases that passed in the baseline
+// but fail now. Cases missing from either run are ignored.
+func Compare(baseline, current *Report, tolerance float64) []Regression {
+	var regressions []Regression
+	check := func(metric string, before, after float64) {
+		if before-after > tolerance {
+			regressions = append(regressions, Regression{Metric: metric, Baseline: before, Current: after})
+		}
+	}
+
+	check("pass_rate", baseline.Summary.PassRate, current.Summary.PassRate)
+	check("mean_score", baseline.Summary.MeanScore, current.Summary.MeanScore)
+	for _, name := range sortedKeys(baseline.Summary.Scores) {
+		if after, ok := current.Summary.Scores[name]; ok {
+			check("scores."+name, baseline.Summary.Scores[name], after)
+		}
+	}
+
+	before := make(map[string]CaseResult, len(baseline.Results))
+	for _, r := range baseline.Results {
+		before[r.ID] = r
+	}
+	for _, r := range current.Results {
+		if b, ok := before[r.ID]; ok && b.Passed && !r.Passed {
+			regressions = append(regressions, Regression{Case: r.ID, Metric: "passed", Baseline: b.Score, Current: r.Score})
+		}
+	}
+	return regressions
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadReport reads a report written by WriteJSON.
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse report %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Markdown renders the report, followed by the regressions if any, as a
+// Markdown document.
+func (r *Report) Markdown(regressions []Regression) string {
+	var sb strings.Builder
+	sb.WriteString("# Agent Evaluation\n\n")
+	if r.Agent != "" {
+		fmt.Fprintf(&sb, "- Agent: `%s`\n", r.Agent)
+	}
+	if r.Dataset != "" {
+		fmt.Fprintf(&sb, "- Dataset: `%s`\n", r.Dataset)
+	}
+	fmt.Fprintf(&sb, "- Started: %s (%s)\n\n", r.StartedAt.Format(time.RFC3339), r.Duration)
+
+	s := r.Summary
+	sb.WriteString("## Summary\n\n| Metric | Value |\n|---|---|\n")
+	fmt.Fprintf(&sb, "| Cases | %d |\n", s.Cases)
+	fmt.Fprintf(&sb, "| Passed | %d (%.1f%%, threshold %.2f) |\n", s.Passed, s.PassRate*100, r.Threshold)
+	fmt.Fprintf(&sb, "| Errors | %d |\n", s.Errors)
+	fmt.Fprintf(&sb, "| Mean score | %.3f |\n", s.MeanScore)
+	for _, name := range sortedKeys(s.Scores) {
+		fmt.Fprintf(&sb, "| %s | %.3f |\n", name, s.Scores[name])
+	}
+	fmt.Fprintf(&sb, "| Mean latency | %d ms |\n", s.MeanLatencyMs)
+	fmt.Fprintf(&sb, "| Total tokens | %d |\n\n", s.TotalTokens)
+
+	if len(regressions) > 0 {
+		sb.WriteString("## Regressions\n\n")
+		for _, reg := range regressions {
+			fmt.Fprintf(&sb, "- %s\n", reg)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Cases\n\n| ID | Passed | Score | Details |\n|---|---|---|---|\n")
+	for _, res := range r.Results {
+		status := "✓"
+		if !res.Passed {
+			status = "✗"
+		}
+		var details []string
+		if res.Error != "" {
+			details = append(details, "error: "+res.Error)
+		}
+		for _, name := range sortedKeys(res.Scores) {
+			score := res.Scores[name]
+			d := fmt.Sprintf("%s %.2f", name, score.Value)
+			if score.Detail != "" {
+				d += " (" + score.Detail + ")"
+			}
+			details = append(details, d)
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %.2f | %s |\n", res.ID, status, res.Score, markdownCell(strings.Join(details, "; ")))
+	}
+	return sb.String()
+}
+
+// markdownCell escapes text for a single Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
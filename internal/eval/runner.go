@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+)
+
+// Runner runs cases through an agent and scores the responses.
+type Runner struct {
+	// Agent answers the cases.
+	Agent agent.Agent
+
+	// Scorers grade each response.
+	Scorers []Scorer
+
+	// Concurrency is the number of cases run at once. Default is 4.
+	Concurrency int
+
+	// Timeout bounds each case. Zero is unlimited.
+	Timeout time.Duration
+
+	// PassThreshold is the mean score a case needs to pass. Default is 0.7.
+	PassThreshold float64
+
+	// Verbose logs each finished case.
+	Verbose bool
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	ID        string           `json:"id"`
+	Query     string           `json:"query"`
+	Expected  string           `json:"expected,omitempty"`
+	Output    string           `json:"output,omitempty"`
+	Scores    map[string]Score `json:"scores,omitempty"`
+	Score     float64          `json:"score"` // Mean of Scores
+	Passed    bool             `json:"passed"`
+	Error     string           `json:"error,omitempty"`
+	LatencyMs int64            `json:"latency_ms"`
+	Tokens    int              `json:"tokens"`
+	Tags      []string         `json:"tags,omitempty"`
+}
+
+// Run evaluates cases and returns a report with results in dataset order.
+// Agent errors fail the case; scorer errors are recorded in the score detail.
+func (r *Runner) Run(ctx context.Context, cases []Case) *Report {
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	threshold := r.PassThreshold
+	if threshold <= 0 {
+		threshold = 0.7
+	}
+
+	report := &Report{
+		StartedAt: time.Now().UTC(),
+		Threshold: threshold,
+		Results:   make([]CaseResult, len(cases)),
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, c := range cases {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := r.runCase(ctx, c, threshold)
+			report.Results[i] = result
+			if r.Verbose {
+				log.Printf("[Eval] %s: score %.2f passed=%t %s", c.ID, result.Score, result.Passed, result.Error)
+			}
+		}()
+	}
+	wg.Wait()
+
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+	report.Summary = summarize(report.Results)
+	return report
+}
+
+// runCase runs and scores a single case.
+func (r *Runner) runCase(ctx context.Context, c Case, threshold float64) CaseResult {
+	result := CaseResult{ID: c.ID, Query: c.Query, Expected: c.Expected, Tags: c.Tags}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, err := r.Agent.Run(ctx, c.Query)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Output = resp.Output
+	result.Tokens = resp.Usage.TotalTokens
+
+	result.Scores = make(map[string]Score)
+	var total float64
+	for _, s := range r.Scorers {
+		score, err := s.Score(ctx, c, resp)
+		if errors.Is(err, agent.ErrNotApplicable) {
+			continue
+		}
+		if err != nil {
+			score = Score{Detail: "error: " + err.Error()}
+		}
+		result.Scores[s.Name()] = score
+		total += score.Value
+	}
+	if len(result.Scores) > 0 {
+		result.Score = total / float64(len(result.Scores))
+		result.Passed = result.Score >= threshold
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+)
+
+// answerAgent answers from a map of query to output and errors on unknown queries.
+type answerAgent struct {
+	answers  map[string]string
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (a *answerAgent) Run(ctx context.Context, query string) (*agent.Response, error) {
+	return a.RunWithHistory(ctx, nil, query)
+}
+
+func (a *answerAgent) RunWithHistory(_ context.Context, _ []agent.Message, query string) (*agent.Response, error) {
+	n := a.inFlight.Add(1)
+	defer a.inFlight.Add(-1)
+	if n > a.peak.Load() {
+		a.peak.Store(n)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	out, ok := a.answers[query]
+	if !ok {
+		return nil, errors.New("no answer")
+	}
+	return &agent.Response{Output: out, Usage: agent.Usage{TotalTokens: 10}}, nil
+}
+
+func writeDataset(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDataset(t *testing.T) {
+	path := writeDataset(t, `{"id": "add", "query": "2+2", "expected": "4", "expected_tools": ["calculator"]}
+# comment
+
+{"query": "capital of France", "expected": "Paris"}
+`)
+	cases, err := LoadDataset(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cases) != 2 || cases[0].ID != "add" || cases[1].ID != "line_4" {
+		t.Errorf("unexpected cases %+v", cases)
+	}
+	if cases[1].ExpectedTools != nil {
+		t.Error("expected omitted tools to stay nil")
+	}
+
+	for _, bad := range []string{`{"expected": "x"}`, `{"id": "a", "query": "q"}` + "\n" + `{"id": "a", "query": "q"}`, `not json`} {
+		if _, err := LoadDataset(writeDataset(t, bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestRunner_RunAndCompare(t *testing.T) {
+	cases := []Case{
+		{ID: "a", Query: "2+2", Expected: "4"},
+		{ID: "b", Query: "capital of France", Expected: "Paris"},
+		{ID: "c", Query: "3*3", Expected: "9"},
+		{ID: "d", Query: "unknown", Expected: "x"},
+	}
+	baselineAgent := &answerAgent{answers: map[string]string{"2+2": "4", "capital of France": "Paris", "3*3": "9"}}
+	runner := &Runner{Agent: baselineAgent, Scorers: []Scorer{ExactMatch{}, FuzzyMatch{}}, Concurrency: 2}
+
+	baseline := runner.Run(context.Background(), cases)
+	if baseline.Summary.Passed != 3 || baseline.Summary.Errors != 1 || baseline.Summary.TotalTokens != 30 {
+		t.Errorf("unexpected summary %+v", baseline.Summary)
+	}
+	if baseline.Results[3].ID != "d" || baseline.Results[3].Error == "" {
+		t.Errorf("expected results in dataset order with the failure recorded, got %+v", baseline.Results[3])
+	}
+	if peak := baselineAgent.peak.Load(); peak > 2 {
+		t.Errorf("expected at most 2 cases at once, got %d", peak)
+	}
+
+	// Round-trip the baseline through JSON as the CLI does
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := baseline.WriteJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := ReadReport(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runner.Agent = &answerAgent{answers: map[string]string{"2+2": "4", "capital of France": "Lyon", "3*3": "9"}}
+	current := runner.Run(context.Background(), cases)
+
+	regressions := Compare(baseline, current, 0.05)
+	var caseRegressed, passRate bool
+	for _, r := range regressions {
+		caseRegressed = caseRegressed || (r.Case == "b" && r.Metric == "passed")
+		passRate = passRate || r.Metric == "pass_rate"
+	}
+	if !caseRegressed || !passRate {
+		t.Errorf("expected case b and pass_rate regressions, got %v", regressions)
+	}
+	if len(Compare(baseline, baseline, 0.05)) != 0 {
+		t.Error("expected no regressions against itself")
+	}
+
+	md := current.Markdown(regressions)
+	for _, want := range []string{"## Summary", "## Regressions", "| b | ✗ |", "exact"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected markdown to contain %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// Scorer grades an agent's response to a case on a 0-1 scale.
+// Scorers return agent.ErrNotApplicable when a case has nothing for them to
+// grade, e.g. no expected tools. Implementations must be safe for concurrent use.
+type Scorer interface {
+	// Name identifies the scorer in reports.
+	Name() string
+
+	// Score grades resp against c.
+	Score(ctx context.Context, c Case, resp *agent.Response) (Score, error)
+}
+
+// Score is one scorer's grade for a case.
+type Score struct {
+	Value  float64 `json:"value"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// ExactMatch scores 1 when the answer equals the expected answer after
+// agent.NormalizeAnswer, so "The total is 1,200." matches "1200".
+type ExactMatch struct{}
+
+// Name returns "exact".
+func (ExactMatch) Name() string { return "exact" }
+
+// Score compares the normalized answers.
+func (ExactMatch) Score(_ context.Context, c Case, resp *agent.Response) (Score, error) {
+	if c.Expected == "" {
+		return Score{}, agent.ErrNotApplicable
+	}
+	if agent.NormalizeAnswer(resp.Output) == agent.NormalizeAnswer(c.Expected) {
+		return Score{Value: 1}, nil
+	}
+	return Score{Value: 0, Detail: fmt.Sprintf("got %q", agent.NormalizeAnswer(resp.Output))}, nil
+}
+
+// FuzzyMatch scores the share of the expected answer's words that appear in
+// the answer. Answers that match exactly after normalization score 1.
+type FuzzyMatch struct{}
+
+// Name returns "fuzzy".
+func (FuzzyMatch) Name() string { return "fuzzy" }
+
+// wordPattern splits text into lowercase words and numbers.
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+(?:[.,]\d+)*`)
+
+// Score computes the expected-word recall.
+func (FuzzyMatch) Score(_ context.Context, c Case, resp *agent.Response) (Score, error) {
+	if c.Expected == "" {
+		return Score{}, agent.ErrNotApplicable
+	}
+	if agent.NormalizeAnswer(resp.Output) == agent.NormalizeAnswer(c.Expected) {
+		return Score{Value: 1}, nil
+	}
+
+	expected := wordPattern.FindAllString(strings.ToLower(c.Expected), -1)
+	if len(expected) == 0 {
+		return Score{}, agent.ErrNotApplicable
+	}
+	got := make(map[string]bool)
+	for _, w := range wordPattern.FindAllString(strings.ToLower(resp.Output), -1) {
+		got[w] = true
+	}
+
+	var missing []string
+	for _, w := range expected {
+		if !got[w] {
+			missing = append(missing, w)
+		}
+	}
+	score := Score{Value: float64(len(expected)-len(missing)) / float64(len(expected))}
+	if len(missing) > 0 {
+		score.Detail = "missing: " + strings.Join(missing, ", ")
+	}
+	return score, nil
+}
+
+// ToolCalls scores the F1 of the tools called against Case.ExpectedTools,
+// counting repeated calls.
+type ToolCalls struct{}
+
+// Name returns "tools".
+func (ToolCalls) Name() string { return "tools" }
+
+// Score compares the tools called in the response's action steps.
+func (ToolCalls) Score(_ context.Context, c Case, resp *agent.Response) (Score, error) {
+	if c.ExpectedTools == nil {
+		return Score{}, agent.ErrNotApplicable
+	}
+
+	var called []string
+	for _, step := range resp.Steps {
+		if step.Type == agent.StepTypeAction && step.ToolName != "" {
+			called = append(called, step.ToolName)
+		}
+	}
+	detail := fmt.Sprintf("called [%s], expected [%s]", strings.Join(called, ", "), strings.Join(c.ExpectedTools, ", "))
+
+	if len(c.ExpectedTools) == 0 || len(called) == 0 {
+		if len(c.ExpectedTools) == len(called) {
+			return Score{Value: 1}, nil
+		}
+		return Score{Value: 0, Detail: detail}, nil
+	}
+
+	remaining := make(map[string]int)
+	for _, name := range c.ExpectedTools {
+		remaining[name]++
+	}
+	matched := 0
+	for _, name := range called {
+		if remaining[name] > 0 {
+			remaining[name]--
+			matched++
+		}
+	}
+
+	precision := float64(matched) / float64(len(called))
+	recall := float64(matched) / float64(len(c.ExpectedTools))
+	score := Score{Detail: detail}
+	if matched > 0 {
+		score.Value = 2 * precision * recall / (precision + recall)
+	}
+	if score.Value == 1 {
+		score.Detail = ""
+	}
+	return score, nil
+}
+
+// LLMJudge asks a model to grade the answer against the expected answer.
+type LLMJudge struct {
+	llm   agent.LLMClient
+	model string
+}
+
+// NewLLMJudge creates a judge scorer. Model overrides the client's default when set.
+func NewLLMJudge(llmClient agent.LLMClient, model string) *LLMJudge {
+	return &LLMJudge{llm: llmClient, model: model}
+}
+
+const judgePrompt = `Grade the response against the reference answer.
+
+Question: %s
+
+Reference answer: %s
+
+Response: %s
+
+Score from 0 to 10 how well the response agrees with the reference answer. Judge correctness, not style or length.
+
+Return JSON: {"score": 0, "reasoning": "one sentence"}
+Return only valid JSON.`
+
+// Name returns "judge".
+func (j *LLMJudge) Name() string { return "judge" }
+
+// Score asks the model for a 0-10 grade and scales it to 0-1.
+func (j *LLMJudge) Score(ctx context.Context, c Case, resp *agent.Response) (Score, error) {
+	if c.Expected == "" {
+		return Score{}, agent.ErrNotApplicable
+	}
+
+	out, err := j.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: "You are a strict grader. Respond only with valid JSON."},
+			{Role: llm.RoleUser, Content: fmt.Sprintf(judgePrompt, c.Query, c.Expected, resp.Output)},
+		},
+		Model:       j.model,
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return Score{}, fmt.Errorf("judge request failed: %w", err)
+	}
+
+	content := out.Content
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+	var grade struct {
+		Score     float64 `json:"score"`
+		Reasoning string  `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(content), &grade); err != nil {
+		return Score{}, fmt.Errorf("failed to parse grade: %w", err)
+	}
+	return Score{Value: min(max(grade.Score/10, 0), 1), Detail: grade.Reasoning}, nil
+}
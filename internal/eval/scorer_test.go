@@ -0,0 +1,69 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// judgeClient returns a fixed judge reply.
+type judgeClient struct {
+	content string
+}
+
+func (c judgeClient) Chat(_ context.Context, _ *llm.ChatRequest) (*llm.ChatResponse, error) {
+	return &llm.ChatResponse{Content: c.content}, nil
+}
+
+func (c judgeClient) ChatWithTools(_ context.Context, _ *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+	return nil, errors.New("not used")
+}
+
+func TestScorers(t *testing.T) {
+	steps := []agent.Step{
+		{Type: agent.StepTypeAction, ToolName: "calculator"},
+		{Type: agent.StepTypeObservation, ToolName: "calculator"},
+		{Type: agent.StepTypeAction, ToolName: "search"},
+	}
+
+	tests := []struct {
+		name    string
+		scorer  Scorer
+		c       Case
+		output  string
+		want    float64
+		skipped bool
+	}{
+		{"exact normalized", ExactMatch{}, Case{Expected: "1200"}, "The total is 1,200.", 1, false},
+		{"exact mismatch", ExactMatch{}, Case{Expected: "1200"}, "1201", 0, false},
+		{"exact without expected", ExactMatch{}, Case{}, "x", 0, true},
+		{"fuzzy partial", FuzzyMatch{}, Case{Expected: "Paris is the capital"}, "The capital of France is Paris", 1, false},
+		{"fuzzy missing words", FuzzyMatch{}, Case{Expected: "red green blue yellow"}, "red and blue", 0.5, false},
+		{"tools exact", ToolCalls{}, Case{ExpectedTools: []string{"search", "calculator"}}, "", 1, false},
+		{"tools partial", ToolCalls{}, Case{ExpectedTools: []string{"calculator"}}, "", 2.0 / 3, false},
+		{"tools none expected", ToolCalls{}, Case{ExpectedTools: []string{}}, "", 0, false},
+		{"tools not scored", ToolCalls{}, Case{}, "", 0, true},
+		{"judge", NewLLMJudge(judgeClient{"```json\n{\"score\": 8, \"reasoning\": \"close\"}\n```"}, ""), Case{Expected: "x"}, "y", 0.8, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, err := tt.scorer.Score(context.Background(), tt.c, &agent.Response{Output: tt.output, Steps: steps})
+			if tt.skipped {
+				if !errors.Is(err, agent.ErrNotApplicable) {
+					t.Errorf("expected ErrNotApplicable, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := score.Value - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("expected %.3f, got %.3f (%s)", tt.want, score.Value, score.Detail)
+			}
+		})
+	}
+}
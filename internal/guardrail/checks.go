@@ -0,0 +1,287 @@
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultInjectionPatterns are common phrasings of prompt-injection attempts.
+var defaultInjectionPatterns = []string{
+	`(?i)\b(ignore|disregard|forget|override)\b[^.\n]{0,40}\b(previous|prior|above|earlier|all|your)\b[^.\n]{0,20}\b(instructions?|prompts?|rules|directions|context)\b`,
+	`(?i)\b(reveal|show|print|repeat|output|leak)\b[^.\n]{0,30}\b(system|hidden|initial)\s+(prompt|instructions?|message)\b`,
+	`(?i)\byou\s+are\s+now\s+(?:in\s+)?(?:DAN|developer\s+mode|jailbroken|unrestricted|an?\s+unfiltered)\b`,
+	`(?i)\b(?:enable|activate|enter)\s+(?:developer|god|jailbreak)\s+mode\b`,
+	`(?i)\bdo\s+anything\s+now\b`,
+	`(?i)</?\s*(?:system|im_start|im_end)\s*>`,
+	`(?i)\bnew\s+(?:system\s+)?instructions?\s*:`,
+}
+
+// PromptInjection flags text that tries to override the agent's instructions.
+// It checks input only, including tool results, which may carry indirect
+// injections from fetched content. Redaction removes the matched phrases.
+type PromptInjection struct {
+	patterns []*regexp.Regexp
+}
+
+// NewPromptInjection creates a prompt-injection detector using the built-in
+// heuristics plus any extra regular expressions.
+func NewPromptInjection(extraPatterns ...string) (*PromptInjection, error) {
+	g := &PromptInjection{}
+	for _, p := range defaultInjectionPatterns {
+		g.patterns = append(g.patterns, regexp.MustCompile(p))
+	}
+	for _, p := range extraPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid injection pattern %q: %w", p, err)
+		}
+		g.patterns = append(g.patterns, re)
+	}
+	return g, nil
+}
+
+// Name returns the guardrail name.
+func (g *PromptInjection) Name() string {
+	return "prompt_injection"
+}
+
+// CheckInput flags injection phrases in text.
+func (g *PromptInjection) CheckInput(_ context.Context, text string) (Result, error) {
+	var result Result
+	redacted := text
+	for _, re := range g.patterns {
+		if match := re.FindString(redacted); match != "" {
+			result.Findings = append(result.Findings, fmt.Sprintf("possible prompt injection: %q", truncateRunes(match, 60)))
+			redacted = re.ReplaceAllString(redacted, "[REMOVED]")
+		}
+	}
+	if len(result.Findings) > 0 {
+		result.Redacted = redacted
+	}
+	return result, nil
+}
+
+// CheckOutput does not apply to model output.
+func (g *PromptInjection) CheckOutput(_ context.Context, _ string) (Result, error) {
+	return Result{}, nil
+}
+
+// MaxOutputMatch is zero: output is not checked.
+func (g *PromptInjection) MaxOutputMatch() int {
+	return 0
+}
+
+// PIIKind names a category of personally identifiable information.
+type PIIKind string
+
+const (
+	PIIEmail PIIKind = "email"
+	PIIPhone PIIKind = "phone"
+	PIICard  PIIKind = "card"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+	// Card candidates are 13-19 digits, optionally grouped by spaces or dashes;
+	// a Luhn check filters out other long numbers.
+	cardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+	// Phones need separators or a leading +, so plain numbers in arithmetic
+	// are not mistaken for them.
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)\s?|\d{2,4}[\s.-])\d{3,4}[\s.-]\d{3,4}\b|\+\d{7,14}\b`)
+)
+
+// PII detects email addresses, phone numbers and payment card numbers in
+// both input and output. Redaction replaces each match with a placeholder
+// such as [EMAIL]. Findings report counts, never the values themselves.
+type PII struct {
+	kinds map[PIIKind]bool
+}
+
+// NewPII creates a PII detector for the given kinds, or all kinds if none.
+func NewPII(kinds ...PIIKind) *PII {
+	if len(kinds) == 0 {
+		kinds = []PIIKind{PIIEmail, PIIPhone, PIICard}
+	}
+	g := &PII{kinds: make(map[PIIKind]bool, len(kinds))}
+	for _, k := range kinds {
+		g.kinds[k] = true
+	}
+	return g
+}
+
+// Name returns the guardrail name.
+func (g *PII) Name() string {
+	return "pii"
+}
+
+// CheckInput detects PII in text sent to the model.
+func (g *PII) CheckInput(_ context.Context, text string) (Result, error) {
+	return g.check(text), nil
+}
+
+// CheckOutput detects PII in model output.
+func (g *PII) CheckOutput(_ context.Context, text string) (Result, error) {
+	return g.check(text), nil
+}
+
+// MaxOutputMatch bounds a match by the longest valid email address, which
+// is longer than any card or phone number.
+func (g *PII) MaxOutputMatch() int {
+	return 254
+}
+
+// check finds and redacts each enabled kind. Cards are handled before phones
+// so grouped card numbers are not reported twice.
+func (g *PII) check(text string) Result {
+	var result Result
+	redacted := text
+
+	replace := func(kind PIIKind, re *regexp.Regexp, valid func(string) bool) {
+		if !g.kinds[kind] {
+			return
+		}
+		var b strings.Builder
+		count, last := 0, 0
+		for _, loc := range re.FindAllStringIndex(redacted, -1) {
+			match := redacted[loc[0]:loc[1]]
+			if isNumberPart(redacted, loc[0]-1) || isNumberPart(redacted, loc[1]) {
+				continue // part of a longer number
+			}
+			if valid != nil && !valid(match) {
+				continue
+			}
+			count++
+			b.WriteString(redacted[last:loc[0]])
+			b.WriteString("[" + strings.ToUpper(string(kind)) + "]")
+			last = loc[1]
+		}
+		if count > 0 {
+			b.WriteString(redacted[last:])
+			redacted = b.String()
+			result.Findings = append(result.Findings, fmt.Sprintf("%d %s value(s) detected", count, kind))
+		}
+	}
+
+	replace(PIIEmail, emailPattern, nil)
+	replace(PIICard, cardPattern, luhnValid)
+	replace(PIIPhone, phonePattern, func(s string) bool {
+		n := countDigits(s)
+		return n >= 7 && n <= 15
+	})
+
+	if len(result.Findings) > 0 {
+		result.Redacted = redacted
+	}
+	return result
+}
+
+// isNumberPart reports whether s[i] continues a number: a digit, or a
+// separator adjacent to a digit.
+func isNumberPart(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	if s[i] >= '0' && s[i] <= '9' {
+		return true
+	}
+	if s[i] == '-' || s[i] == '.' {
+		return (i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9') || (i > 0 && s[i-1] >= '0' && s[i-1] <= '9')
+	}
+	return false
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// countDigits returns the number of ASCII digits in s.
+func countDigits(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// MaxLength limits the length of input and output in characters.
+// Redaction truncates the text to the limit.
+type MaxLength struct {
+	maxInput  int
+	maxOutput int
+}
+
+// NewMaxLength creates a length limit. A limit of zero disables that stage.
+func NewMaxLength(maxInput, maxOutput int) *MaxLength {
+	return &MaxLength{maxInput: maxInput, maxOutput: maxOutput}
+}
+
+// Name returns the guardrail name.
+func (g *MaxLength) Name() string {
+	return "max_length"
+}
+
+// CheckInput enforces the input limit.
+func (g *MaxLength) CheckInput(_ context.Context, text string) (Result, error) {
+	return checkLength(StageInput, text, g.maxInput), nil
+}
+
+// CheckOutput enforces the output limit.
+func (g *MaxLength) CheckOutput(_ context.Context, text string) (Result, error) {
+	return checkLength(StageOutput, text, g.maxOutput), nil
+}
+
+// MaxOutputMatch is zero without an output limit; otherwise the whole
+// output is needed to measure it.
+func (g *MaxLength) MaxOutputMatch() int {
+	if g.maxOutput <= 0 {
+		return 0
+	}
+	return -1
+}
+
+// checkLength reports text longer than limit characters.
+func checkLength(stage Stage, text string, limit int) Result {
+	if limit <= 0 {
+		return Result{}
+	}
+	n := utf8.RuneCountInString(text)
+	if n <= limit {
+		return Result{}
+	}
+	return Result{
+		Findings: []string{fmt.Sprintf("%s is %d characters, limit is %d", stage, n, limit)},
+		Redacted: truncateRunes(text, limit),
+	}
+}
+
+// truncateRunes shortens s to at most n characters.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,246 @@
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// ErrToolsUnsupported is returned by Client.ChatWithTools when the wrapped
+// client has no function calling support.
+var ErrToolsUnsupported = errors.New("wrapped client does not support tools")
+
+// Client is llm.Client middleware that applies a pipeline to every call:
+// user and tool messages pass through the pre-hooks, and response content
+// through the post-hooks. It also forwards ChatWithTools, so it can stand in
+// for an agent's LLM client.
+type Client struct {
+	inner    llm.Client
+	pipeline *Pipeline
+}
+
+// toolChatter is implemented by clients that support function calling.
+type toolChatter interface {
+	ChatWithTools(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error)
+}
+
+// NewClient wraps inner with the pipeline.
+func NewClient(inner llm.Client, pipeline *Pipeline) *Client {
+	return &Client{
+		inner:    inner,
+		pipeline: pipeline,
+	}
+}
+
+// Chat checks the request, calls the wrapped client and checks the response.
+func (c *Client) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	messages, err := c.checkMessages(ctx, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	guarded := *req
+	guarded.Messages = messages
+
+	resp, err := c.inner.Chat(ctx, &guarded)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.pipeline.CheckOutput(ctx, resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	out := *resp
+	out.Content = content
+	return &out, nil
+}
+
+// ChatWithTools checks the request and the response content. Tool call
+// arguments are not checked; tool results are checked on the next call.
+func (c *Client) ChatWithTools(ctx context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+	tc, ok := c.inner.(toolChatter)
+	if !ok {
+		return nil, ErrToolsUnsupported
+	}
+
+	messages, err := c.checkMessages(ctx, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	guarded := *req
+	guarded.Messages = messages
+
+	resp, err := tc.ChatWithTools(ctx, &guarded)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.pipeline.CheckOutput(ctx, resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	out := *resp
+	out.Content = content
+	return &out, nil
+}
+
+// ChatStream checks the request and then the streamed content. When every
+// rule only warns, chunks pass through as they arrive. When the enforcing
+// rules redact bounded matches (see StreamGuardrail), content is redacted
+// piece by piece, holding back only enough text to catch a match that spans
+// chunks. Otherwise, as with a banned topic or output length rule, the
+// stream is buffered so that blocked output is never sent, and the checked
+// content is delivered as a single chunk. Either way the whole output is
+// checked once the stream ends, recording violations in the report.
+func (c *Client) ChatStream(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	messages, err := c.checkMessages(ctx, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	guarded := *req
+	guarded.Messages = messages
+
+	stream, err := c.inner.ChatStream(ctx, &guarded)
+	if err != nil {
+		return nil, err
+	}
+
+	redactor, window, streams := c.pipeline.streamRules()
+	buffered := !streams
+	out := make(chan llm.StreamChunk)
+	go func() {
+		defer close(out)
+
+		send := func(chunk llm.StreamChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// Pieces are redacted without a report; the final check records
+		// the violations of the whole output once
+		quiet := context.WithValue(ctx, reportKey{}, (*Report)(nil))
+		var content strings.Builder
+		var pending, finishReason string
+		for chunk := range stream {
+			if chunk.Error != nil {
+				send(chunk)
+				return
+			}
+			content.WriteString(chunk.Content)
+			if chunk.Done {
+				finishReason = chunk.FinishReason
+			}
+
+			switch {
+			case buffered:
+			case window > 0:
+				if chunk.Content == "" {
+					break
+				}
+				piece, rest, err := redactPiece(quiet, redactor, pending+chunk.Content, window)
+				if err != nil {
+					send(llm.StreamChunk{Error: err})
+					return
+				}
+				pending = rest
+				if piece != "" && !send(llm.StreamChunk{Content: piece}) {
+					return
+				}
+			case !chunk.Done:
+				if !send(chunk) {
+					return
+				}
+			case chunk.Content != "":
+				if !send(llm.StreamChunk{Content: chunk.Content}) {
+					return
+				}
+			}
+			if chunk.Done {
+				break
+			}
+		}
+
+		checked, err := c.pipeline.CheckOutput(ctx, content.String())
+		if err != nil {
+			send(llm.StreamChunk{Error: err})
+			return
+		}
+		if window > 0 {
+			checked, err = redactor.CheckOutput(quiet, pending)
+			if err != nil {
+				send(llm.StreamChunk{Error: err})
+				return
+			}
+		}
+		if (buffered || window > 0) && checked != "" && !send(llm.StreamChunk{Content: checked}) {
+			return
+		}
+		send(llm.StreamChunk{Done: true, FinishReason: finishReason})
+	}()
+
+	return out, nil
+}
+
+// redactPiece splits pending text into a redacted piece that is safe to
+// send and the rest, held back because a match may continue into the next
+// chunk. Findings span at most window bytes, so only that much is held back,
+// and the piece is only cut where redacting both sides apart gives the same
+// text as redacting them together, so no match straddles the cut.
+func redactPiece(ctx context.Context, redactor *Pipeline, pending string, window int) (piece, rest string, err error) {
+	cut := len(pending) - window
+	for cut > 0 && !utf8.RuneStart(pending[cut]) {
+		cut--
+	}
+	if cut <= 0 {
+		return "", pending, nil
+	}
+
+	whole, err := redactor.CheckOutput(ctx, pending)
+	if err != nil {
+		return "", "", err
+	}
+	head, err := redactor.CheckOutput(ctx, pending[:cut])
+	if err != nil {
+		return "", "", err
+	}
+	tail, err := redactor.CheckOutput(ctx, pending[cut:])
+	if err != nil {
+		return "", "", err
+	}
+	if head+tail != whole {
+		return "", pending, nil
+	}
+	return head, pending[cut:], nil
+}
+
+// Close closes the wrapped client.
+func (c *Client) Close() error {
+	return c.inner.Close()
+}
+
+// checkMessages runs the pre-hooks over user and tool messages. System and
+// assistant messages are trusted and left unchanged.
+func (c *Client) checkMessages(ctx context.Context, messages []llm.Message) ([]llm.Message, error) {
+	checked := make([]llm.Message, len(messages))
+	for i, msg := range messages {
+		if msg.Role == llm.RoleUser || msg.Role == llm.RoleTool {
+			content, err := c.pipeline.CheckInput(ctx, msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			msg.Content = content
+		}
+		checked[i] = msg
+	}
+	return checked, nil
+}
+
+// Ensure Client implements llm.Client.
+var _ llm.Client = (*Client)(nil)
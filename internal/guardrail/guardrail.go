@@ -0,0 +1,287 @@
+// Package guardrail enforces input and output policies around LLM and agent
+// calls: prompt-injection heuristics, PII redaction, banned topics, length
+// limits and JSON-schema output checks.
+//
+// Guardrails are combined into a Pipeline, each with an Action that decides
+// what happens on a match: block the call, redact the offending text, or
+// only warn. Pipelines run as llm.Client middleware (see NewClient) and inside
+// the agent loops; every violation is recorded in the Report carried by the
+// request context so handlers can return it.
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Action is what a Pipeline does when a guardrail finds a violation.
+type Action string
+
+const (
+	// ActionBlock rejects the text with a *BlockedError.
+	ActionBlock Action = "block"
+
+	// ActionRedact replaces the text with the guardrail's redacted version.
+	// Guardrails that cannot redact fall back to blocking.
+	ActionRedact Action = "redact"
+
+	// ActionWarn records the violation and lets the text through unchanged.
+	ActionWarn Action = "warn"
+)
+
+// Stage identifies which side of a model call is being checked.
+type Stage string
+
+const (
+	// StageInput covers text sent to the model: user messages and tool results.
+	StageInput Stage = "input"
+
+	// StageOutput covers text produced by the model.
+	StageOutput Stage = "output"
+)
+
+// ErrBlocked is matched by errors.Is for every *BlockedError.
+var ErrBlocked = errors.New("blocked by guardrail")
+
+// Result is the outcome of a single guardrail check.
+type Result struct {
+	// Findings describe each policy match; empty means the text passed.
+	// Findings never repeat the sensitive text itself.
+	Findings []string
+
+	// Redacted is the text with offending spans masked or removed.
+	// Empty when the guardrail cannot redact.
+	Redacted string
+}
+
+// Guardrail checks text before it reaches the model (pre-hook) and after the
+// model produces it (post-hook). A guardrail that does not apply to a stage
+// returns an empty Result.
+type Guardrail interface {
+	// Name identifies the guardrail in violations.
+	Name() string
+
+	// CheckInput is the pre-hook, run on user messages and tool results.
+	CheckInput(ctx context.Context, text string) (Result, error)
+
+	// CheckOutput is the post-hook, run on model responses.
+	CheckOutput(ctx context.Context, text string) (Result, error)
+}
+
+// StreamGuardrail is implemented by guardrails whose output findings each
+// span a bounded length of text, so that redacting rules can check a stream
+// piece by piece instead of buffering it whole.
+type StreamGuardrail interface {
+	Guardrail
+
+	// MaxOutputMatch is the most bytes of output a single finding spans:
+	// zero if the guardrail does not check output, negative if its findings
+	// depend on the output as a whole.
+	MaxOutputMatch() int
+}
+
+// Violation is a single policy match reported to the caller.
+type Violation struct {
+	Guardrail string `json:"guardrail"`
+	Stage     Stage  `json:"stage"`
+	Action    Action `json:"action"`
+	Message   string `json:"message"`
+}
+
+// BlockedError is returned when a guardrail with ActionBlock matches.
+type BlockedError struct {
+	Violations []Violation
+}
+
+// Error implements error.
+func (e *BlockedError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = fmt.Sprintf("%s: %s", v.Guardrail, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", ErrBlocked, strings.Join(messages, "; "))
+}
+
+// Is reports whether target is ErrBlocked.
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// Rule pairs a guardrail with the action taken when it matches.
+type Rule struct {
+	Guardrail Guardrail
+	Action    Action
+}
+
+// Pipeline runs rules in order; redactions from earlier rules are visible to
+// later ones. A nil *Pipeline passes all text through unchanged.
+type Pipeline struct {
+	rules []Rule
+}
+
+// NewPipeline creates a pipeline from rules. Rules without an action warn.
+func NewPipeline(rules ...Rule) *Pipeline {
+	p := &Pipeline{rules: make([]Rule, 0, len(rules))}
+	for _, r := range rules {
+		if r.Action == "" {
+			r.Action = ActionWarn
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p
+}
+
+// Rules returns the pipeline's rules.
+func (p *Pipeline) Rules() []Rule {
+	if p == nil {
+		return nil
+	}
+	return p.rules
+}
+
+// CheckInput applies the pre-hooks to text, returning the possibly redacted text.
+func (p *Pipeline) CheckInput(ctx context.Context, text string) (string, error) {
+	return p.check(ctx, StageInput, text)
+}
+
+// CheckOutput applies the post-hooks to text, returning the possibly redacted text.
+func (p *Pipeline) CheckOutput(ctx context.Context, text string) (string, error) {
+	return p.check(ctx, StageOutput, text)
+}
+
+// check runs every rule for stage and records violations in the context's report.
+func (p *Pipeline) check(ctx context.Context, stage Stage, text string) (string, error) {
+	if p == nil || text == "" {
+		return text, nil
+	}
+
+	report := ReportFrom(ctx)
+	for _, rule := range p.rules {
+		var result Result
+		var err error
+		if stage == StageInput {
+			result, err = rule.Guardrail.CheckInput(ctx, text)
+		} else {
+			result, err = rule.Guardrail.CheckOutput(ctx, text)
+		}
+		if err != nil {
+			return "", fmt.Errorf("guardrail %s: %w", rule.Guardrail.Name(), err)
+		}
+		if len(result.Findings) == 0 {
+			continue
+		}
+
+		action := rule.Action
+		if action == ActionRedact && result.Redacted == "" {
+			action = ActionBlock
+		}
+
+		violations := make([]Violation, len(result.Findings))
+		for i, finding := range result.Findings {
+			violations[i] = Violation{
+				Guardrail: rule.Guardrail.Name(),
+				Stage:     stage,
+				Action:    action,
+				Message:   finding,
+			}
+		}
+		report.add(violations...)
+
+		switch action {
+		case ActionBlock:
+			return "", &BlockedError{Violations: violations}
+		case ActionRedact:
+			text = result.Redacted
+		}
+	}
+
+	return text, nil
+}
+
+// streamRules returns the rules that can change streamed output and the
+// longest text one of their findings spans. ok is false if a rule can block
+// output or needs all of it, so the stream must be buffered whole.
+func (p *Pipeline) streamRules() (rules *Pipeline, window int, ok bool) {
+	rules = &Pipeline{}
+	for _, r := range p.Rules() {
+		if r.Action == ActionWarn {
+			continue
+		}
+		g, streams := r.Guardrail.(StreamGuardrail)
+		if !streams {
+			return nil, 0, false
+		}
+		n := g.MaxOutputMatch()
+		switch {
+		case n == 0:
+			continue
+		case n < 0 || r.Action != ActionRedact:
+			return nil, 0, false
+		}
+		rules.rules = append(rules.rules, r)
+		window = max(window, n)
+	}
+	return rules, window, true
+}
+
+// Report collects the violations of one request. It is safe for concurrent use.
+type Report struct {
+	mu         sync.Mutex
+	violations []Violation
+}
+
+// reportKey is the context key holding the request's *Report.
+type reportKey struct{}
+
+// WithReport returns a context carrying a report. An existing report is
+// reused so nested agents and clients add to the same one.
+func WithReport(ctx context.Context) (context.Context, *Report) {
+	if r := ReportFrom(ctx); r != nil {
+		return ctx, r
+	}
+	r := &Report{}
+	return context.WithValue(ctx, reportKey{}, r), r
+}
+
+// ReportFrom returns the report carried by ctx, or nil.
+func ReportFrom(ctx context.Context) *Report {
+	r, _ := ctx.Value(reportKey{}).(*Report)
+	return r
+}
+
+// add records violations, skipping exact duplicates from repeated checks of
+// the same conversation.
+func (r *Report) add(violations ...Violation) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, v := range violations {
+		duplicate := false
+		for _, existing := range r.violations {
+			if existing == v {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			r.violations = append(r.violations, v)
+		}
+	}
+}
+
+// Violations returns a copy of the recorded violations.
+func (r *Report) Violations() []Violation {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Violation(nil), r.violations...)
+}
@@ -0,0 +1,118 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// JSONSchema requires model output to be JSON matching a schema.
+// It checks output only and cannot redact, so ActionRedact blocks.
+type JSONSchema struct {
+	schema map[string]any
+}
+
+// NewJSONSchema creates an output schema check. See ValidateSchema for the
+// supported keywords.
+func NewJSONSchema(schema map[string]any) *JSONSchema {
+	return &JSONSchema{schema: schema}
+}
+
+// Name returns the guardrail name.
+func (g *JSONSchema) Name() string {
+	return "json_schema"
+}
+
+// CheckInput does not apply to input.
+func (g *JSONSchema) CheckInput(_ context.Context, _ string) (Result, error) {
+	return Result{}, nil
+}
+
+// CheckOutput parses text as JSON, tolerating code fences, and validates it.
+func (g *JSONSchema) CheckOutput(_ context.Context, text string) (Result, error) {
+	var value any
+	if err := json.Unmarshal([]byte(extractJSON(text)), &value); err != nil {
+		return Result{Findings: []string{"output is not valid JSON"}}, nil
+	}
+	return Result{Findings: ValidateSchema(value, g.schema)}, nil
+}
+
+// ValidateSchema checks a decoded JSON value against a subset of JSON Schema
+// (type, properties, required, items and enum) and returns the violations
+// found, with paths rooted at "$".
+func ValidateSchema(value any, schema map[string]any) []string {
+	return validateSchema(value, schema, "$")
+}
+
+func validateSchema(value any, schema map[string]any, path string) []string {
+	var violations []string
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, v := range enum {
+			if fmt.Sprint(v) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			violations = append(violations, fmt.Sprintf("%s is not one of %v", path, enum))
+		}
+	}
+
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return append(violations, fmt.Sprintf("%s must be an object", path))
+		}
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name := fmt.Sprint(r)
+				if _, exists := obj[name]; !exists {
+					violations = append(violations, fmt.Sprintf("%s.%s is required", path, name))
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]any); ok {
+			for name, sub := range props {
+				subSchema, ok := sub.(map[string]any)
+				if v, exists := obj[name]; exists && ok {
+					violations = append(violations, validateSchema(v, subSchema, path+"."+name)...)
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return append(violations, fmt.Sprintf("%s must be an array", path))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, v := range arr {
+				violations = append(violations, validateSchema(v, items, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			violations = append(violations, fmt.Sprintf("%s must be a string", path))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			violations = append(violations, fmt.Sprintf("%s must be a number", path))
+		}
+	case "integer":
+		if f, ok := value.(float64); !ok || f != math.Trunc(f) {
+			violations = append(violations, fmt.Sprintf("%s must be an integer", path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			violations = append(violations, fmt.Sprintf("%s must be a boolean", path))
+		}
+	}
+
+	sort.Strings(violations)
+	return violations
+}
@@ -0,0 +1,168 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// TopicClassifier reports which of the given topics a text discusses.
+type TopicClassifier interface {
+	Classify(ctx context.Context, text string, topics []string) ([]string, error)
+}
+
+// KeywordClassifier matches topics by whole-word, case-insensitive keywords.
+type KeywordClassifier struct {
+	patterns map[string]*regexp.Regexp
+}
+
+// NewKeywordClassifier creates a classifier from topic names to keywords.
+func NewKeywordClassifier(keywords map[string][]string) *KeywordClassifier {
+	c := &KeywordClassifier{patterns: make(map[string]*regexp.Regexp, len(keywords))}
+	for topic, words := range keywords {
+		if len(words) == 0 {
+			continue
+		}
+		quoted := make([]string, len(words))
+		for i, w := range words {
+			quoted[i] = regexp.QuoteMeta(w)
+		}
+		c.patterns[topic] = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	return c
+}
+
+// Classify returns the topics whose keywords appear in text.
+func (c *KeywordClassifier) Classify(_ context.Context, text string, topics []string) ([]string, error) {
+	var matched []string
+	for _, topic := range topics {
+		if re, ok := c.patterns[topic]; ok && re.MatchString(text) {
+			matched = append(matched, topic)
+		}
+	}
+	return matched, nil
+}
+
+// TopicClassificationPrompt asks the model which banned topics a text discusses.
+const TopicClassificationPrompt = `You are a content policy classifier.
+Decide which of the listed topics the text below discusses. Only count a topic
+if the text substantively engages with it, not a passing mention.
+
+Topics: %s
+
+Text:
+"""
+%s
+"""
+
+Respond with JSON only: {"topics": ["<topic from the list>", ...]}. Use an empty list if none apply.`
+
+// LLMClassifier classifies topics with a model call and structured JSON output.
+type LLMClassifier struct {
+	client llm.Client
+	model  string
+}
+
+// NewLLMClassifier creates a model-backed topic classifier.
+// Model may be empty to use the client's default.
+func NewLLMClassifier(client llm.Client, model string) *LLMClassifier {
+	return &LLMClassifier{client: client, model: model}
+}
+
+// Classify asks the model which topics apply. Topics outside the list are ignored.
+func (c *LLMClassifier) Classify(ctx context.Context, text string, topics []string) ([]string, error) {
+	resp, err := c.client.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleUser, Content: fmt.Sprintf(TopicClassificationPrompt, strings.Join(topics, ", "), text)},
+		},
+		Model:       c.model,
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("topic classification failed: %w", err)
+	}
+
+	var out struct {
+		Topics []string `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse topic classification: %w", err)
+	}
+
+	var matched []string
+	for _, t := range out.Topics {
+		if slices.Contains(topics, t) && !slices.Contains(matched, t) {
+			matched = append(matched, t)
+		}
+	}
+	return matched, nil
+}
+
+// BannedTopics flags input and output that discuss any banned topic.
+// It cannot redact, so ActionRedact blocks.
+type BannedTopics struct {
+	classifier TopicClassifier
+	topics     []string
+}
+
+// NewBannedTopics creates a banned-topic guardrail.
+func NewBannedTopics(classifier TopicClassifier, topics ...string) *BannedTopics {
+	return &BannedTopics{classifier: classifier, topics: topics}
+}
+
+// Name returns the guardrail name.
+func (g *BannedTopics) Name() string {
+	return "banned_topics"
+}
+
+// CheckInput classifies text sent to the model.
+func (g *BannedTopics) CheckInput(ctx context.Context, text string) (Result, error) {
+	return g.check(ctx, text)
+}
+
+// CheckOutput classifies model output.
+func (g *BannedTopics) CheckOutput(ctx context.Context, text string) (Result, error) {
+	return g.check(ctx, text)
+}
+
+func (g *BannedTopics) check(ctx context.Context, text string) (Result, error) {
+	if len(g.topics) == 0 {
+		return Result{}, nil
+	}
+	matched, err := g.classifier.Classify(ctx, text, g.topics)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	for _, topic := range matched {
+		result.Findings = append(result.Findings, fmt.Sprintf("banned topic: %s", topic))
+	}
+	return result, nil
+}
+
+// extractJSON returns the JSON object in s, stripping code fences and prose.
+func extractJSON(s string) string {
+	if start := strings.Index(s, "```"); start != -1 {
+		s = s[start+3:]
+		s = strings.TrimPrefix(s, "json")
+		if end := strings.Index(s, "```"); end != -1 {
+			s = s[:end]
+		}
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		return s
+	}
+	if start := strings.Index(s, "{"); start != -1 {
+		if end := strings.LastIndex(s, "}"); end > start {
+			return s[start : end+1]
+		}
+	}
+	return s
+}
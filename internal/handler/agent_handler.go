@@ -6,25 +6,36 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
 )
 
 // AgentHandler handles agent-related HTTP requests.
 type AgentHandler struct {
-	agent agent.Agent
+	agent    agent.Agent
+	sessions *memory.ConversationManager
 }
 
 // NewAgentHandler creates a new AgentHandler.
-func NewAgentHandler(a agent.Agent) *AgentHandler {
+// Sessions may be nil to disable session_id support.
+func NewAgentHandler(a agent.Agent, sessions *memory.ConversationManager) *AgentHandler {
 	return &AgentHandler{
-		agent: a,
+		agent:    a,
+		sessions: sessions,
 	}
 }
 
 // AgentRequest represents the request body for agent endpoint.
+// With a SessionID, earlier turns of the session are loaded before History
+// and this turn is appended to the session after the run.
+// Budget limits the run; once spent, the agent returns a best-effort answer
+// unless the budget is strict.
 type AgentRequest struct {
-	Query   string         `json:"query" validate:"required"`
-	History []AgentMessage `json:"history,omitempty"`
-	Verbose bool           `json:"verbose,omitempty"`
+	Query     string         `json:"query" validate:"required"`
+	History   []AgentMessage `json:"history,omitempty"`
+	Verbose   bool           `json:"verbose,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Budget    *BudgetRequest `json:"budget,omitempty"`
 }
 
 // AgentMessage represents a message in the conversation history.
@@ -34,10 +45,20 @@ type AgentMessage struct {
 }
 
 // AgentResponse represents the response from the agent.
+// Route is set when the agent is a router and Cascade when it is a cascade.
+// Incomplete gives the limit that stopped the run when Output is a
+// best-effort answer.
 type AgentResponse struct {
-	Output string     `json:"output"`
-	Steps  []StepInfo `json:"steps,omitempty"`
-	Usage  UsageInfo  `json:"usage"`
+	Output    string     `json:"output"`
+	Steps     []StepInfo `json:"steps,omitempty"`
+	Usage     UsageInfo  `json:"usage"`
+	SessionID string     `json:"session_id,omitempty"`
+
+	Violations []guardrail.Violation  `json:"violations,omitempty"`
+	Budget     *agent.BudgetUsage     `json:"budget,omitempty"`
+	Route      *agent.RoutingDecision `json:"route,omitempty"`
+	Cascade    *agent.CascadeReport   `json:"cascade,omitempty"`
+	Incomplete string                 `json:"incomplete,omitempty"`
 }
 
 // StepInfo represents a single step in the agent's reasoning.
@@ -47,6 +68,7 @@ type StepInfo struct {
 	ToolName   string `json:"tool_name,omitempty"`
 	ToolInput  string `json:"tool_input,omitempty"`
 	ToolOutput string `json:"tool_output,omitempty"`
+	Agent      string `json:"agent,omitempty"`
 }
 
 // Run handles POST /api/agent requests.
@@ -66,23 +88,27 @@ func (h *AgentHandler) Run(c echo.Context) error {
 		})
 	}
 
-	// Convert history to agent messages
-	history := make([]agent.Message, 0, len(req.History))
-	for _, msg := range req.History {
-		history = append(history, agent.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
+	report := withGuardrailReport(c)
+	budget := startBudget(c, req.Budget)
+	ctx := c.Request().Context()
+	turn, sessionHistory, err := openSession(c, h.sessions, req.SessionID)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "session_error",
+			Message: err.Error(),
 		})
 	}
+	defer turn.end()
+
+	// Convert history to agent messages
+	history := toAgentMessages(append(sessionHistory, req.History...))
 
 	// Run the agent
-	resp, err := h.agent.RunWithHistory(c.Request().Context(), history, req.Query)
+	resp, err := runAgent(c, ctx, h.agent, history, req.Query)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "agent_error",
-			Message: err.Error(),
-		})
+		return runError(c, "agent_error", err, report)
 	}
+	turn.record(ctx, userTurn(req.History, req.Query), resp.Output, resp.Steps)
 
 	// Convert steps
 	var steps []StepInfo
@@ -94,10 +120,14 @@ func (h *AgentHandler) Run(c echo.Context) error {
 				ToolName:   step.ToolName,
 				ToolInput:  step.ToolInput,
 				ToolOutput: step.ToolOutput,
+				Agent:      step.Agent,
 			})
 		}
 	}
 
+	route, _ := resp.Metadata["router"].(*agent.RoutingDecision)
+	cascade, _ := resp.Metadata["cascade"].(*agent.CascadeReport)
+
 	return c.JSON(http.StatusOK, AgentResponse{
 		Output: resp.Output,
 		Steps:  steps,
@@ -106,5 +136,23 @@ func (h *AgentHandler) Run(c echo.Context) error {
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
+		SessionID:  turn.sessionID(),
+		Violations: report.Violations(),
+		Budget:     budgetUsage(budget),
+		Route:      route,
+		Cascade:    cascade,
+		Incomplete: incompleteReason(resp),
 	})
 }
+
+// toAgentMessages converts request history to agent messages.
+func toAgentMessages(msgs []AgentMessage) []agent.Message {
+	history := make([]agent.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		history = append(history, agent.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	return history
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
+)
+
+// AgentsHandler serves agents built from declarative definitions.
+type AgentsHandler struct {
+	handlers map[string]*AgentHandler
+	infos    []AgentInfo
+}
+
+// AgentInfo describes a defined agent.
+type AgentInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Strategy    string   `json:"strategy"`
+	Tools       []string `json:"tools,omitempty"`
+	Workers     []string `json:"workers,omitempty"`
+}
+
+// NewAgentsHandler creates a new AgentsHandler for agents built from defs.
+// Sessions may be nil to disable session_id support.
+func NewAgentsHandler(agents map[string]agent.Agent, defs []agent.AgentDefinition, sessions *memory.ConversationManager) *AgentsHandler {
+	h := &AgentsHandler{
+		handlers: make(map[string]*AgentHandler, len(agents)),
+		infos:    make([]AgentInfo, 0, len(defs)),
+	}
+
+	for _, def := range defs {
+		a, ok := agents[def.Name]
+		if !ok {
+			continue
+		}
+		h.handlers[def.Name] = NewAgentHandler(a, sessions)
+
+		strategy := def.Strategy
+		if strategy == "" {
+			strategy = agent.StrategyReAct
+		}
+		h.infos = append(h.infos, AgentInfo{
+			Name:        def.Name,
+			Description: def.Description,
+			Strategy:    string(strategy),
+			Tools:       def.Tools,
+			Workers:     def.Workers,
+		})
+	}
+
+	return h
+}
+
+// List handles GET /api/agents requests.
+func (h *AgentsHandler) List(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"agents": h.infos,
+		"count":  len(h.infos),
+	})
+}
+
+// Run handles POST /api/agents/:name/run requests.
+// The request and response bodies match POST /api/agent.
+func (h *AgentsHandler) Run(c echo.Context) error {
+	name := c.Param("name")
+	agentHandler, ok := h.handlers[name]
+	if !ok {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "agent_not_found",
+			Message: "No agent named " + name,
+		})
+	}
+
+	return agentHandler.Run(c)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+)
+
+// budgetDefaultsKey is the echo context key holding the server's run limits.
+const budgetDefaultsKey = "budget_defaults"
+
+// BudgetRequest limits the resources of a single agent run. Zero fields fall
+// back to the server defaults; a request can tighten them but not raise them.
+// Strict fails the run when it hits a limit instead of returning a
+// best-effort answer.
+type BudgetRequest struct {
+	MaxTokens    int     `json:"max_tokens,omitempty"`
+	MaxCost      float64 `json:"max_cost,omitempty"`
+	TimeoutMs    int     `json:"timeout_ms,omitempty"`
+	MaxToolCalls int     `json:"max_tool_calls,omitempty"`
+	Strict       bool    `json:"strict,omitempty"`
+}
+
+// BudgetDefaults is middleware that sets the run limits applied to agent
+// requests. Timeout is relative to the start of each run. Limits.Model names
+// the model used to price calls that do not specify one.
+func BudgetDefaults(limits agent.BudgetLimits, timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(budgetDefaultsKey, budgetDefaults{limits: limits, timeout: timeout})
+			return next(c)
+		}
+	}
+}
+
+type budgetDefaults struct {
+	limits  agent.BudgetLimits
+	timeout time.Duration
+}
+
+// startBudget attaches a budget to the request context combining the server
+// defaults with req. It returns nil when neither sets a limit.
+func startBudget(c echo.Context, req *BudgetRequest) *agent.Budget {
+	defaults, _ := c.Get(budgetDefaultsKey).(budgetDefaults)
+	limits := defaults.limits
+	timeout := defaults.timeout
+
+	if req != nil {
+		if req.Strict {
+			c.SetRequest(c.Request().WithContext(agent.WithStrictLimits(c.Request().Context())))
+		}
+		limits.MaxTokens = tighter(limits.MaxTokens, req.MaxTokens)
+		limits.MaxCost = tighter(limits.MaxCost, req.MaxCost)
+		limits.MaxToolCalls = tighter(limits.MaxToolCalls, req.MaxToolCalls)
+		timeout = tighter(timeout, time.Duration(req.TimeoutMs)*time.Millisecond)
+	}
+	if timeout > 0 {
+		limits.Deadline = time.Now().Add(timeout)
+	}
+
+	if limits.MaxTokens <= 0 && limits.MaxCost <= 0 && limits.MaxToolCalls <= 0 && limits.Deadline.IsZero() {
+		return nil
+	}
+
+	budget := agent.NewBudget(limits)
+	c.SetRequest(c.Request().WithContext(agent.WithBudget(c.Request().Context(), budget)))
+	return budget
+}
+
+// incompleteReason returns why a run stopped at a limit with a best-effort
+// answer, or "" if it finished.
+func incompleteReason(resp *agent.Response) string {
+	reason, _ := resp.Metadata["incomplete_reason"].(string)
+	return reason
+}
+
+// budgetUsage reports what a run consumed, or nil without a budget.
+func budgetUsage(b *agent.Budget) *agent.BudgetUsage {
+	if b == nil {
+		return nil
+	}
+	usage := b.Usage()
+	return &usage
+}
+
+// tighter returns the smaller positive limit, treating zero as unlimited.
+func tighter[T int | float64 | time.Duration](current, requested T) T {
+	if requested <= 0 {
+		return current
+	}
+	if current <= 0 || requested < current {
+		return requested
+	}
+	return current
+}
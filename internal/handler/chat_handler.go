@@ -2,30 +2,39 @@ package handler
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
 	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
 )
 
 // ChatHandler handles chat-related HTTP requests.
 type ChatHandler struct {
 	llmClient llm.Client
+	sessions  *memory.ConversationManager
 }
 
 // NewChatHandler creates a new ChatHandler.
-func NewChatHandler(client llm.Client) *ChatHandler {
+// Sessions may be nil to disable session_id support.
+func NewChatHandler(client llm.Client, sessions *memory.ConversationManager) *ChatHandler {
 	return &ChatHandler{
 		llmClient: client,
+		sessions:  sessions,
 	}
 }
 
 // ChatRequest represents the request body for chat endpoint.
+// With a SessionID, Messages holds only the new turn; earlier turns are
+// loaded from the session and the exchange is appended after the reply.
 type ChatRequest struct {
 	Messages    []MessageRequest `json:"messages" validate:"required,min=1"`
 	MaxTokens   int              `json:"max_tokens,omitempty"`
 	Temperature float32          `json:"temperature,omitempty"`
 	Stream      bool             `json:"stream,omitempty"`
+	SessionID   string           `json:"session_id,omitempty"`
 }
 
 // MessageRequest represents a single message in the request.
@@ -39,6 +48,9 @@ type ChatResponse struct {
 	Content      string    `json:"content"`
 	FinishReason string    `json:"finish_reason"`
 	Usage        UsageInfo `json:"usage"`
+	SessionID    string    `json:"session_id,omitempty"`
+
+	Violations []guardrail.Violation `json:"violations,omitempty"`
 }
 
 // UsageInfo contains token usage information.
@@ -49,9 +61,11 @@ type UsageInfo struct {
 }
 
 // ErrorResponse represents an error response.
+// Violations is set when a guardrail blocked the request.
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error      string                `json:"error"`
+	Message    string                `json:"message"`
+	Violations []guardrail.Violation `json:"violations,omitempty"`
 }
 
 // Chat handles POST /api/chat requests.
@@ -71,32 +85,53 @@ func (h *ChatHandler) Chat(c echo.Context) error {
 		})
 	}
 
-	// Convert request messages to LLM messages
-	messages := make([]llm.Message, len(req.Messages))
+	report := withGuardrailReport(c)
+	ctx := c.Request().Context()
+	turn, sessionHistory, err := openSession(c, h.sessions, req.SessionID)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "session_error",
+			Message: err.Error(),
+		})
+	}
+	defer turn.end()
+
+	inputs := make([]AgentMessage, len(req.Messages))
 	for i, msg := range req.Messages {
-		messages[i] = llm.Message{
+		inputs[i] = AgentMessage{Role: msg.Role, Content: msg.Content}
+	}
+
+	// Convert session history and request messages to LLM messages
+	messages := make([]llm.Message, 0, len(sessionHistory)+len(inputs))
+	for _, msg := range append(sessionHistory, inputs...) {
+		messages = append(messages, llm.Message{
 			Role:    llm.Role(msg.Role),
 			Content: msg.Content,
-		}
+		})
 	}
 
 	// Handle streaming response
 	if req.Stream {
-		return h.handleStreamingChat(c, messages, req.MaxTokens, req.Temperature)
+		content, err := h.handleStreamingChat(c, messages, req.MaxTokens, req.Temperature)
+		if err != nil {
+			return runError(c, "llm_error", err, report)
+		}
+		if content != "" {
+			turn.record(ctx, inputs, content, nil)
+		}
+		return nil
 	}
 
 	// Non-streaming response
-	resp, err := h.llmClient.Chat(c.Request().Context(), &llm.ChatRequest{
+	resp, err := h.llmClient.Chat(ctx, &llm.ChatRequest{
 		Messages:    messages,
 		MaxTokens:   req.MaxTokens,
 		Temperature: req.Temperature,
 	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "llm_error",
-			Message: err.Error(),
-		})
+		return runError(c, "llm_error", err, report)
 	}
+	turn.record(ctx, inputs, resp.Content, nil)
 
 	return c.JSON(http.StatusOK, ChatResponse{
 		Content:      resp.Content,
@@ -106,16 +141,15 @@ func (h *ChatHandler) Chat(c echo.Context) error {
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
+		SessionID:  turn.sessionID(),
+		Violations: report.Violations(),
 	})
 }
 
 // handleStreamingChat handles streaming chat responses using SSE.
-func (h *ChatHandler) handleStreamingChat(c echo.Context, messages []llm.Message, maxTokens int, temperature float32) error {
-	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().WriteHeader(http.StatusOK)
-
+// It returns the streamed content once the stream completes successfully.
+// Errors from starting the stream are returned before any response is written.
+func (h *ChatHandler) handleStreamingChat(c echo.Context, messages []llm.Message, maxTokens int, temperature float32) (string, error) {
 	stream, err := h.llmClient.ChatStream(c.Request().Context(), &llm.ChatRequest{
 		Messages:    messages,
 		MaxTokens:   maxTokens,
@@ -123,22 +157,29 @@ func (h *ChatHandler) handleStreamingChat(c echo.Context, messages []llm.Message
 		Stream:      true,
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	flusher, ok := c.Response().Writer.(http.Flusher)
 	if !ok {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Streaming not supported")
+		return "", echo.NewHTTPError(http.StatusInternalServerError, "Streaming not supported")
 	}
 
+	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+
+	var content strings.Builder
 	for chunk := range stream {
 		if chunk.Error != nil {
 			_, _ = c.Response().Write([]byte("event: error\ndata: " + chunk.Error.Error() + "\n\n"))
 			flusher.Flush()
-			break
+			return "", nil
 		}
 
 		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
 			_, _ = c.Response().Write([]byte("data: " + chunk.Content + "\n\n"))
 			flusher.Flush()
 		}
@@ -150,7 +191,7 @@ func (h *ChatHandler) handleStreamingChat(c echo.Context, messages []llm.Message
 		}
 	}
 
-	return nil
+	return content.String(), nil
 }
 
 // Health handles GET /health requests.
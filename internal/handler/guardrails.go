@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+)
+
+// guardrailsKey is the echo context key holding the pipeline applied to
+// messages saved to sessions.
+const guardrailsKey = "guardrails"
+
+// Guardrails is middleware that sets the pipeline whose input checks apply
+// to user messages before they are saved to a session, so that redacted
+// text is stored as redacted. It should not include checks that call a
+// model, since they would run again for every saved message.
+func Guardrails(p *guardrail.Pipeline) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(guardrailsKey, p)
+			return next(c)
+		}
+	}
+}
+
+// withGuardrailReport attaches a guardrail report to the request context so
+// violations from clients and agents used by the handler are collected.
+func withGuardrailReport(c echo.Context) *guardrail.Report {
+	ctx, report := guardrail.WithReport(c.Request().Context())
+	c.SetRequest(c.Request().WithContext(ctx))
+	return report
+}
+
+// runError responds to a failed run. Guardrail blocks are reported as 422
+// with the violations, as are limits and loops that stop strict runs; other
+// errors as 500 with the given error code.
+func runError(c echo.Context, code string, err error, report *guardrail.Report) error {
+	if errors.Is(err, guardrail.ErrBlocked) {
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error:      "guardrail_blocked",
+			Message:    err.Error(),
+			Violations: report.Violations(),
+		})
+	}
+	if errors.Is(err, agent.ErrBudgetExceeded) || errors.Is(err, agent.ErrMaxIterations) || errors.Is(err, agent.ErrLoopDetected) {
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+			Error:   "limit_exceeded",
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   code,
+		Message: err.Error(),
+	})
+}
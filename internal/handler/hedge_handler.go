@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+)
+
+// HedgeHandler exposes the outcomes of hedged chat requests for tuning the
+// hedge delay.
+type HedgeHandler struct {
+	client *llm.HedgedClient
+}
+
+// NewHedgeHandler creates a new HedgeHandler.
+func NewHedgeHandler(client *llm.HedgedClient) *HedgeHandler {
+	return &HedgeHandler{
+		client: client,
+	}
+}
+
+// Stats handles GET /api/hedge/stats requests.
+func (h *HedgeHandler) Stats(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.client.Stats())
+}
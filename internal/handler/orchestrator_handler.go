@@ -6,34 +6,47 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
 )
 
 // OrchestratorHandler handles Orchestrator agent HTTP requests.
 type OrchestratorHandler struct {
-	agent *agent.OrchestratorAgent
+	agent    *agent.OrchestratorAgent
+	sessions *memory.ConversationManager
 }
 
 // NewOrchestratorHandler creates a new OrchestratorHandler.
-func NewOrchestratorHandler(a *agent.OrchestratorAgent) *OrchestratorHandler {
+// Sessions may be nil to disable session_id support.
+func NewOrchestratorHandler(a *agent.OrchestratorAgent, sessions *memory.ConversationManager) *OrchestratorHandler {
 	return &OrchestratorHandler{
-		agent: a,
+		agent:    a,
+		sessions: sessions,
 	}
 }
 
 // OrchestratorRequest represents the request body for orchestrator endpoint.
+// SessionID continues a server-side conversation as in AgentRequest.
+// Budget is shared by all workers; subtasks left when it runs out are skipped.
 type OrchestratorRequest struct {
-	Query   string         `json:"query" validate:"required"`
-	History []AgentMessage `json:"history,omitempty"`
-	Verbose bool           `json:"verbose,omitempty"`
+	Query     string         `json:"query" validate:"required"`
+	History   []AgentMessage `json:"history,omitempty"`
+	Verbose   bool           `json:"verbose,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Budget    *BudgetRequest `json:"budget,omitempty"`
 }
 
 // OrchestratorResponse represents the response from the orchestrator agent.
 type OrchestratorResponse struct {
-	Output   string        `json:"output"`
-	Plan     *TaskPlanInfo `json:"plan,omitempty"`
-	Workers  []WorkerInfo  `json:"workers,omitempty"`
-	Subtasks []SubtaskInfo `json:"subtasks,omitempty"`
-	Usage    UsageInfo     `json:"usage"`
+	Output    string        `json:"output"`
+	Plan      *TaskPlanInfo `json:"plan,omitempty"`
+	Workers   []WorkerInfo  `json:"workers,omitempty"`
+	Subtasks  []SubtaskInfo `json:"subtasks,omitempty"`
+	Usage     UsageInfo     `json:"usage"`
+	SessionID string        `json:"session_id,omitempty"`
+
+	Violations []guardrail.Violation `json:"violations,omitempty"`
+	Budget     *agent.BudgetUsage    `json:"budget,omitempty"`
 }
 
 // TaskPlanInfo represents the task decomposition plan.
@@ -51,13 +64,15 @@ type WorkerInfo struct {
 
 // SubtaskInfo represents a subtask and its result.
 type SubtaskInfo struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	WorkerType  string `json:"worker_type"`
-	Success     bool   `json:"success"`
-	Output      string `json:"output,omitempty"`
-	Error       string `json:"error,omitempty"`
-	DurationMs  int64  `json:"duration_ms"`
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	WorkerType  string     `json:"worker_type"`
+	Worker      string     `json:"worker,omitempty"`
+	Success     bool       `json:"success"`
+	Output      string     `json:"output,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DurationMs  int64      `json:"duration_ms"`
+	Usage       *UsageInfo `json:"usage,omitempty"`
 }
 
 // Run handles POST /api/orchestrator requests.
@@ -77,27 +92,34 @@ func (h *OrchestratorHandler) Run(c echo.Context) error {
 		})
 	}
 
-	// Convert history to agent messages
-	history := make([]agent.Message, 0, len(req.History))
-	for _, msg := range req.History {
-		history = append(history, agent.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
+	report := withGuardrailReport(c)
+	budget := startBudget(c, req.Budget)
+	ctx := c.Request().Context()
+	turn, sessionHistory, err := openSession(c, h.sessions, req.SessionID)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "session_error",
+			Message: err.Error(),
 		})
 	}
+	defer turn.end()
+
+	// Convert history to agent messages
+	history := toAgentMessages(append(sessionHistory, req.History...))
 
 	// Run the orchestrator agent
-	resp, err := h.agent.RunWithHistory(c.Request().Context(), history, req.Query)
+	resp, err := runAgent(c, ctx, h.agent, history, req.Query)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "orchestrator_error",
-			Message: err.Error(),
-		})
+		return runError(c, "orchestrator_error", err, report)
 	}
+	turn.record(ctx, userTurn(req.History, req.Query), resp.Output, resp.Steps)
 
 	// Build response
 	result := OrchestratorResponse{
-		Output: resp.Output,
+		Output:     resp.Output,
+		SessionID:  turn.sessionID(),
+		Violations: report.Violations(),
+		Budget:     budgetUsage(budget),
 		Usage: UsageInfo{
 			PromptTokens:     resp.Usage.PromptTokens,
 			CompletionTokens: resp.Usage.CompletionTokens,
@@ -108,53 +130,53 @@ func (h *OrchestratorHandler) Run(c echo.Context) error {
 	// Extract detailed info if verbose mode
 	if req.Verbose {
 		// Extract plan from metadata
-		if metadata, ok := resp.Metadata["orchestrator"]; ok {
-			if orchData, ok := metadata.(map[string]any); ok {
-				// Plan info
-				if plan, ok := orchData["plan"].(*agent.TaskPlan); ok && plan != nil {
-					result.Plan = &TaskPlanInfo{
-						Analysis:     plan.Analysis,
-						SubtaskCount: len(plan.Subtasks),
-					}
+		if trace, ok := resp.Metadata["orchestrator"].(*agent.OrchestratorTrace); ok {
+			// Plan info
+			if plan := trace.Plan; plan != nil {
+				result.Plan = &TaskPlanInfo{
+					Analysis:     plan.Analysis,
+					SubtaskCount: len(plan.Subtasks),
+				}
 
-					// Subtask results
-					for _, subtask := range plan.Subtasks {
-						result.Subtasks = append(result.Subtasks, SubtaskInfo{
-							ID:          subtask.ID,
-							Description: subtask.Description,
-							WorkerType:  subtask.WorkerType,
-						})
-					}
+				// Subtask results
+				for _, subtask := range plan.Subtasks {
+					result.Subtasks = append(result.Subtasks, SubtaskInfo{
+						ID:          subtask.ID,
+						Description: subtask.Description,
+						WorkerType:  subtask.WorkerType,
+					})
 				}
+			}
 
-				// Results
-				if results, ok := orchData["results"].([]agent.SubtaskResult); ok {
-					resultMap := make(map[string]agent.SubtaskResult)
-					for _, r := range results {
-						resultMap[r.ID] = r
-					}
+			// Results
+			resultMap := make(map[string]agent.SubtaskResult)
+			for _, r := range trace.Results {
+				resultMap[r.ID] = r
+			}
 
-					// Update subtasks with results
-					for i := range result.Subtasks {
-						if r, ok := resultMap[result.Subtasks[i].ID]; ok {
-							result.Subtasks[i].Success = r.Success
-							result.Subtasks[i].Output = r.Output
-							result.Subtasks[i].Error = r.Error
-							result.Subtasks[i].DurationMs = r.Duration
-						}
+			// Update subtasks with results
+			for i := range result.Subtasks {
+				if r, ok := resultMap[result.Subtasks[i].ID]; ok {
+					result.Subtasks[i].Worker = r.Worker
+					result.Subtasks[i].Success = r.Success
+					result.Subtasks[i].Output = r.Output
+					result.Subtasks[i].Error = r.Error
+					result.Subtasks[i].DurationMs = r.Duration
+					result.Subtasks[i].Usage = &UsageInfo{
+						PromptTokens:     r.Usage.PromptTokens,
+						CompletionTokens: r.Usage.CompletionTokens,
+						TotalTokens:      r.Usage.TotalTokens,
 					}
 				}
+			}
 
-				// Workers info
-				if workers, ok := orchData["workers"].([]*agent.WorkerAgent); ok {
-					for _, w := range workers {
-						result.Workers = append(result.Workers, WorkerInfo{
-							Name:         w.Name,
-							Description:  w.Description,
-							Capabilities: w.Tools,
-						})
-					}
-				}
+			// Workers info
+			for _, w := range trace.Workers {
+				result.Workers = append(result.Workers, WorkerInfo{
+					Name:         w.Name,
+					Description:  w.Description,
+					Capabilities: w.Tools,
+				})
 			}
 		}
 	}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+)
+
+// PlanExecuteHandler handles Plan-and-Execute agent HTTP requests.
+type PlanExecuteHandler struct {
+	agent *agent.PlanExecuteAgent
+}
+
+// NewPlanExecuteHandler creates a new PlanExecuteHandler.
+func NewPlanExecuteHandler(a *agent.PlanExecuteAgent) *PlanExecuteHandler {
+	return &PlanExecuteHandler{
+		agent: a,
+	}
+}
+
+// PlanExecuteRequest represents the request body for plan-execute endpoint.
+// Budget is shared by all steps; steps left when it runs out are skipped.
+type PlanExecuteRequest struct {
+	Query   string         `json:"query" validate:"required"`
+	History []AgentMessage `json:"history,omitempty"`
+	Verbose bool           `json:"verbose,omitempty"`
+	Budget  *BudgetRequest `json:"budget,omitempty"`
+}
+
+// PlanExecuteResponse represents the response from the plan-execute agent.
+type PlanExecuteResponse struct {
+	Output      string         `json:"output"`
+	InitialPlan []string       `json:"initial_plan,omitempty"`
+	Completed   []PlanStepInfo `json:"completed,omitempty"`
+	Replans     int            `json:"replans"`
+	Steps       []StepInfo     `json:"steps,omitempty"`
+	Usage       UsageInfo      `json:"usage"`
+
+	Violations []guardrail.Violation `json:"violations,omitempty"`
+	Budget     *agent.BudgetUsage    `json:"budget,omitempty"`
+}
+
+// PlanStepInfo represents an executed plan step and its result.
+type PlanStepInfo struct {
+	Step    string `json:"step"`
+	Success bool   `json:"success"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Run handles POST /api/plan-execute requests.
+func (h *PlanExecuteHandler) Run(c echo.Context) error {
+	var req PlanExecuteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse request body",
+		})
+	}
+
+	if req.Query == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Query is required",
+		})
+	}
+
+	// Convert history to agent messages
+	history := make([]agent.Message, 0, len(req.History))
+	for _, msg := range req.History {
+		history = append(history, agent.Message{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+
+	// Run the plan-execute agent
+	report := withGuardrailReport(c)
+	budget := startBudget(c, req.Budget)
+	resp, err := runAgent(c, c.Request().Context(), h.agent, history, req.Query)
+	if err != nil {
+		return runError(c, "plan_execute_error", err, report)
+	}
+
+	result := PlanExecuteResponse{
+		Output:     resp.Output,
+		Violations: report.Violations(),
+		Budget:     budgetUsage(budget),
+		Usage: UsageInfo{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}
+
+	if metadata, ok := resp.Metadata["plan_execute"]; ok {
+		if peData, ok := metadata.(map[string]any); ok {
+			if replans, ok := peData["replans"].(int); ok {
+				result.Replans = replans
+			}
+			if req.Verbose {
+				if plan, ok := peData["initial_plan"].([]string); ok {
+					result.InitialPlan = plan
+				}
+				if completed, ok := peData["completed"].([]agent.PlanStepResult); ok {
+					for _, r := range completed {
+						result.Completed = append(result.Completed, PlanStepInfo{
+							Step:    r.Step,
+							Success: r.Success,
+							Output:  r.Output,
+							Error:   r.Error,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	// Convert steps, including plan revisions
+	if req.Verbose {
+		for _, step := range resp.Steps {
+			result.Steps = append(result.Steps, StepInfo{
+				Type:       step.Type,
+				Content:    step.Content,
+				ToolName:   step.ToolName,
+				ToolInput:  step.ToolInput,
+				ToolOutput: step.ToolOutput,
+				Agent:      step.Agent,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
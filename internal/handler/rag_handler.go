@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/rag"
+)
+
+// RAGHandler handles knowledge base question answering requests.
+type RAGHandler struct {
+	chain *rag.QAChain
+}
+
+// NewRAGHandler creates a new RAGHandler.
+func NewRAGHandler(chain *rag.QAChain) *RAGHandler {
+	return &RAGHandler{
+		chain: chain,
+	}
+}
+
+// RAGQueryRequest represents the request body for the RAG query endpoint.
+type RAGQueryRequest struct {
+	Query string `json:"query" validate:"required"`
+
+	// TopK overrides the number of documents retrieved.
+	TopK int `json:"top_k,omitempty"`
+
+	// Budget limits the answer's LLM call; an exhausted budget fails the
+	// request, as there is no best-effort answer to fall back on.
+	Budget *BudgetRequest `json:"budget,omitempty"`
+}
+
+// RAGQueryResponse represents the answer and the sources it may cite.
+type RAGQueryResponse struct {
+	Answer  string       `json:"answer"`
+	Sources []rag.Source `json:"sources"`
+	Usage   UsageInfo    `json:"usage"`
+
+	Violations []guardrail.Violation `json:"violations,omitempty"`
+	Budget     *agent.BudgetUsage    `json:"budget,omitempty"`
+}
+
+// Query handles POST /api/rag/query requests.
+func (h *RAGHandler) Query(c echo.Context) error {
+	var req RAGQueryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse request body",
+		})
+	}
+
+	if req.Query == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: "Query is required",
+		})
+	}
+
+	report := withGuardrailReport(c)
+	budget := startBudget(c, req.Budget)
+	answer, err := h.chain.Query(c.Request().Context(), req.Query, req.TopK)
+	if err != nil {
+		return runError(c, "rag_error", err, report)
+	}
+
+	return c.JSON(http.StatusOK, RAGQueryResponse{
+		Answer:     answer.Answer,
+		Sources:    answer.Sources,
+		Violations: report.Violations(),
+		Budget:     budgetUsage(budget),
+		Usage: UsageInfo{
+			PromptTokens:     answer.Usage.PromptTokens,
+			CompletionTokens: answer.Usage.CompletionTokens,
+			TotalTokens:      answer.Usage.TotalTokens,
+		},
+	})
+}
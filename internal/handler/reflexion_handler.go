@@ -1,30 +1,54 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
 )
 
 // ReflexionHandler handles Reflexion agent HTTP requests.
 type ReflexionHandler struct {
-	agent *agent.ReflexionAgent
+	agent    *agent.ReflexionAgent
+	sessions *memory.ConversationManager
 }
 
 // NewReflexionHandler creates a new ReflexionHandler.
-func NewReflexionHandler(a *agent.ReflexionAgent) *ReflexionHandler {
+// Sessions may be nil to disable session_id support.
+func NewReflexionHandler(a *agent.ReflexionAgent, sessions *memory.ConversationManager) *ReflexionHandler {
 	return &ReflexionHandler{
-		agent: a,
+		agent:    a,
+		sessions: sessions,
 	}
 }
 
 // ReflexionRequest represents the request body for reflexion endpoint.
+// UserID and SessionID scope the reflections the agent learns from and stores;
+// SessionID also continues the server-side conversation as in AgentRequest.
+// Evaluators selects and weights named evaluators for quality gating; the
+// name "rules" refers to the checks in Rules. Budget spans all attempts.
 type ReflexionRequest struct {
-	Query   string         `json:"query" validate:"required"`
-	History []AgentMessage `json:"history,omitempty"`
-	Verbose bool           `json:"verbose,omitempty"`
+	Query      string            `json:"query" validate:"required"`
+	History    []AgentMessage    `json:"history,omitempty"`
+	Verbose    bool              `json:"verbose,omitempty"`
+	UserID     string            `json:"user_id,omitempty"`
+	SessionID  string            `json:"session_id,omitempty"`
+	Reference  string            `json:"reference,omitempty"`
+	Evaluators []EvaluatorChoice `json:"evaluators,omitempty"`
+	Rules      *agent.RuleConfig `json:"rules,omitempty"`
+	Budget     *BudgetRequest    `json:"budget,omitempty"`
+}
+
+// EvaluatorChoice selects a named evaluator and its weight.
+type EvaluatorChoice struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight,omitempty"`
 }
 
 // ReflexionResponse represents the response from the reflexion agent.
@@ -35,10 +59,15 @@ type ReflexionResponse struct {
 	FinalEvaluation *EvaluationInfo  `json:"final_evaluation,omitempty"`
 	TotalAttempts   int              `json:"total_attempts"`
 	Usage           UsageInfo        `json:"usage"`
+	SessionID       string           `json:"session_id,omitempty"`
+
+	Violations []guardrail.Violation `json:"violations,omitempty"`
+	Budget     *agent.BudgetUsage    `json:"budget,omitempty"`
 }
 
 // ReflectionInfo represents a single reflection iteration.
 type ReflectionInfo struct {
+	ID         string          `json:"id,omitempty"`
 	Attempt    int             `json:"attempt"`
 	Score      float64         `json:"score"`
 	Evaluation *EvaluationInfo `json:"evaluation,omitempty"`
@@ -70,23 +99,42 @@ func (h *ReflexionHandler) Run(c echo.Context) error {
 		})
 	}
 
-	// Convert history to agent messages
-	history := make([]agent.Message, 0, len(req.History))
-	for _, msg := range req.History {
-		history = append(history, agent.Message{
-			Role:    msg.Role,
-			Content: msg.Content,
+	evaluator, err := h.evaluator(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
 		})
 	}
 
-	// Run the reflexion agent
-	resp, err := h.agent.RunWithHistory(c.Request().Context(), history, req.Query)
+	report := withGuardrailReport(c)
+	budget := startBudget(c, req.Budget)
+	turn, sessionHistory, err := openSession(c, h.sessions, req.SessionID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "reflexion_error",
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "session_error",
 			Message: err.Error(),
 		})
 	}
+	defer turn.end()
+
+	// Convert history to agent messages
+	history := toAgentMessages(append(sessionHistory, req.History...))
+
+	// Run the reflexion agent within the caller's reflection scope
+	ctx := agent.WithReflectionScope(c.Request().Context(), agent.ReflectionScope{
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+	})
+	ctx = agent.WithEvalOptions(ctx, agent.EvalOptions{
+		Evaluator: evaluator,
+		Reference: req.Reference,
+	})
+	resp, err := runAgent(c, ctx, h.agent, history, req.Query)
+	if err != nil {
+		return runError(c, "reflexion_error", err, report)
+	}
+	turn.record(ctx, userTurn(req.History, req.Query), resp.Output, resp.Steps)
 
 	// Convert steps
 	var steps []StepInfo
@@ -98,6 +146,7 @@ func (h *ReflexionHandler) Run(c echo.Context) error {
 				ToolName:   step.ToolName,
 				ToolInput:  step.ToolInput,
 				ToolOutput: step.ToolOutput,
+				Agent:      step.Agent,
 			})
 		}
 	}
@@ -107,36 +156,31 @@ func (h *ReflexionHandler) Run(c echo.Context) error {
 	var finalEval *EvaluationInfo
 	totalAttempts := 1
 
-	if metadata, ok := resp.Metadata["reflexion"]; ok {
-		if refData, ok := metadata.(map[string]any); ok {
-			if attempts, ok := refData["attempts"].(int); ok {
-				totalAttempts = attempts
+	if trace, ok := resp.Metadata["reflexion"].(*agent.ReflexionTrace); ok {
+		totalAttempts = trace.Attempts
+		for _, ref := range trace.Reflections {
+			info := ReflectionInfo{
+				ID:       ref.ID,
+				Attempt:  ref.Attempt,
+				Score:    ref.Evaluation.Score,
+				Feedback: ref.Feedback,
 			}
-			if refList, ok := refData["reflections"].([]agent.Reflection); ok {
-				for i, ref := range refList {
-					info := ReflectionInfo{
-						Attempt:  i + 1,
-						Score:    ref.Evaluation.Score,
-						Feedback: ref.Feedback,
-					}
-					if req.Verbose {
-						info.Evaluation = &EvaluationInfo{
-							Score:      ref.Evaluation.Score,
-							Reasoning:  ref.Evaluation.Reasoning,
-							Strengths:  ref.Evaluation.Strengths,
-							Weaknesses: ref.Evaluation.Weaknesses,
-						}
-					}
-					reflections = append(reflections, info)
+			if req.Verbose {
+				info.Evaluation = &EvaluationInfo{
+					Score:      ref.Evaluation.Score,
+					Reasoning:  ref.Evaluation.Reasoning,
+					Strengths:  ref.Evaluation.Strengths,
+					Weaknesses: ref.Evaluation.Weaknesses,
 				}
 			}
-			if eval, ok := refData["final_evaluation"].(*agent.Evaluation); ok && eval != nil {
-				finalEval = &EvaluationInfo{
-					Score:      eval.Score,
-					Reasoning:  eval.Reasoning,
-					Strengths:  eval.Strengths,
-					Weaknesses: eval.Weaknesses,
-				}
+			reflections = append(reflections, info)
+		}
+		if eval := trace.FinalEvaluation; eval != nil {
+			finalEval = &EvaluationInfo{
+				Score:      eval.Score,
+				Reasoning:  eval.Reasoning,
+				Strengths:  eval.Strengths,
+				Weaknesses: eval.Weaknesses,
 			}
 		}
 	}
@@ -152,5 +196,105 @@ func (h *ReflexionHandler) Run(c echo.Context) error {
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
+		SessionID:  turn.sessionID(),
+		Violations: report.Violations(),
+		Budget:     budgetUsage(budget),
 	})
 }
+
+// evaluator builds the evaluator selected by the request, or nil to use the
+// agent's default.
+func (h *ReflexionHandler) evaluator(req ReflexionRequest) (agent.Evaluator, error) {
+	choices := req.Evaluators
+	if req.Rules != nil && !slices.ContainsFunc(choices, func(c EvaluatorChoice) bool { return c.Name == "rules" }) {
+		choices = append(choices, EvaluatorChoice{Name: "rules"})
+	}
+	if len(choices) == 0 {
+		return nil, nil
+	}
+
+	weighted := make([]agent.WeightedEvaluator, 0, len(choices))
+	for _, choice := range choices {
+		var e agent.Evaluator
+		if choice.Name == "rules" {
+			if req.Rules == nil {
+				return nil, errors.New("evaluator \"rules\" requires rules")
+			}
+			rules, err := agent.NewRuleEvaluator(*req.Rules)
+			if err != nil {
+				return nil, err
+			}
+			e = rules
+		} else {
+			var ok bool
+			if e, ok = h.agent.Evaluator(choice.Name); !ok {
+				return nil, fmt.Errorf("unknown evaluator %q", choice.Name)
+			}
+		}
+		weighted = append(weighted, agent.WeightedEvaluator{
+			Name:      choice.Name,
+			Evaluator: e,
+			Weight:    choice.Weight,
+		})
+	}
+
+	if len(weighted) == 1 {
+		return weighted[0].Evaluator, nil
+	}
+	return agent.NewCompositeEvaluator(weighted...), nil
+}
+
+// reflectionScope reads the user_id and session_id query parameters.
+func reflectionScope(c echo.Context) agent.ReflectionScope {
+	return agent.ReflectionScope{
+		UserID:    c.QueryParam("user_id"),
+		SessionID: c.QueryParam("session_id"),
+	}
+}
+
+// ListReflections handles GET /api/reflexion/reflections requests.
+func (h *ReflexionHandler) ListReflections(c echo.Context) error {
+	reflections, err := h.agent.Memory().List(c.Request().Context(), reflectionScope(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "reflection_store_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"reflections": reflections,
+		"count":       len(reflections),
+	})
+}
+
+// DeleteReflection handles DELETE /api/reflexion/reflections/:id requests.
+func (h *ReflexionHandler) DeleteReflection(c echo.Context) error {
+	err := h.agent.Memory().Delete(c.Request().Context(), reflectionScope(c), c.Param("id"))
+	if errors.Is(err, agent.ErrReflectionNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "reflection_not_found",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "reflection_store_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ClearReflections handles DELETE /api/reflexion/reflections requests.
+func (h *ReflexionHandler) ClearReflections(c echo.Context) error {
+	if err := h.agent.Memory().Clear(c.Request().Context(), reflectionScope(c)); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "reflection_store_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
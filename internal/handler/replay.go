@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"path"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/replay"
+)
+
+// recordDirKey is the echo context key holding the trace directory.
+const recordDirKey = "record_dir"
+
+// RecordRuns is middleware that records every agent run to a trace file in
+// dir for offline replay. The file path is returned in the X-Replay-Trace
+// response header.
+func RecordRuns(dir string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(recordDirKey, dir)
+			return next(c)
+		}
+	}
+}
+
+// runAgent runs a, recording the run when RecordRuns is in effect. The trace
+// names the agent after the defined agent or the endpoint, e.g. "reflexion".
+func runAgent(c echo.Context, ctx context.Context, a agent.Agent, history []agent.Message, query string) (*agent.Response, error) {
+	dir, _ := c.Get(recordDirKey).(string)
+	if dir == "" {
+		return a.RunWithHistory(ctx, history, query)
+	}
+
+	name := c.Param("name")
+	if name == "" {
+		name = path.Base(c.Path())
+	}
+	resp, err := replay.NewRecordingAgent(a, name, dir).RunWithHistory(ctx, history, query)
+	if resp != nil {
+		if file, ok := resp.Metadata["replay_trace"].(string); ok {
+			c.Response().Header().Set("X-Replay-Trace", file)
+			log.Printf("Recorded %s run to %s", name, file)
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/guardrail"
+	"github.com/hassan123789/go-ai-agent/internal/memory"
+)
+
+// HeaderSessionID carries the session ID on session-aware responses,
+// including streamed ones that have no JSON body.
+const HeaderSessionID = "X-Session-ID"
+
+// sessionTurn is one request/response turn of a server-side conversation.
+// It holds the session lock from startTurn until end, so concurrent turns
+// on the same session run one after another.
+type sessionTurn struct {
+	sessions   *memory.ConversationManager
+	id         string
+	unlock     func()
+	guardrails *guardrail.Pipeline
+}
+
+// startTurn locks the session for a turn. It returns nil when the request
+// has no session ID or sessions are disabled; a nil turn is a no-op.
+func startTurn(c echo.Context, sessions *memory.ConversationManager, id string) (*sessionTurn, error) {
+	if sessions == nil || id == "" {
+		return nil, nil
+	}
+
+	unlock, err := sessions.LockSession(c.Request().Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	c.Response().Header().Set(HeaderSessionID, id)
+
+	guardrails, _ := c.Get(guardrailsKey).(*guardrail.Pipeline)
+	return &sessionTurn{
+		sessions:   sessions,
+		id:         id,
+		unlock:     unlock,
+		guardrails: guardrails,
+	}, nil
+}
+
+// openSession starts a turn and loads the session's history.
+// The caller must end the returned turn.
+func openSession(c echo.Context, sessions *memory.ConversationManager, id string) (*sessionTurn, []AgentMessage, error) {
+	turn, err := startTurn(c, sessions, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history, err := turn.history(c.Request().Context())
+	if err != nil {
+		turn.end()
+		return nil, nil, err
+	}
+	return turn, history, nil
+}
+
+// sessionID returns the session ID, or "" for a nil turn.
+func (t *sessionTurn) sessionID() string {
+	if t == nil {
+		return ""
+	}
+	return t.id
+}
+
+// history returns the session's earlier user, assistant and system messages.
+// Recorded tool steps are kept for inspection but not replayed, since the
+// assistant answers already reflect them.
+func (t *sessionTurn) history(ctx context.Context) ([]AgentMessage, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	msgs, err := t.sessions.GetFromSession(ctx, t.id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]AgentMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Role == memory.RoleTool {
+			continue
+		}
+		history = append(history, AgentMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+	return history, nil
+}
+
+// record appends the turn's input messages, tool steps and final answer to
+// the session. User messages are saved as the model saw them, after the
+// input guardrails set by Guardrails. Failures are logged rather than
+// returned because the run itself has already succeeded.
+func (t *sessionTurn) record(ctx context.Context, inputs []AgentMessage, output string, steps []agent.Step) {
+	if t == nil {
+		return
+	}
+
+	msgs := make([]memory.Message, 0, len(inputs)+len(steps)+1)
+	for _, in := range inputs {
+		content := in.Content
+		if in.Role == memory.RoleUser {
+			checked, err := t.guardrails.CheckInput(ctx, content)
+			if err != nil {
+				log.Printf("[Session] failed to record turn for %s: %v", t.id, err)
+				return
+			}
+			content = checked
+		}
+		msgs = append(msgs, memory.NewMessage(in.Role, content))
+	}
+
+	// Pair each observation with the input of the action that produced it
+	pendingInput := make(map[string]string)
+	for _, step := range steps {
+		switch step.Type {
+		case agent.StepTypeAction:
+			pendingInput[step.ToolName] = step.ToolInput
+		case agent.StepTypeObservation:
+			if step.ToolName == "" {
+				continue
+			}
+			metadata := map[string]any{
+				"tool_name":  step.ToolName,
+				"tool_input": pendingInput[step.ToolName],
+			}
+			if step.Agent != "" {
+				metadata["agent"] = step.Agent
+			}
+			delete(pendingInput, step.ToolName)
+			msgs = append(msgs, memory.NewMessageWithMetadata(memory.RoleTool, step.ToolOutput, metadata))
+		}
+	}
+
+	msgs = append(msgs, memory.NewMessage(memory.RoleAssistant, output))
+
+	for _, msg := range msgs {
+		if err := t.sessions.AddToSession(ctx, t.id, msg); err != nil {
+			log.Printf("[Session] failed to record turn for %s: %v", t.id, err)
+			return
+		}
+	}
+}
+
+// end releases the session lock.
+func (t *sessionTurn) end() {
+	if t == nil {
+		return
+	}
+	t.unlock()
+}
+
+// userTurn returns the messages a query-style request adds to a session.
+func userTurn(history []AgentMessage, query string) []AgentMessage {
+	inputs := make([]AgentMessage, 0, len(history)+1)
+	inputs = append(inputs, history...)
+	return append(inputs, AgentMessage{Role: memory.RoleUser, Content: query})
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/hassan123789/go-ai-agent/internal/memory"
+)
+
+// SessionsHandler exposes the conversation sessions used by the
+// session-aware endpoints.
+type SessionsHandler struct {
+	sessions *memory.ConversationManager
+}
+
+// NewSessionsHandler creates a new SessionsHandler.
+func NewSessionsHandler(sessions *memory.ConversationManager) *SessionsHandler {
+	return &SessionsHandler{
+		sessions: sessions,
+	}
+}
+
+// Get handles GET /api/sessions/:id requests.
+// Tool messages carry tool_name and tool_input in their metadata.
+func (h *SessionsHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	if h.sessions.GetSession(id) == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "session_not_found",
+			Message: "Session " + id + " not found",
+		})
+	}
+
+	messages, err := h.sessions.GetFromSession(c.Request().Context(), id, 0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "session_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"session_id": id,
+		"messages":   messages,
+		"count":      len(messages),
+	})
+}
+
+// Delete handles DELETE /api/sessions/:id requests.
+// It waits for any in-flight turn on the session to finish.
+func (h *SessionsHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	unlock, err := h.sessions.LockSession(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "session_error",
+			Message: err.Error(),
+		})
+	}
+	defer unlock()
+
+	if err := h.sessions.DeleteSession(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "session_error",
+			Message: err.Error(),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
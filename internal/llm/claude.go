@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -64,6 +65,14 @@ func NewClaudeClient(cfg ClaudeConfig) (*ClaudeClient, error) {
 
 // Chat sends a chat completion request and returns the response.
 func (c *ClaudeClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	ctx, span := startChatSpan(ctx, ProviderClaude, string(c.modelFor(req.Model)), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	resp, err := c.chat(ctx, req)
+	endChatSpan(span, resp, err)
+	return resp, err
+}
+
+// chat implements Chat without tracing.
+func (c *ClaudeClient) chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	messages := make([]anthropic.MessageParam, 0, len(req.Messages))
 	var systemPrompt string
 
@@ -88,7 +97,7 @@ func (c *ClaudeClient) Chat(ctx context.Context, req *ChatRequest) (*ChatRespons
 	}
 
 	params := anthropic.MessageNewParams{
-		Model:     c.model,
+		Model:     c.modelFor(req.Model),
 		MaxTokens: int64(maxTokens),
 		Messages:  messages,
 	}
@@ -131,6 +140,17 @@ func (c *ClaudeClient) Chat(ctx context.Context, req *ChatRequest) (*ChatRespons
 
 // ChatStream sends a streaming chat completion request.
 func (c *ClaudeClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	ctx, span := startChatSpan(ctx, ProviderClaude, string(c.modelFor(req.Model)), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	stream, err := c.chatStream(ctx, req)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return traceStream(ctx, span, stream), nil
+}
+
+// chatStream implements ChatStream without tracing.
+func (c *ClaudeClient) chatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
 	messages := make([]anthropic.MessageParam, 0, len(req.Messages))
 	var systemPrompt string
 
@@ -155,7 +175,7 @@ func (c *ClaudeClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan
 	}
 
 	params := anthropic.MessageNewParams{
-		Model:     c.model,
+		Model:     c.modelFor(req.Model),
 		MaxTokens: int64(maxTokens),
 		Messages:  messages,
 	}
@@ -219,6 +239,14 @@ func (c *ClaudeClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan
 	return ch, nil
 }
 
+// modelFor returns the request's model override or the client default.
+func (c *ClaudeClient) modelFor(override string) anthropic.Model {
+	if override != "" {
+		return anthropic.Model(override)
+	}
+	return c.model
+}
+
 // Close releases any resources held by the client.
 func (c *ClaudeClient) Close() error {
 	// Anthropic client doesn't have explicit cleanup
@@ -21,8 +21,10 @@ const (
 )
 
 // ChatRequest represents a request to the LLM.
+// Model, when set, overrides the client's default model.
 type ChatRequest struct {
 	Messages    []Message
+	Model       string
 	MaxTokens   int
 	Temperature float32
 	Stream      bool
@@ -0,0 +1,306 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HedgedClient sends each request to a primary provider and, when no
+// response or first stream chunk has arrived within Delay, the same request
+// to a secondary provider. Whichever answers first is returned and the other
+// request is cancelled. A primary failure before the delay sends the
+// secondary request at once.
+//
+// Each request is traced as a "hedge" span around the provider spans,
+// recording whether the secondary was sent and which provider won. Stats
+// aggregates the same for tuning Delay.
+type HedgedClient struct {
+	primary       Client
+	secondary     Client
+	primaryName   Provider
+	secondaryName Provider
+	config        HedgeConfig
+
+	mu    sync.Mutex
+	stats HedgeStats
+}
+
+// HedgeConfig contains configuration for a HedgedClient.
+type HedgeConfig struct {
+	// Primary is the provider tried first. Default is the MultiProvider's
+	// primary.
+	Primary Provider
+
+	// Secondary is the provider of the hedged request. Default is the
+	// MultiProvider's fallback.
+	Secondary Provider
+
+	// Delay is how long the primary may go without a response or first
+	// chunk before the secondary is sent. Default is 500ms.
+	Delay time.Duration
+
+	// SecondaryModel is the model of hedged requests. A request's Model
+	// names a primary model, so it is not passed on; default is the
+	// secondary client's model.
+	SecondaryModel string
+}
+
+// HedgeStats counts the outcomes of a HedgedClient's requests.
+type HedgeStats struct {
+	// Requests is the number of requests made.
+	Requests int `json:"requests"`
+
+	// Hedged is the number of requests that were also sent to the secondary.
+	Hedged int `json:"hedged"`
+
+	// Wins counts the requests answered by each provider.
+	Wins map[Provider]int `json:"wins"`
+
+	// Failures is the number of requests both providers failed.
+	Failures int `json:"failures"`
+}
+
+// NewHedgedClient creates a hedged client over two of mp's providers.
+func NewHedgedClient(mp *MultiProvider, config HedgeConfig) (*HedgedClient, error) {
+	if config.Primary == "" {
+		config.Primary = mp.primary
+	}
+	if config.Secondary == "" {
+		config.Secondary = mp.fallback
+	}
+	if config.Delay <= 0 {
+		config.Delay = 500 * time.Millisecond
+	}
+
+	primary, ok := mp.GetClient(config.Primary)
+	if !ok {
+		return nil, fmt.Errorf("primary provider %s not found", config.Primary)
+	}
+	if config.Secondary == "" || config.Secondary == config.Primary {
+		return nil, errors.New("hedging requires a secondary provider distinct from the primary")
+	}
+	secondary, ok := mp.GetClient(config.Secondary)
+	if !ok {
+		return nil, fmt.Errorf("secondary provider %s not found", config.Secondary)
+	}
+
+	return &HedgedClient{
+		primary:       primary,
+		secondary:     secondary,
+		primaryName:   config.Primary,
+		secondaryName: config.Secondary,
+		config:        config,
+		stats:         HedgeStats{Wins: make(map[Provider]int)},
+	}, nil
+}
+
+// hedgeAttempt is the outcome of one provider's request. For streams, chunk
+// is the first chunk and stream the rest.
+type hedgeAttempt struct {
+	provider Provider
+	resp     *ChatResponse
+	chunk    StreamChunk
+	stream   <-chan StreamChunk
+	cancel   context.CancelFunc
+	err      error
+}
+
+// Chat sends a chat completion request, hedged after Delay.
+func (h *HedgedClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	winner, err := h.race(ctx, req, func(ctx context.Context, client Client, req *ChatRequest) hedgeAttempt {
+		resp, err := client.Chat(ctx, req)
+		return hedgeAttempt{resp: resp, err: err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	winner.cancel()
+	return winner.resp, nil
+}
+
+// ChatStream sends a streaming chat completion request, hedged after Delay
+// without a first chunk. It returns once a provider has sent its first
+// chunk, or with an error if both fail first.
+func (h *HedgedClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	winner, err := h.race(ctx, req, func(ctx context.Context, client Client, req *ChatRequest) hedgeAttempt {
+		stream, err := client.ChatStream(ctx, req)
+		if err != nil {
+			return hedgeAttempt{err: err}
+		}
+		chunk, ok := <-stream
+		switch {
+		case !ok:
+			return hedgeAttempt{err: errors.New("stream closed without a response")}
+		case chunk.Error != nil:
+			go drain(stream)
+			return hedgeAttempt{err: chunk.Error}
+		}
+		return hedgeAttempt{chunk: chunk, stream: stream}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer winner.cancel()
+
+		select {
+		case out <- winner.chunk:
+		case <-ctx.Done():
+			drain(winner.stream)
+			return
+		}
+		for chunk := range winner.stream {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				drain(winner.stream)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// race runs attempt on the primary and, after Delay or a primary failure,
+// on the secondary. It returns the first successful attempt, whose context
+// the caller must cancel once done with it, and cancels the other.
+func (h *HedgedClient) race(ctx context.Context, req *ChatRequest, attempt func(context.Context, Client, *ChatRequest) hedgeAttempt) (hedgeAttempt, error) {
+	ctx, span := tracer().Start(ctx, "hedge", trace.WithAttributes(
+		attribute.String("llm.hedge.primary", string(h.primaryName)),
+		attribute.String("llm.hedge.secondary", string(h.secondaryName)),
+		attribute.Int64("llm.hedge.delay_ms", h.config.Delay.Milliseconds()),
+	))
+	start := time.Now()
+
+	results := make(chan hedgeAttempt, 2)
+	var launched []Provider
+	var cancels []context.CancelFunc
+	launch := func(provider Provider, client Client, req *ChatRequest) {
+		actx, cancel := context.WithCancel(ctx)
+		launched = append(launched, provider)
+		cancels = append(cancels, cancel)
+		go func() {
+			a := attempt(actx, client, req)
+			a.provider, a.cancel = provider, cancel
+			results <- a
+		}()
+	}
+
+	launch(h.primaryName, h.primary, req)
+	timer := time.NewTimer(h.config.Delay)
+	defer timer.Stop()
+	hedge := timer.C
+
+	sendSecondary := func() {
+		hedged := *req
+		hedged.Model = h.config.SecondaryModel
+		launch(h.secondaryName, h.secondary, &hedged)
+		hedge = nil
+	}
+
+	var errs []error
+	for len(errs) < len(launched) {
+		select {
+		case <-hedge:
+			sendSecondary()
+		case a := <-results:
+			if a.err != nil {
+				a.cancel()
+				errs = append(errs, fmt.Errorf("%s: %w", a.provider, a.err))
+				if hedge != nil {
+					sendSecondary()
+				}
+				continue
+			}
+
+			// Cancel the loser and discard its result
+			for i, provider := range launched {
+				if provider != a.provider {
+					cancels[i]()
+				}
+			}
+			go discard(results, len(launched)-len(errs)-1)
+			h.finish(span, start, a.provider, len(launched) > 1, nil)
+			return a, nil
+		case <-ctx.Done():
+			for _, cancel := range cancels {
+				cancel()
+			}
+			go discard(results, len(launched)-len(errs))
+			h.finish(span, start, "", len(launched) > 1, ctx.Err())
+			return hedgeAttempt{}, ctx.Err()
+		}
+	}
+
+	err := fmt.Errorf("all hedged requests failed: %w", errors.Join(errs...))
+	h.finish(span, start, "", len(launched) > 1, err)
+	return hedgeAttempt{}, err
+}
+
+// finish records a request's outcome in the stats and its span, and ends
+// the span. winner is empty if the request failed.
+func (h *HedgedClient) finish(span trace.Span, start time.Time, winner Provider, hedged bool, err error) {
+	h.mu.Lock()
+	h.stats.Requests++
+	if hedged {
+		h.stats.Hedged++
+	}
+	if winner != "" {
+		h.stats.Wins[winner]++
+	} else {
+		h.stats.Failures++
+	}
+	h.mu.Unlock()
+
+	span.SetAttributes(attribute.Bool("llm.hedge.hedged", hedged))
+	if winner != "" {
+		span.SetAttributes(
+			attribute.String("llm.hedge.winner", string(winner)),
+			attribute.Int64("llm.hedge.first_response_ms", time.Since(start).Milliseconds()),
+		)
+	}
+	endSpan(span, err)
+}
+
+// Stats returns the outcomes of the requests made so far.
+func (h *HedgedClient) Stats() HedgeStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats := h.stats
+	stats.Wins = make(map[Provider]int, len(h.stats.Wins))
+	for provider, wins := range h.stats.Wins {
+		stats.Wins[provider] = wins
+	}
+	return stats
+}
+
+// Close releases nothing; the clients belong to the MultiProvider.
+func (h *HedgedClient) Close() error {
+	return nil
+}
+
+// discard waits for n losing attempts and releases them.
+func discard(results <-chan hedgeAttempt, n int) {
+	for range n {
+		a := <-results
+		if a.stream != nil {
+			drain(a.stream)
+		}
+		a.cancel()
+	}
+}
+
+// drain reads a stream to its end so that its producer can finish.
+func drain(stream <-chan StreamChunk) {
+	for range stream {
+	}
+}
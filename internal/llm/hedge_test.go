@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hedgeTestClient answers after delay unless its context is cancelled first.
+type hedgeTestClient struct {
+	delay     time.Duration
+	err       error
+	content   string
+	calls     atomic.Int32
+	model     atomic.Value
+	cancelled chan struct{}
+}
+
+func newHedgeTestClient(delay time.Duration, content string, err error) *hedgeTestClient {
+	return &hedgeTestClient{delay: delay, content: content, err: err, cancelled: make(chan struct{})}
+}
+
+func (c *hedgeTestClient) wait(ctx context.Context, req *ChatRequest) error {
+	c.calls.Add(1)
+	c.model.Store(req.Model)
+	select {
+	case <-time.After(c.delay):
+		return c.err
+	case <-ctx.Done():
+		close(c.cancelled)
+		return ctx.Err()
+	}
+}
+
+func (c *hedgeTestClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	if err := c.wait(ctx, req); err != nil {
+		return nil, err
+	}
+	return &ChatResponse{Content: c.content}, nil
+}
+
+func (c *hedgeTestClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	ch := make(chan StreamChunk)
+	go func() {
+		defer close(ch)
+		if err := c.wait(ctx, req); err != nil {
+			ch <- StreamChunk{Error: err, Done: true}
+			return
+		}
+		for _, r := range c.content {
+			ch <- StreamChunk{Content: string(r)}
+		}
+		ch <- StreamChunk{Done: true}
+	}()
+	return ch, nil
+}
+
+func (c *hedgeTestClient) Close() error { return nil }
+
+func newTestHedgedClient(t *testing.T, primary, secondary Client, config HedgeConfig) *HedgedClient {
+	t.Helper()
+	h, err := NewHedgedClient(&MultiProvider{
+		clients:  map[Provider]Client{ProviderOpenAI: primary, ProviderClaude: secondary},
+		primary:  ProviderOpenAI,
+		fallback: ProviderClaude,
+	}, config)
+	if err != nil {
+		t.Fatalf("failed to create hedged client: %v", err)
+	}
+	return h
+}
+
+func TestHedgedClient_Chat(t *testing.T) {
+	t.Run("fast primary is not hedged", func(t *testing.T) {
+		primary := newHedgeTestClient(0, "primary", nil)
+		secondary := newHedgeTestClient(0, "secondary", nil)
+		h := newTestHedgedClient(t, primary, secondary, HedgeConfig{Delay: time.Hour})
+
+		resp, err := h.Chat(context.Background(), &ChatRequest{})
+		if err != nil || resp.Content != "primary" {
+			t.Fatalf("expected the primary's answer, got %v, %v", resp, err)
+		}
+		if secondary.calls.Load() != 0 {
+			t.Error("expected no hedged request")
+		}
+		if stats := h.Stats(); stats.Requests != 1 || stats.Hedged != 0 || stats.Wins[ProviderOpenAI] != 1 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("slow primary is hedged and cancelled", func(t *testing.T) {
+		primary := newHedgeTestClient(time.Hour, "primary", nil)
+		secondary := newHedgeTestClient(0, "secondary", nil)
+		h := newTestHedgedClient(t, primary, secondary, HedgeConfig{Delay: 10 * time.Millisecond, SecondaryModel: "claude-haiku-4-5"})
+
+		resp, err := h.Chat(context.Background(), &ChatRequest{Model: "gpt-4o"})
+		if err != nil || resp.Content != "secondary" {
+			t.Fatalf("expected the secondary's answer, got %v, %v", resp, err)
+		}
+		select {
+		case <-primary.cancelled:
+		case <-time.After(time.Second):
+			t.Error("expected the primary request to be cancelled")
+		}
+		if model := secondary.model.Load(); model != "claude-haiku-4-5" {
+			t.Errorf("expected the secondary model, got %v", model)
+		}
+		if stats := h.Stats(); stats.Hedged != 1 || stats.Wins[ProviderClaude] != 1 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	})
+
+	t.Run("primary failure hedges at once", func(t *testing.T) {
+		primary := newHedgeTestClient(0, "", errors.New("rate limited"))
+		secondary := newHedgeTestClient(0, "secondary", nil)
+		h := newTestHedgedClient(t, primary, secondary, HedgeConfig{Delay: time.Hour})
+
+		resp, err := h.Chat(context.Background(), &ChatRequest{})
+		if err != nil || resp.Content != "secondary" {
+			t.Fatalf("expected the secondary's answer, got %v, %v", resp, err)
+		}
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		primary := newHedgeTestClient(0, "", errors.New("rate limited"))
+		secondary := newHedgeTestClient(0, "", errors.New("overloaded"))
+		h := newTestHedgedClient(t, primary, secondary, HedgeConfig{})
+
+		_, err := h.Chat(context.Background(), &ChatRequest{})
+		if err == nil || !strings.Contains(err.Error(), "openai: rate limited") || !strings.Contains(err.Error(), "claude: overloaded") {
+			t.Errorf("expected both errors, got %v", err)
+		}
+		if stats := h.Stats(); stats.Failures != 1 {
+			t.Errorf("unexpected stats %+v", stats)
+		}
+	})
+}
+
+func TestHedgedClient_ChatStream(t *testing.T) {
+	primary := newHedgeTestClient(time.Hour, "primary", nil)
+	secondary := newHedgeTestClient(0, "secondary", nil)
+	h := newTestHedgedClient(t, primary, secondary, HedgeConfig{Delay: 10 * time.Millisecond})
+
+	stream, err := h.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var content strings.Builder
+	for chunk := range stream {
+		content.WriteString(chunk.Content)
+	}
+
+	if content.String() != "secondary" {
+		t.Errorf("expected the secondary's stream, got %q", content.String())
+	}
+	select {
+	case <-primary.cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the primary stream to be cancelled")
+	}
+	if stats := h.Stats(); stats.Wins[ProviderClaude] != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestNewHedgedClient_RequiresSecondary(t *testing.T) {
+	mp := &MultiProvider{clients: map[Provider]Client{ProviderOpenAI: newHedgeTestClient(0, "", nil)}, primary: ProviderOpenAI}
+	if _, err := NewHedgedClient(mp, HedgeConfig{}); err == nil {
+		t.Error("expected an error without a secondary provider")
+	}
+}
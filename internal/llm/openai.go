@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -50,6 +51,14 @@ func NewOpenAIClient(cfg OpenAIConfig) (*OpenAIClient, error) {
 
 // Chat sends a chat completion request and returns the response.
 func (c *OpenAIClient) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	ctx, span := startChatSpan(ctx, ProviderOpenAI, c.modelFor(req.Model), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	resp, err := c.chat(ctx, req)
+	endChatSpan(span, resp, err)
+	return resp, err
+}
+
+// chat implements Chat without tracing.
+func (c *OpenAIClient) chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = openai.ChatCompletionMessage{
@@ -69,7 +78,7 @@ func (c *OpenAIClient) Chat(ctx context.Context, req *ChatRequest) (*ChatRespons
 	}
 
 	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       c.modelFor(req.Model),
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: temperature,
@@ -95,6 +104,17 @@ func (c *OpenAIClient) Chat(ctx context.Context, req *ChatRequest) (*ChatRespons
 
 // ChatStream sends a streaming chat completion request.
 func (c *OpenAIClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
+	ctx, span := startChatSpan(ctx, ProviderOpenAI, c.modelFor(req.Model), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	stream, err := c.chatStream(ctx, req)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return traceStream(ctx, span, stream), nil
+}
+
+// chatStream implements ChatStream without tracing.
+func (c *OpenAIClient) chatStream(ctx context.Context, req *ChatRequest) (<-chan StreamChunk, error) {
 	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = openai.ChatCompletionMessage{
@@ -114,7 +134,7 @@ func (c *OpenAIClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan
 	}
 
 	stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       c.modelFor(req.Model),
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: temperature,
@@ -155,6 +175,14 @@ func (c *OpenAIClient) ChatStream(ctx context.Context, req *ChatRequest) (<-chan
 	return ch, nil
 }
 
+// modelFor returns the request's model override or the client default.
+func (c *OpenAIClient) modelFor(override string) string {
+	if override != "" {
+		return override
+	}
+	return c.model
+}
+
 // Close releases any resources held by the client.
 func (c *OpenAIClient) Close() error {
 	// OpenAI client doesn't have explicit cleanup
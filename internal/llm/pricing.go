@@ -0,0 +1,51 @@
+package llm
+
+import "strings"
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost returns the price of usage at this rate.
+func (p ModelPrice) Cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*p.InputPerMillion + float64(u.CompletionTokens)*p.OutputPerMillion) / 1e6
+}
+
+// DefaultPrices lists list prices by model name prefix. Dated and suffixed
+// model names resolve to the longest matching prefix.
+var DefaultPrices = map[string]ModelPrice{
+	"gpt-4o":            {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":       {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4.1":           {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	"gpt-4.1-mini":      {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	"gpt-4.1-nano":      {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gpt-4-turbo":       {InputPerMillion: 10.00, OutputPerMillion: 30.00},
+	"gpt-3.5-turbo":     {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+	"o1":                {InputPerMillion: 15.00, OutputPerMillion: 60.00},
+	"o3-mini":           {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"claude-opus-4":     {InputPerMillion: 15.00, OutputPerMillion: 75.00},
+	"claude-opus-4-5":   {InputPerMillion: 5.00, OutputPerMillion: 25.00},
+	"claude-sonnet-4":   {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-3-7-sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00},
+	"claude-haiku-4-5":  {InputPerMillion: 1.00, OutputPerMillion: 5.00},
+	"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4.00},
+	"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+}
+
+// PriceFor returns the price of a model from DefaultPrices using the longest
+// matching name prefix. Unknown models, including local Ollama models,
+// report false.
+func PriceFor(model string) (ModelPrice, bool) {
+	var best string
+	for prefix := range DefaultPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return DefaultPrices[best], true
+}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -33,9 +34,11 @@ type ToolStreamChunk struct {
 }
 
 // ChatWithToolsStreamRequest is the request for streaming tool calls.
+// Model, when set, overrides the client's default model.
 type ChatWithToolsStreamRequest struct {
 	Messages    []Message
 	Tools       []ToolDefinition
+	Model       string
 	StrictMode  bool
 	MaxTokens   int
 	Temperature float32
@@ -75,7 +78,7 @@ func (c *OpenAIClient) ChatWithToolsStream(ctx context.Context, req *ChatWithToo
 	}
 
 	stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       c.modelFor(req.Model),
 		Messages:    messages,
 		Tools:       tools,
 		MaxTokens:   maxTokens,
@@ -309,6 +312,14 @@ type StructuredOutput struct {
 
 // ChatWithStructuredOutput requests a response matching the schema.
 func (c *OpenAIClient) ChatWithStructuredOutput(ctx context.Context, req *ChatRequest, output StructuredOutput) (*ChatResponse, error) {
+	ctx, span := startChatSpan(ctx, ProviderOpenAI, c.modelFor(req.Model), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	resp, err := c.chatWithStructuredOutput(ctx, req, output)
+	endChatSpan(span, resp, err)
+	return resp, err
+}
+
+// chatWithStructuredOutput implements ChatWithStructuredOutput without tracing.
+func (c *OpenAIClient) chatWithStructuredOutput(ctx context.Context, req *ChatRequest, output StructuredOutput) (*ChatResponse, error) {
 	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = openai.ChatCompletionMessage{
@@ -331,7 +342,7 @@ func (c *OpenAIClient) ChatWithStructuredOutput(ctx context.Context, req *ChatRe
 	schemaBytes, _ := json.Marshal(output.Schema)
 
 	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       c.modelFor(req.Model),
 		Messages:    messages,
 		MaxTokens:   maxTokens,
 		Temperature: temperature,
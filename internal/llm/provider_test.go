@@ -274,3 +274,29 @@ func TestOllamaClientCreation(t *testing.T) {
 		_ = client.Close()
 	})
 }
+
+func TestPriceFor(t *testing.T) {
+	tests := []struct {
+		model string
+		want  ModelPrice
+		found bool
+	}{
+		{"gpt-4o-mini-2024-07-18", DefaultPrices["gpt-4o-mini"], true},
+		{"gpt-4o", DefaultPrices["gpt-4o"], true},
+		{ClaudeOpus45, DefaultPrices["claude-opus-4-5"], true},
+		{ClaudeOpus4, DefaultPrices["claude-opus-4"], true},
+		{OllamaLlama3_2, ModelPrice{}, false},
+	}
+
+	for _, tt := range tests {
+		got, found := PriceFor(tt.model)
+		if got != tt.want || found != tt.found {
+			t.Errorf("PriceFor(%q) = %v, %v; want %v, %v", tt.model, got, found, tt.want, tt.found)
+		}
+	}
+
+	cost := DefaultPrices["gpt-4o"].Cost(Usage{PromptTokens: 1000, CompletionTokens: 500})
+	if cost != 0.0075 {
+		t.Errorf("expected cost 0.0075, got %v", cost)
+	}
+}
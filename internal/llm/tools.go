@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -30,9 +31,11 @@ type FunctionDefinition struct {
 }
 
 // ChatWithToolsRequest represents a request with tool definitions.
+// Model, when set, overrides the client's default model.
 type ChatWithToolsRequest struct {
 	Messages    []Message
 	Tools       []ToolDefinition
+	Model       string
 	MaxTokens   int
 	Temperature float32
 }
@@ -64,6 +67,14 @@ type ToolClient interface {
 
 // ChatWithTools sends a chat completion request with tool definitions.
 func (c *OpenAIClient) ChatWithTools(ctx context.Context, req *ChatWithToolsRequest) (*ChatWithToolsResponse, error) {
+	ctx, span := startChatSpan(ctx, ProviderOpenAI, c.modelFor(req.Model), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	resp, err := c.chatWithTools(ctx, req)
+	endToolsSpan(span, resp, err)
+	return resp, err
+}
+
+// chatWithTools implements ChatWithTools without tracing.
+func (c *OpenAIClient) chatWithTools(ctx context.Context, req *ChatWithToolsRequest) (*ChatWithToolsResponse, error) {
 	messages := convertMessages(req.Messages)
 	tools := convertTools(req.Tools)
 
@@ -78,7 +89,7 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, req *ChatWithToolsRequ
 	}
 
 	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       c.modelFor(req.Model),
 		Messages:    messages,
 		Tools:       tools,
 		MaxTokens:   maxTokens,
@@ -107,6 +118,14 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, req *ChatWithToolsRequ
 
 // ChatWithToolResults continues a conversation after tool execution.
 func (c *OpenAIClient) ChatWithToolResults(ctx context.Context, req *ChatWithToolsRequest, toolResults []ToolMessage) (*ChatWithToolsResponse, error) {
+	ctx, span := startChatSpan(ctx, ProviderOpenAI, c.modelFor(req.Model), cmp.Or(req.MaxTokens, c.defaultMax), req.Temperature)
+	resp, err := c.chatWithToolResults(ctx, req, toolResults)
+	endToolsSpan(span, resp, err)
+	return resp, err
+}
+
+// chatWithToolResults implements ChatWithToolResults without tracing.
+func (c *OpenAIClient) chatWithToolResults(ctx context.Context, req *ChatWithToolsRequest, toolResults []ToolMessage) (*ChatWithToolsResponse, error) {
 	messages := convertMessages(req.Messages)
 
 	// Add tool result messages
@@ -131,7 +150,7 @@ func (c *OpenAIClient) ChatWithToolResults(ctx context.Context, req *ChatWithToo
 	}
 
 	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
+		Model:       c.modelFor(req.Model),
 		Messages:    messages,
 		Tools:       tools,
 		MaxTokens:   maxTokens,
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer returns the tracer for model calls. It is looked up on each use so
+// that spans go to the current global provider (see the telemetry package).
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/hassan123789/go-ai-agent/internal/llm")
+}
+
+// providerNames maps providers to their gen_ai.provider.name values.
+var providerNames = map[Provider]string{
+	ProviderOpenAI: "openai",
+	ProviderClaude: "anthropic",
+	ProviderOllama: "ollama",
+}
+
+// startChatSpan starts a client span for a chat call following the GenAI
+// semantic conventions: "chat {model}" with the request parameters.
+func startChatSpan(ctx context.Context, provider Provider, model string, maxTokens int, temperature float32) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		semconv.GenAIProviderNameKey.String(providerNames[provider]),
+		semconv.GenAIRequestModel(model),
+	}
+	if maxTokens > 0 {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(maxTokens))
+	}
+	if temperature > 0 {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(float64(temperature)))
+	}
+
+	return tracer().Start(ctx, "chat "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endChatSpan records the outcome of a chat call and ends its span.
+func endChatSpan(span trace.Span, resp *ChatResponse, err error) {
+	if resp != nil {
+		setResponseAttributes(span, resp.FinishReason, resp.Usage)
+	}
+	endSpan(span, err)
+}
+
+// endToolsSpan records the outcome of a function calling request and ends its span.
+func endToolsSpan(span trace.Span, resp *ChatWithToolsResponse, err error) {
+	if resp != nil {
+		setResponseAttributes(span, resp.FinishReason, resp.Usage)
+		span.SetAttributes(attribute.Int("gen_ai.response.tool_calls", len(resp.ToolCalls)))
+	}
+	endSpan(span, err)
+}
+
+// setResponseAttributes sets the finish reason and token usage of a response.
+func setResponseAttributes(span trace.Span, finishReason string, usage Usage) {
+	if finishReason != "" {
+		span.SetAttributes(semconv.GenAIResponseFinishReasons(finishReason))
+	}
+	span.SetAttributes(
+		semconv.GenAIUsageInputTokens(usage.PromptTokens),
+		semconv.GenAIUsageOutputTokens(usage.CompletionTokens),
+	)
+}
+
+// endSpan marks the span failed if err is set and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceStream forwards a stream's chunks and ends the span once the stream
+// closes, recording the finish reason or error.
+func traceStream(ctx context.Context, span trace.Span, in <-chan StreamChunk) <-chan StreamChunk {
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+
+		var finishReason string
+		var streamErr error
+		defer func() {
+			if finishReason != "" {
+				span.SetAttributes(semconv.GenAIResponseFinishReasons(finishReason))
+			}
+			endSpan(span, streamErr)
+		}()
+
+		for chunk := range in {
+			if chunk.FinishReason != "" {
+				finishReason = chunk.FinishReason
+			}
+			if chunk.Error != nil {
+				streamErr = chunk.Error
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				streamErr = ctx.Err()
+				// Drain so the producer can finish
+				for range in {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/hassan123789/go-ai-agent/internal/telemetry"
+)
+
+// newOllamaTestServer serves canned OpenAI-compatible chat completions.
+func newOllamaTestServer(t *testing.T, stream bool) *OllamaClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, `data: {"choices":[{"index":0,"delta":{"content":"Hel"}}]}`+"\n\n")
+			_, _ = fmt.Fprint(w, `data: {"choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`+"\n\n")
+			_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewOllamaClient(OllamaConfig{BaseURL: server.URL, Model: "llama3.2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client
+}
+
+// spanAttrs indexes a span's attributes by key.
+func spanAttrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestChat_Tracing(t *testing.T) {
+	exporter, restore := telemetry.SetupInMemory()
+	defer restore()
+
+	client := newOllamaTestServer(t, false)
+	if _, err := client.Chat(context.Background(), &ChatRequest{
+		Messages:    []Message{{Role: RoleUser, Content: "Hi"}},
+		Temperature: 0.2,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "chat llama3.2" {
+		t.Errorf("expected span 'chat llama3.2', got %q", spans[0].Name)
+	}
+
+	attrs := spanAttrs(spans[0])
+	checks := map[attribute.Key]string{
+		"gen_ai.operation.name":      "chat",
+		"gen_ai.provider.name":       "ollama",
+		"gen_ai.request.model":       "llama3.2",
+		"gen_ai.usage.input_tokens":  "12",
+		"gen_ai.usage.output_tokens": "3",
+		"gen_ai.request.max_tokens":  "2048",
+	}
+	for key, want := range checks {
+		if got := attrs[key].Emit(); got != want {
+			t.Errorf("expected %s=%s, got %q", key, want, got)
+		}
+	}
+	if got := attrs["gen_ai.response.finish_reasons"].AsStringSlice(); len(got) != 1 || got[0] != "stop" {
+		t.Errorf("expected finish reason stop, got %v", got)
+	}
+}
+
+func TestChatStream_Tracing(t *testing.T) {
+	exporter, restore := telemetry.SetupInMemory()
+	defer restore()
+
+	client := newOllamaTestServer(t, true)
+	stream, err := client.ChatStream(context.Background(), &ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var content string
+	for chunk := range stream {
+		if chunk.Error != nil {
+			t.Fatalf("unexpected stream error: %v", chunk.Error)
+		}
+		content += chunk.Content
+	}
+	if content != "Hello" {
+		t.Errorf("expected streamed content 'Hello', got %q", content)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected the span to end with the stream, got %d spans", len(spans))
+	}
+	if spans[0].Status.Code == codes.Error {
+		t.Errorf("unexpected error status: %s", spans[0].Status.Description)
+	}
+}
+
+func TestChat_TracingError(t *testing.T) {
+	exporter, restore := telemetry.SetupInMemory()
+	defer restore()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"message":"model not found"}}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewOllamaClient(OllamaConfig{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Chat(context.Background(), &ChatRequest{Messages: []Message{{Role: RoleUser, Content: "Hi"}}}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected 1 errored span, got %+v", spans)
+	}
+}
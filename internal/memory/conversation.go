@@ -10,11 +10,19 @@ import (
 // Each session has its own memory buffer.
 type ConversationManager struct {
 	sessions   map[string]*BufferMemory
+	locks      map[string]*sessionLock
 	activeID   string
 	defaultCfg BufferConfig
 	mu         sync.RWMutex
 }
 
+// sessionLock serializes turns within one session.
+// refs counts holders and waiters so idle locks can be dropped.
+type sessionLock struct {
+	ch   chan struct{}
+	refs int
+}
+
 // NewConversationManager creates a new conversation manager.
 func NewConversationManager(defaultCfg BufferConfig) *ConversationManager {
 	if defaultCfg.MaxSize <= 0 {
@@ -22,6 +30,7 @@ func NewConversationManager(defaultCfg BufferConfig) *ConversationManager {
 	}
 	return &ConversationManager{
 		sessions:   make(map[string]*BufferMemory),
+		locks:      make(map[string]*sessionLock),
 		activeID:   "",
 		defaultCfg: defaultCfg,
 	}
@@ -173,3 +182,43 @@ func (cm *ConversationManager) GetFromSession(ctx context.Context, sessionID str
 	}
 	return session.Get(ctx, limit)
 }
+
+// LockSession acquires exclusive use of a session so that concurrent turns
+// are not interleaved. It blocks until the session is free or ctx is done.
+// The returned unlock function is safe to call more than once.
+func (cm *ConversationManager) LockSession(ctx context.Context, id string) (func(), error) {
+	cm.mu.Lock()
+	l, exists := cm.locks[id]
+	if !exists {
+		l = &sessionLock{ch: make(chan struct{}, 1)}
+		cm.locks[id] = l
+	}
+	l.refs++
+	cm.mu.Unlock()
+
+	select {
+	case l.ch <- struct{}{}:
+	case <-ctx.Done():
+		cm.releaseLock(id, l)
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-l.ch
+			cm.releaseLock(id, l)
+		})
+	}, nil
+}
+
+// releaseLock drops a reference to a session lock, removing it when unused.
+func (cm *ConversationManager) releaseLock(id string, l *sessionLock) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	l.refs--
+	if l.refs == 0 {
+		delete(cm.locks, id)
+	}
+}
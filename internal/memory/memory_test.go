@@ -2,7 +2,9 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 )
 
 func TestBufferMemory_Add(t *testing.T) {
@@ -201,6 +203,54 @@ func TestConversationManager_ActiveSession(t *testing.T) {
 	}
 }
 
+func TestConversationManager_LockSession(t *testing.T) {
+	cm := NewConversationManager(DefaultBufferConfig())
+	ctx := context.Background()
+
+	unlock, err := cm.LockSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("LockSession failed: %v", err)
+	}
+
+	// Other sessions are independent
+	unlockOther, err := cm.LockSession(ctx, "s2")
+	if err != nil {
+		t.Fatalf("LockSession for another session failed: %v", err)
+	}
+	unlockOther()
+
+	// A second turn on the same session waits until the deadline
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := cm.LockSession(waitCtx, "s1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded while locked, got %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock2, err := cm.LockSession(ctx, "s1")
+		if err == nil {
+			unlock2()
+		}
+		close(acquired)
+	}()
+
+	unlock()
+	unlock() // safe to call twice
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiting turn was not released")
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if len(cm.locks) != 0 {
+		t.Errorf("expected idle locks to be dropped, got %d", len(cm.locks))
+	}
+}
+
 func TestNewMessage(t *testing.T) {
 	msg := NewMessage(RoleUser, "Hello")
 
@@ -0,0 +1,192 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/vectorstore"
+)
+
+// NoAnswer is the answer given when nothing relevant is retrieved.
+const NoAnswer = "I couldn't find anything relevant to this question in the knowledge base."
+
+// QAChain answers questions from retrieved documents, citing them by number.
+type QAChain struct {
+	llm       llm.Client
+	retriever Retriever
+	config    QAConfig
+}
+
+// QAConfig contains configuration for a QAChain.
+type QAConfig struct {
+	// Model overrides the LLM client's default model when set.
+	Model string
+
+	// Temperature is the sampling temperature. Zero uses the client default.
+	Temperature float32
+
+	// TopK is the number of documents retrieved. Default is 4.
+	TopK int
+
+	// MinScore drops retrieved documents scoring below it.
+	MinScore float32
+
+	// MaxContextTokens bounds the documents placed in the prompt, estimated
+	// at four characters per token. Documents are added most relevant
+	// first; the last one that does not fit is truncated. Default is 3000.
+	MaxContextTokens int
+
+	// SystemPrompt instructs the model how to answer.
+	SystemPrompt string
+
+	// Verbose enables logging of retrieval.
+	Verbose bool
+}
+
+// Source is a retrieved document as numbered in the prompt.
+type Source struct {
+	// Index is the citation number, starting at 1.
+	Index int `json:"index"`
+
+	ID      string  `json:"id"`
+	Source  string  `json:"source"`
+	Content string  `json:"content"`
+	Score   float32 `json:"score"`
+
+	// Cited reports whether the answer cites this source.
+	Cited bool `json:"cited"`
+}
+
+// Answer is the result of a QAChain query.
+type Answer struct {
+	Answer  string    `json:"answer"`
+	Sources []Source  `json:"sources"`
+	Usage   llm.Usage `json:"usage"`
+}
+
+const defaultQAPrompt = `You answer questions using only the numbered sources provided.
+
+Rules:
+- Cite the sources that support each statement with their numbers in square brackets, e.g. [1] or [2][3].
+- If the sources do not contain the answer, say so; do not make one up.
+- Be concise.`
+
+// citationPattern matches citations such as [2].
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// NewQAChain creates a question-answering chain.
+func NewQAChain(llmClient llm.Client, retriever Retriever, config QAConfig) *QAChain {
+	if config.TopK <= 0 {
+		config.TopK = 4
+	}
+	if config.MaxContextTokens <= 0 {
+		config.MaxContextTokens = 3000
+	}
+	if config.SystemPrompt == "" {
+		config.SystemPrompt = defaultQAPrompt
+	}
+
+	return &QAChain{
+		llm:       llmClient,
+		retriever: retriever,
+		config:    config,
+	}
+}
+
+// Query retrieves documents for the question and answers it from them.
+// topK overrides the configured number of documents when positive.
+func (q *QAChain) Query(ctx context.Context, question string, topK int) (*Answer, error) {
+	if topK <= 0 {
+		topK = q.config.TopK
+	}
+
+	results, err := q.retriever.Retrieve(ctx, question, topK)
+	if err != nil {
+		return nil, fmt.Errorf("retrieval failed: %w", err)
+	}
+	sources := q.fitContext(results)
+
+	if q.config.Verbose {
+		log.Printf("[RAG] Retrieved %d documents, %d in context, for: %s", len(results), len(sources), question)
+	}
+	if len(sources) == 0 {
+		return &Answer{Answer: NoAnswer, Sources: []Source{}}, nil
+	}
+
+	resp, err := q.llm.Chat(ctx, &llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: q.config.SystemPrompt},
+			{Role: llm.RoleUser, Content: buildPrompt(question, sources)},
+		},
+		Model:       q.config.Model,
+		Temperature: q.config.Temperature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("LLM call failed: %w", err)
+	}
+
+	for _, match := range citationPattern.FindAllStringSubmatch(resp.Content, -1) {
+		n, _ := strconv.Atoi(match[1])
+		if n >= 1 && n <= len(sources) {
+			sources[n-1].Cited = true
+		}
+	}
+
+	return &Answer{
+		Answer:  resp.Content,
+		Sources: sources,
+		Usage:   resp.Usage,
+	}, nil
+}
+
+// fitContext numbers the results that pass MinScore and fit within
+// MaxContextTokens.
+func (q *QAChain) fitContext(results []vectorstore.SearchResult) []Source {
+	remaining := q.config.MaxContextTokens * 4
+	sources := make([]Source, 0, len(results))
+	for _, r := range results {
+		if r.Score < q.config.MinScore {
+			continue
+		}
+		content := strings.TrimSpace(r.Document.Content)
+		if content == "" {
+			continue
+		}
+		if len(content) > remaining {
+			// Truncate one document to fill the window, unless too little is left to help
+			if remaining < 200 {
+				break
+			}
+			content = strings.ToValidUTF8(content[:remaining], "") + "..."
+		}
+		remaining -= len(content)
+		sources = append(sources, Source{
+			Index:   len(sources) + 1,
+			ID:      r.Document.ID,
+			Source:  SourceName(r.Document),
+			Content: content,
+			Score:   r.Score,
+		})
+		if remaining <= 0 {
+			break
+		}
+	}
+	return sources
+}
+
+// buildPrompt lists the numbered sources followed by the question.
+func buildPrompt(question string, sources []Source) string {
+	var sb strings.Builder
+	sb.WriteString("Sources:\n\n")
+	for _, s := range sources {
+		sb.WriteString(fmt.Sprintf("[%d] (%s)\n%s\n\n", s.Index, s.Source, s.Content))
+	}
+	sb.WriteString("Question: ")
+	sb.WriteString(question)
+	return sb.String()
+}
@@ -0,0 +1,154 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/hassan123789/go-ai-agent/internal/embedding"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/vectorstore"
+)
+
+// chatClient answers every chat request with a fixed reply and keeps the last request.
+type chatClient struct {
+	reply string
+	last  *llm.ChatRequest
+}
+
+func (c *chatClient) Chat(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	c.last = req
+	return &llm.ChatResponse{Content: c.reply, Usage: llm.Usage{TotalTokens: 42}}, nil
+}
+
+func (c *chatClient) ChatStream(context.Context, *llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *chatClient) Close() error {
+	return nil
+}
+
+func fixedRetriever(results ...vectorstore.SearchResult) Retriever {
+	return RetrieverFunc(func(_ context.Context, _ string, limit int) ([]vectorstore.SearchResult, error) {
+		if len(results) > limit {
+			return results[:limit], nil
+		}
+		return results, nil
+	})
+}
+
+func result(id, content, source string, score float32) vectorstore.SearchResult {
+	doc := embedding.NewDocument(id, content)
+	if source != "" {
+		doc.Metadata = map[string]any{"source": source}
+	}
+	return vectorstore.SearchResult{Document: doc, Score: score}
+}
+
+func TestQAChain_Citations(t *testing.T) {
+	client := &chatClient{reply: "Refunds take 5 days [2]. Annual plans are prorated [2][9]."}
+	chain := NewQAChain(client, fixedRetriever(
+		result("a", "Shipping takes a week.", "shipping.md", 0.9),
+		result("b", "Refunds take 5 days and annual plans are prorated.", "refunds.md", 0.8),
+		result("c", "Unrelated.", "", 0.1),
+	), QAConfig{MinScore: 0.5})
+
+	answer, err := chain.Query(context.Background(), "How do refunds work?", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(answer.Sources) != 2 {
+		t.Fatalf("expected the low-scoring document to be dropped, got %+v", answer.Sources)
+	}
+	if answer.Sources[0].Cited || !answer.Sources[1].Cited || answer.Sources[1].Source != "refunds.md" {
+		t.Errorf("expected only source 2 to be cited, got %+v", answer.Sources)
+	}
+	prompt := client.last.Messages[1].Content
+	if !strings.Contains(prompt, "[2] (refunds.md)\nRefunds take 5 days") || !strings.HasSuffix(prompt, "Question: How do refunds work?") {
+		t.Errorf("unexpected prompt %q", prompt)
+	}
+	if answer.Usage.TotalTokens != 42 {
+		t.Errorf("expected usage to be reported, got %+v", answer.Usage)
+	}
+}
+
+func TestQAChain_ContextWindow(t *testing.T) {
+	client := &chatClient{reply: "ok"}
+	chain := NewQAChain(client, fixedRetriever(
+		result("a", strings.Repeat("a", 300), "", 0.9),
+		result("b", strings.Repeat("b", 300), "", 0.8),
+		result("c", strings.Repeat("c", 300), "", 0.7),
+	), QAConfig{MaxContextTokens: 125})
+
+	// 500 characters fit the first document and a truncated second one
+	answer, err := chain.Query(context.Background(), "q", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(answer.Sources) != 2 || answer.Sources[1].Content != strings.Repeat("b", 200)+"..." {
+		t.Errorf("unexpected context %+v", answer.Sources)
+	}
+}
+
+func TestQAChain_NothingRetrieved(t *testing.T) {
+	client := &chatClient{reply: "made up"}
+	answer, err := NewQAChain(client, fixedRetriever(), QAConfig{}).Query(context.Background(), "q", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answer.Answer != NoAnswer || client.last != nil {
+		t.Errorf("expected no LLM call and the fixed answer, got %q", answer.Answer)
+	}
+}
+
+func TestKnowledgeSearch(t *testing.T) {
+	tool := NewKnowledgeSearch(fixedRetriever(
+		result("refunds.md#1", "Refunds take 5 days.", "refunds.md", 0.8),
+	), 0)
+
+	res, err := tool.Execute(context.Background(), `{"query": "refunds"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.IsSuccess() || res.Output != "[1] (refunds.md, score 0.80)\nRefunds take 5 days." {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	res, _ = tool.Execute(context.Background(), `{"query": ""}`)
+	if res.IsSuccess() {
+		t.Error("expected an empty query to fail")
+	}
+}
+
+func TestChunk(t *testing.T) {
+	text := "first paragraph\n\nsecond paragraph\n\n" + strings.Repeat("word ", 10)
+	chunks := Chunk(text, 35)
+
+	want := []string{"first paragraph\n\nsecond paragraph", "word word word word word word word", "word word word"}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %q", len(want), chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("chunk %d: expected %q, got %q", i, want[i], chunks[i])
+		}
+	}
+}
+
+func TestChunk_NonASCII(t *testing.T) {
+	text := strings.Repeat("日本語のテキスト", 5)
+	chunks := Chunk(text, 16)
+
+	if strings.Join(chunks, "") != text {
+		t.Errorf("expected chunks to rejoin to the text, got %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if !utf8.ValidString(chunk) || len(chunk) > 16 {
+			t.Errorf("expected valid UTF-8 of at most 16 bytes, got %q", chunk)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package rag provides retrieval-augmented generation over a knowledge base:
+// retrievers over the vector stores, a question-answering chain that answers
+// with numbered source citations, and a knowledge_search tool for agents.
+package rag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hassan123789/go-ai-agent/internal/embedding"
+	"github.com/hassan123789/go-ai-agent/internal/vectorstore"
+)
+
+// Retriever finds the documents most relevant to a query.
+type Retriever interface {
+	// Retrieve returns up to limit results, most relevant first.
+	Retrieve(ctx context.Context, query string, limit int) ([]vectorstore.SearchResult, error)
+}
+
+// RetrieverFunc adapts a function to the Retriever interface.
+type RetrieverFunc func(ctx context.Context, query string, limit int) ([]vectorstore.SearchResult, error)
+
+// Retrieve calls f.
+func (f RetrieverFunc) Retrieve(ctx context.Context, query string, limit int) ([]vectorstore.SearchResult, error) {
+	return f(ctx, query, limit)
+}
+
+// StoreRetriever retrieves by text similarity from a SemanticStore.
+type StoreRetriever struct {
+	store vectorstore.SemanticStore
+}
+
+// NewStoreRetriever creates a retriever over store.
+func NewStoreRetriever(store vectorstore.SemanticStore) *StoreRetriever {
+	return &StoreRetriever{store: store}
+}
+
+// Retrieve searches the store for the query text.
+func (r *StoreRetriever) Retrieve(ctx context.Context, query string, limit int) ([]vectorstore.SearchResult, error) {
+	return r.store.SearchText(ctx, query, limit)
+}
+
+// RAPTORRetriever retrieves from a RAPTORStore, expanding each match with
+// the summary of its cluster.
+type RAPTORRetriever struct {
+	store *vectorstore.RAPTORStore
+}
+
+// NewRAPTORRetriever creates a retriever over store.
+func NewRAPTORRetriever(store *vectorstore.RAPTORStore) *RAPTORRetriever {
+	return &RAPTORRetriever{store: store}
+}
+
+// Retrieve performs multi-hop retrieval for the query text.
+func (r *RAPTORRetriever) Retrieve(ctx context.Context, query string, limit int) ([]vectorstore.SearchResult, error) {
+	return r.store.SearchWithContext(ctx, query, limit)
+}
+
+// SourceName returns the name a document is cited by: its "source" or
+// "title" metadata, or its ID.
+func SourceName(doc embedding.Document) string {
+	for _, key := range []string{"source", "title"} {
+		if name, ok := doc.Metadata[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	return doc.ID
+}
+
+// LoadDirectory reads the .md and .txt files under dir and splits them into
+// chunks of at most chunkSize characters at paragraph boundaries. Each chunk
+// records its file in the "source" metadata.
+func LoadDirectory(dir string, chunkSize int) ([]embedding.Document, error) {
+	if chunkSize <= 0 {
+		chunkSize = 1000
+	}
+
+	var docs []embedding.Document
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".md" && ext != ".txt") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		source, err := filepath.Rel(dir, path)
+		if err != nil {
+			source = path
+		}
+		for i, chunk := range Chunk(string(data), chunkSize) {
+			docs = append(docs, embedding.NewDocumentWithMetadata(
+				fmt.Sprintf("%s#%d", source, i+1), chunk,
+				map[string]any{"source": source, "chunk": i + 1},
+			))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load knowledge base: %w", err)
+	}
+	return docs, nil
+}
+
+// Chunk splits text into pieces of at most size bytes, joining whole
+// paragraphs where they fit and splitting longer paragraphs at spaces, or
+// between characters where a paragraph has none.
+func Chunk(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if current.Len() > 0 && current.Len()+2+len(paragraph) > size {
+			flush()
+		}
+		for len(paragraph) > size {
+			cut := strings.LastIndex(paragraph[:size], " ")
+			if cut <= 0 {
+				cut = size
+				for cut > 0 && !utf8.RuneStart(paragraph[cut]) {
+					cut--
+				}
+				if cut == 0 {
+					_, cut = utf8.DecodeRuneInString(paragraph)
+				}
+			}
+			flush()
+			current.WriteString(paragraph[:cut])
+			flush()
+			paragraph = strings.TrimSpace(paragraph[cut:])
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush()
+	return chunks
+}
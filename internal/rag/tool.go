@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// KnowledgeSearch is a tool that lets agents search the knowledge base.
+type KnowledgeSearch struct {
+	retriever Retriever
+	limit     int
+}
+
+// NewKnowledgeSearch creates a knowledge_search tool returning up to limit
+// passages per search. Default limit is 4.
+func NewKnowledgeSearch(retriever Retriever, limit int) *KnowledgeSearch {
+	if limit <= 0 {
+		limit = 4
+	}
+	return &KnowledgeSearch{retriever: retriever, limit: limit}
+}
+
+// Name returns the tool name.
+func (k *KnowledgeSearch) Name() string {
+	return "knowledge_search"
+}
+
+// Description returns what this tool does.
+func (k *KnowledgeSearch) Description() string {
+	return "Searches the knowledge base and returns the most relevant passages with their sources. Use it for questions about documents in the knowledge base."
+}
+
+// Parameters returns the JSON Schema for the tool's input.
+func (k *KnowledgeSearch) Parameters() tools.ParameterSchema {
+	return tools.ParameterSchema{
+		Type: "object",
+		Properties: map[string]tools.PropertySchema{
+			"query": {
+				Type:        "string",
+				Description: "What to search for, e.g., 'refund policy for annual plans'",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+// knowledgeSearchArgs represents the arguments for the knowledge_search tool.
+type knowledgeSearchArgs struct {
+	Query string `json:"query"`
+}
+
+// Execute searches the knowledge base and lists the passages found.
+func (k *KnowledgeSearch) Execute(ctx context.Context, arguments string) (tools.Result, error) {
+	args, err := tools.ParseArguments[knowledgeSearchArgs](arguments)
+	if err != nil {
+		return tools.Failure("Invalid arguments: " + err.Error()), nil
+	}
+	if args.Query == "" {
+		return tools.Failure("Query cannot be empty"), nil
+	}
+
+	results, err := k.retriever.Retrieve(ctx, args.Query, k.limit)
+	if err != nil {
+		return tools.Failure("Search failed: " + err.Error()), nil
+	}
+	if len(results) == 0 {
+		return tools.Success("No relevant passages found."), nil
+	}
+
+	var sb strings.Builder
+	sources := make([]string, 0, len(results))
+	for i, r := range results {
+		source := SourceName(r.Document)
+		sources = append(sources, source)
+		sb.WriteString(fmt.Sprintf("[%d] (%s, score %.2f)\n%s\n\n", i+1, source, r.Score, strings.TrimSpace(r.Document.Content)))
+	}
+	return tools.SuccessWithMetadata(strings.TrimSpace(sb.String()), map[string]any{"sources": sources}), nil
+}
@@ -0,0 +1,191 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// ErrNotRecorded is returned by a strict Player for a call the trace does not contain.
+var ErrNotRecorded = errors.New("call not recorded in trace")
+
+// ErrOffline is returned by the Offline client.
+var ErrOffline = errors.New("network calls are disabled during replay")
+
+// Player is an agent.Interceptor that answers every call from a trace and
+// never calls through.
+//
+// A call gets the first unused event with an identical request, so parallel
+// workers replay correctly whatever order they run in. When the run has
+// diverged from the recording, e.g. because a prompt changed, a lenient
+// player falls back to the next unused event of the same kind (for tools,
+// of the same tool) and counts a miss; a strict player returns ErrNotRecorded.
+type Player struct {
+	mu     sync.Mutex
+	events []Event
+	keys   []string
+	used   []bool
+	strict bool
+	misses int
+}
+
+// NewPlayer creates a player for the trace's events.
+func NewPlayer(t *Trace, strict bool) *Player {
+	p := &Player{
+		events: t.Events,
+		keys:   make([]string, len(t.Events)),
+		used:   make([]bool, len(t.Events)),
+		strict: strict,
+	}
+	for i, e := range t.Events {
+		switch e.Type {
+		case EventChat:
+			p.keys[i] = requestKey(e.ChatRequest)
+		case EventChatWithTools:
+			p.keys[i] = requestKey(e.ToolsRequest)
+		case EventTool:
+			p.keys[i] = e.Tool + "\x00" + e.Arguments
+		}
+	}
+	return p
+}
+
+// requestKey identifies a model request by its canonical JSON encoding.
+// Decoding and re-encoding sorts object keys, so that a live request with
+// typed tool schemas matches its recording, which decodes into maps.
+func requestKey(req any) string {
+	data, _ := json.Marshal(req)
+	var canonical any
+	if err := json.Unmarshal(data, &canonical); err != nil {
+		return string(data)
+	}
+	data, _ = json.Marshal(canonical)
+	return string(data)
+}
+
+// take returns the event answering a call of the given type and key.
+// fallback reports whether an unmatched event may stand in for it.
+func (p *Player) take(eventType, key string, fallback func(Event) bool) (Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, e := range p.events {
+		if !p.used[i] && e.Type == eventType && p.keys[i] == key {
+			p.used[i] = true
+			return e, nil
+		}
+	}
+	if !p.strict {
+		for i, e := range p.events {
+			if !p.used[i] && e.Type == eventType && fallback(e) {
+				p.used[i] = true
+				p.misses++
+				return e, nil
+			}
+		}
+	}
+	return Event{}, fmt.Errorf("%w: no unused %s event matches", ErrNotRecorded, eventType)
+}
+
+// recordedError returns the error an event recorded, if any.
+func recordedError(e Event) error {
+	if e.Error == "" {
+		return nil
+	}
+	return errors.New(e.Error)
+}
+
+// InterceptChat answers a chat call from the trace.
+func (p *Player) InterceptChat(_ context.Context, req *llm.ChatRequest, _ agent.ChatFunc) (*llm.ChatResponse, error) {
+	e, err := p.take(EventChat, requestKey(req), func(Event) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if err := recordedError(e); err != nil {
+		return nil, err
+	}
+	return e.ChatResponse, nil
+}
+
+// InterceptChatWithTools answers a function calling request from the trace.
+func (p *Player) InterceptChatWithTools(_ context.Context, req *llm.ChatWithToolsRequest, _ agent.ChatWithToolsFunc) (*llm.ChatWithToolsResponse, error) {
+	e, err := p.take(EventChatWithTools, requestKey(req), func(Event) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if err := recordedError(e); err != nil {
+		return nil, err
+	}
+	return e.ToolsResponse, nil
+}
+
+// InterceptTool answers a tool execution from the trace.
+func (p *Player) InterceptTool(_ context.Context, name, arguments string, _ agent.ToolFunc) (tools.Result, error) {
+	e, err := p.take(EventTool, name+"\x00"+arguments, func(e Event) bool { return e.Tool == name })
+	if err != nil {
+		return tools.Result{}, fmt.Errorf("%s(%s): %w", name, arguments, err)
+	}
+	if err := recordedError(e); err != nil {
+		return tools.Result{}, err
+	}
+	return *e.Result, nil
+}
+
+// Stats reports how the replay used the trace.
+type Stats struct {
+	// Events is the number of recorded events.
+	Events int `json:"events"`
+
+	// Used is the number of events that answered a call.
+	Used int `json:"used"`
+
+	// Misses is the number of calls answered by a fallback event because
+	// no recorded request matched exactly.
+	Misses int `json:"misses"`
+}
+
+// Stats returns the player's usage of the trace so far.
+func (p *Player) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := Stats{Events: len(p.events), Misses: p.misses}
+	for _, used := range p.used {
+		if used {
+			s.Used++
+		}
+	}
+	return s
+}
+
+// Replay re-runs the trace's query on a, answering every model call and
+// tool execution from the trace. a should be built as it was when recording,
+// with a client such as Offline so that nothing reaches the network.
+func Replay(ctx context.Context, t *Trace, a agent.Agent, strict bool) (*agent.Response, Stats, error) {
+	p := NewPlayer(t, strict)
+	resp, err := a.RunWithHistory(agent.WithInterceptor(ctx, p), t.History, t.Query)
+	return resp, p.Stats(), err
+}
+
+// offlineClient is an agent.LLMClient that refuses every call.
+type offlineClient struct{}
+
+// Offline returns a client that fails every call with ErrOffline, for
+// building agents to replay.
+func Offline() agent.LLMClient {
+	return offlineClient{}
+}
+
+func (offlineClient) Chat(context.Context, *llm.ChatRequest) (*llm.ChatResponse, error) {
+	return nil, ErrOffline
+}
+
+func (offlineClient) ChatWithTools(context.Context, *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+	return nil, ErrOffline
+}
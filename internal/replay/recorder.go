@@ -0,0 +1,128 @@
+package replay
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// Recorder is an agent.Interceptor that performs every call and keeps it as
+// a trace event.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecorder creates an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// add appends an event, numbering it in completion order.
+func (r *Recorder) add(e Event, err error) {
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.Seq = len(r.events) + 1
+	r.events = append(r.events, e)
+}
+
+// InterceptChat performs and records a chat call.
+func (r *Recorder) InterceptChat(ctx context.Context, req *llm.ChatRequest, next agent.ChatFunc) (*llm.ChatResponse, error) {
+	resp, err := next(ctx, req)
+	r.add(Event{Type: EventChat, ChatRequest: req, ChatResponse: resp}, err)
+	return resp, err
+}
+
+// InterceptChatWithTools performs and records a function calling request.
+func (r *Recorder) InterceptChatWithTools(ctx context.Context, req *llm.ChatWithToolsRequest, next agent.ChatWithToolsFunc) (*llm.ChatWithToolsResponse, error) {
+	resp, err := next(ctx, req)
+	r.add(Event{Type: EventChatWithTools, ToolsRequest: req, ToolsResponse: resp}, err)
+	return resp, err
+}
+
+// InterceptTool performs and records a tool execution.
+func (r *Recorder) InterceptTool(ctx context.Context, name, arguments string, next agent.ToolFunc) (tools.Result, error) {
+	result, err := next(ctx, name, arguments)
+	r.add(Event{Type: EventTool, Tool: name, Arguments: arguments, Result: &result}, err)
+	return result, err
+}
+
+// Events returns the recorded events in completion order.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// RecordingAgent records every run of the wrapped agent to a trace file.
+type RecordingAgent struct {
+	agent agent.Agent
+	name  string
+	dir   string
+}
+
+// NewRecordingAgent wraps a so that each run is saved to dir as
+// "{name}-{timestamp}-{random}.json". Name identifies the agent for replay.
+func NewRecordingAgent(a agent.Agent, name, dir string) *RecordingAgent {
+	return &RecordingAgent{agent: a, name: name, dir: dir}
+}
+
+// Run processes a query.
+func (r *RecordingAgent) Run(ctx context.Context, query string) (*agent.Response, error) {
+	return r.RunWithHistory(ctx, nil, query)
+}
+
+// RunWithHistory runs the wrapped agent under a Recorder and saves the trace.
+// The trace file path is returned in Response.Metadata["replay_trace"].
+// Failing to save the trace is logged and does not fail the run.
+func (r *RecordingAgent) RunWithHistory(ctx context.Context, history []agent.Message, query string) (*agent.Response, error) {
+	rec := NewRecorder()
+	resp, err := r.agent.RunWithHistory(agent.WithInterceptor(ctx, rec), history, query)
+
+	t := &Trace{
+		Version:    traceVersion,
+		ID:         newTraceID(r.name),
+		Agent:      r.name,
+		RecordedAt: time.Now().UTC(),
+		Query:      query,
+		History:    history,
+		Events:     rec.Events(),
+	}
+	if err != nil {
+		t.Error = err.Error()
+	} else {
+		t.Output = resp.Output
+	}
+
+	path := filepath.Join(r.dir, t.ID+".json")
+	if saveErr := t.Save(path); saveErr != nil {
+		log.Printf("[Replay] Failed to save trace: %v", saveErr)
+		return resp, err
+	}
+	if resp != nil {
+		if resp.Metadata == nil {
+			resp.Metadata = make(map[string]any)
+		}
+		resp.Metadata["replay_trace"] = path
+	}
+	return resp, err
+}
+
+// newTraceID returns a unique, time-ordered trace ID.
+func newTraceID(name string) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%s-%s", name, time.Now().UTC().Format("20060102T150405.000"), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,134 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// calculatorClient calls the calculator once, then answers with a reply
+// that varies per call, as a real model's might.
+type calculatorClient struct {
+	calls atomic.Int32
+}
+
+func (c *calculatorClient) Chat(_ context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	n := c.calls.Add(1)
+	if strings.Contains(req.Messages[0].Content, "orchestrator") && !strings.Contains(req.Messages[len(req.Messages)-1].Content, "Subtask Results") {
+		return &llm.ChatResponse{Content: `{"analysis": "two parts", "subtasks": [
+			{"id": "task_1", "description": "add", "worker_type": "calculator", "input": "2+2"},
+			{"id": "task_2", "description": "multiply", "worker_type": "calculator", "input": "3*3"}
+		]}`}, nil
+	}
+	return &llm.ChatResponse{Content: "combined answer " + string(rune('a'+n))}, nil
+}
+
+func (c *calculatorClient) ChatWithTools(_ context.Context, req *llm.ChatWithToolsRequest) (*llm.ChatWithToolsResponse, error) {
+	n := c.calls.Add(1)
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role == llm.RoleTool {
+		return &llm.ChatWithToolsResponse{Content: "result: " + last.Content + " #" + string(rune('a'+n))}, nil
+	}
+	return &llm.ChatWithToolsResponse{
+		ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "calculator", Arguments: `{"expression": "` + last.Content + `"}`}},
+		Usage:     llm.Usage{TotalTokens: 10},
+	}, nil
+}
+
+func newRegistry() *tools.Registry {
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewCalculator())
+	return registry
+}
+
+// record runs a through a RecordingAgent and loads the saved trace.
+func record(t *testing.T, a agent.Agent, name, query string) (*agent.Response, *Trace) {
+	t.Helper()
+	resp, err := NewRecordingAgent(a, name, t.TempDir()).Run(context.Background(), query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, ok := resp.Metadata["replay_trace"].(string)
+	if !ok || filepath.Ext(path) != ".json" {
+		t.Fatalf("expected trace path in metadata, got %v", resp.Metadata)
+	}
+	trace, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp, trace
+}
+
+func TestReplay_ReAct(t *testing.T) {
+	recorded, trace := record(t, agent.NewReActAgent(&calculatorClient{}, newRegistry(), agent.Config{}), "react", "2+2")
+
+	if trace.Agent != "react" || trace.Query != "2+2" || trace.Output != recorded.Output {
+		t.Errorf("unexpected trace header %+v", trace)
+	}
+	var types []string
+	for _, e := range trace.Events {
+		types = append(types, e.Type)
+	}
+	if strings.Join(types, ",") != "chat_with_tools,tool,chat_with_tools" {
+		t.Errorf("unexpected events %v", types)
+	}
+
+	replayed, stats, err := Replay(context.Background(), trace, agent.NewReActAgent(Offline(), newRegistry(), agent.Config{}), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.Output != recorded.Output {
+		t.Errorf("expected replay to reproduce %q, got %q", recorded.Output, replayed.Output)
+	}
+	if stats.Used != 3 || stats.Misses != 0 {
+		t.Errorf("expected all 3 events used exactly, got %+v", stats)
+	}
+}
+
+func TestReplay_Divergence(t *testing.T) {
+	_, trace := record(t, agent.NewReActAgent(&calculatorClient{}, newRegistry(), agent.Config{}), "react", "2+2")
+	trace.Query = "5+5"
+
+	// Strict replay refuses calls the trace does not contain
+	_, _, err := Replay(context.Background(), trace, agent.NewReActAgent(Offline(), newRegistry(), agent.Config{}), true)
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("expected ErrNotRecorded, got %v", err)
+	}
+
+	// Lenient replay follows the recording and counts the misses
+	replayed, stats, err := Replay(context.Background(), trace, agent.NewReActAgent(Offline(), newRegistry(), agent.Config{}), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Misses == 0 || !strings.HasPrefix(replayed.Output, "result: ") {
+		t.Errorf("expected a lenient replay with misses, got %q and %+v", replayed.Output, stats)
+	}
+}
+
+func TestReplay_OrchestratorParallelWorkers(t *testing.T) {
+	newOrchestrator := func(client agent.LLMClient) *agent.OrchestratorAgent {
+		o := agent.NewOrchestratorAgent(client, newRegistry(), agent.OrchestratorConfig{})
+		o.RegisterWorker(agent.NewCalculatorWorker())
+		return o
+	}
+
+	recorded, trace := record(t, newOrchestrator(&calculatorClient{}), "orchestrator", "add and multiply")
+
+	replayed, stats, err := Replay(context.Background(), trace, newOrchestrator(Offline()), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.Output != recorded.Output {
+		t.Errorf("expected replay to reproduce %q, got %q", recorded.Output, replayed.Output)
+	}
+	if stats.Used != stats.Events {
+		t.Errorf("expected every event to be used, got %+v", stats)
+	}
+}
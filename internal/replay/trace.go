@@ -0,0 +1,86 @@
+// Package replay records agent runs, with every model call and tool
+// execution, to trace files and re-drives agents from them without network
+// access, so that a reported answer can be reproduced, debugged step by step
+// or kept as a regression test.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hassan123789/go-ai-agent/internal/agent"
+	"github.com/hassan123789/go-ai-agent/internal/llm"
+	"github.com/hassan123789/go-ai-agent/internal/tools"
+)
+
+// traceVersion is the version of the trace file format.
+const traceVersion = 1
+
+// Trace is a recorded agent run.
+type Trace struct {
+	Version    int             `json:"version"`
+	ID         string          `json:"id"`
+	Agent      string          `json:"agent"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Query      string          `json:"query"`
+	History    []agent.Message `json:"history,omitempty"`
+	Output     string          `json:"output,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Events     []Event         `json:"events"`
+}
+
+// Event types.
+const (
+	EventChat          = "chat"
+	EventChatWithTools = "chat_with_tools"
+	EventTool          = "tool"
+)
+
+// Event is one model call or tool execution, in completion order.
+type Event struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+
+	// Set for "chat" events.
+	ChatRequest  *llm.ChatRequest  `json:"chat_request,omitempty"`
+	ChatResponse *llm.ChatResponse `json:"chat_response,omitempty"`
+
+	// Set for "chat_with_tools" events.
+	ToolsRequest  *llm.ChatWithToolsRequest  `json:"tools_request,omitempty"`
+	ToolsResponse *llm.ChatWithToolsResponse `json:"tools_response,omitempty"`
+
+	// Set for "tool" events.
+	Tool      string        `json:"tool,omitempty"`
+	Arguments string        `json:"arguments,omitempty"`
+	Result    *tools.Result `json:"result,omitempty"`
+
+	// Error is the error the call returned, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Load reads a trace file.
+func Load(path string) (*Trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read trace: %w", err)
+	}
+	var t Trace
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse trace %s: %w", path, err)
+	}
+	if t.Version != traceVersion {
+		return nil, fmt.Errorf("trace %s has unsupported version %d", path, t.Version)
+	}
+	return &t, nil
+}
+
+// Save writes the trace as indented JSON.
+func (t *Trace) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
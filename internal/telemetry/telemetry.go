@@ -0,0 +1,123 @@
+// Package telemetry configures OpenTelemetry tracing for the server.
+//
+// The llm, tools and agent packages create spans through the global tracer
+// provider, so nothing is recorded until Setup installs one. LLM calls follow
+// the OpenTelemetry GenAI semantic conventions (gen_ai.* attributes).
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// Exporter names accepted by Config.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config configures tracing.
+type Config struct {
+	// Exporter selects where spans are sent: "none" (default), "stdout" or "otlp".
+	Exporter string
+
+	// Endpoint is the OTLP/HTTP collector address, such as "localhost:4318".
+	// Empty uses the OTEL_EXPORTER_OTLP_* environment variables.
+	Endpoint string
+
+	// Insecure disables TLS for the OTLP exporter.
+	Insecure bool
+
+	// ServiceName identifies this process in traces.
+	ServiceName string
+
+	// SampleRatio is the fraction of new traces to record. Zero records all.
+	// Sampling follows the parent span for requests that carry trace context.
+	SampleRatio float64
+}
+
+// Setup installs a global tracer provider and W3C trace context propagator.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Propagate trace context even when spans are not exported, so callers'
+	// traces pass through to downstream services
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(newResource(cfg.ServiceName)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// SetupInMemory installs a global tracer provider that records spans
+// synchronously in memory, for tests. The returned function restores the
+// previous provider.
+func SetupInMemory() (*tracetest.InMemoryExporter, func()) {
+	previous := otel.GetTracerProvider()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+
+	return exporter, func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	}
+}
+
+// newResource describes the service, falling back to the SDK defaults.
+func newResource(serviceName string) *resource.Resource {
+	if serviceName == "" {
+		return resource.Default()
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return resource.Default()
+	}
+	return res
+}
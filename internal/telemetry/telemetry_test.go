@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestSetup_Exporters(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("unexpected shutdown error: %v", err)
+		}
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("unexpected shutdown error: %v", err)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		if _, err := Setup(context.Background(), Config{Exporter: "jaeger"}); err == nil {
+			t.Error("expected error for unsupported exporter")
+		}
+	})
+}
+
+func TestSetup_PropagatesTraceContext(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exporter, restore := SetupInMemory()
+	defer restore()
+
+	// An incoming traceparent header continues the caller's trace
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+
+	_, span := otel.Tracer("test").Start(ctx, "handler")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the caller's trace ID, got %s", got)
+	}
+	if got := spans[0].Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected the caller's span as parent, got %s", got)
+	}
+}
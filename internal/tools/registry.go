@@ -1,10 +1,22 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer returns the tracer for tool executions, looked up on each use so
+// that spans go to the current global provider.
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/hassan123789/go-ai-agent/internal/tools")
+}
+
 // Registry manages the collection of available tools for an agent.
 // It provides thread-safe registration and lookup of tools.
 type Registry struct {
@@ -50,6 +62,36 @@ func (r *Registry) Get(name string) Tool {
 	return r.tools[name]
 }
 
+// Execute runs the named tool with the given JSON arguments inside an
+// "execute_tool {name}" span. Failed results mark the span as an error but
+// are returned as-is for the LLM to see.
+func (r *Registry) Execute(ctx context.Context, name, arguments string) (Result, error) {
+	tool := r.Get(name)
+	if tool == nil {
+		return Result{}, fmt.Errorf("tool %q not found", name)
+	}
+
+	ctx, span := tracer().Start(ctx, "execute_tool "+name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameExecuteTool,
+			semconv.GenAIToolName(name),
+			semconv.GenAIToolDescription(tool.Description()),
+		),
+	)
+	defer span.End()
+
+	result, err := tool.Execute(ctx, arguments)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case !result.IsSuccess():
+		span.SetStatus(codes.Error, result.Error)
+	}
+	return result, err
+}
+
 // Has checks if a tool with the given name exists.
 func (r *Registry) Has(name string) bool {
 	r.mu.RLock()
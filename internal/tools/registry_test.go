@@ -1,7 +1,12 @@
 package tools
 
 import (
+	"context"
 	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/hassan123789/go-ai-agent/internal/telemetry"
 )
 
 func TestRegistry_New(t *testing.T) {
@@ -170,3 +175,36 @@ func TestRegistry_Clear(t *testing.T) {
 		t.Errorf("expected 0 tools after Clear, got %d", r.Count())
 	}
 }
+
+func TestRegistry_Execute(t *testing.T) {
+	exporter, restore := telemetry.SetupInMemory()
+	defer restore()
+
+	r := NewRegistry()
+	r.MustRegister(NewCalculator())
+
+	result, err := r.Execute(context.Background(), "calculator", `{"expression": "6*7"}`)
+	if err != nil || result.Output != "42" {
+		t.Fatalf("expected 42, got %+v (err: %v)", result, err)
+	}
+
+	result, err = r.Execute(context.Background(), "calculator", `{"expression": "1/0"}`)
+	if err != nil || result.IsSuccess() {
+		t.Fatalf("expected a failed result, got %+v (err: %v)", result, err)
+	}
+
+	if _, err := r.Execute(context.Background(), "missing", "{}"); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name != "execute_tool calculator" || spans[0].Status.Code == codes.Error {
+		t.Errorf("unexpected first span: %s %v", spans[0].Name, spans[0].Status)
+	}
+	if spans[1].Status.Code != codes.Error {
+		t.Errorf("expected failed result to mark the span as an error, got %v", spans[1].Status)
+	}
+}